@@ -15,8 +15,9 @@ import (
 // 2. Loads application configuration
 // 3. Establishes a database connection
 // 4. Initializes application components and middleware
-// 5. Configures routes and handlers
-// 6. Starts the HTTP server
+// 5. Schedules the purge of the trash
+// 6. Configures routes and handlers
+// 7. Starts the HTTP server
 func main() {
 	// Initialize logger
 	log := logger.Init(logger.PRODUCTION)
@@ -45,6 +46,9 @@ func main() {
 	// Initialize application components (repositories, services, controllers)
 	components := initialisation.InitializeAppComponents(db, cfg)
 
+	// Purge the expired items of the trash in the background
+	initialisation.StartTrashPurge(components.TrashService, &cfg.Trash, log)
+
 	// Initialize middleware components (auth, CORS)
 	middlewares, mcErr := initialisation.InitializeMiddlewareComponents(cfg, log)
 	if mcErr != nil {
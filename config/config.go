@@ -32,6 +32,10 @@ type Config struct {
 	App      AppConfig
 	Database DatabaseConfig
 	Auth     AuthConfig
+	Import   ImportConfig
+	Media    MediaConfig
+	Learning LearningConfig
+	Trash    TrashConfig
 }
 
 // AppConfig contains general application settings
@@ -54,6 +58,64 @@ type DatabaseConfig struct {
 	Port int
 }
 
+// ImportConfig contains settings for the dictionary data importers
+type ImportConfig struct {
+	// DataDir is the local directory holding the files to import
+	DataDir string `mapstructure:"dataDir"`
+}
+
+// LearningConfig contains settings for the progress of the users through the learning paths
+type LearningConfig struct {
+	// UnlockThreshold is the share, from 0 to 1, of the words of a level to review or master to unlock the next level of a path
+	UnlockThreshold float64 `mapstructure:"unlockThreshold"`
+}
+
+// TrashConfig contains settings for the deleted words, labels and levels kept in the trash
+type TrashConfig struct {
+	// RetentionDays is the number of days a deleted item stays in the trash before being purged
+	RetentionDays int `mapstructure:"retentionDays"`
+	// PurgeInterval is the delay in seconds between two purges of the expired items
+	PurgeInterval int `mapstructure:"purgeInterval"`
+}
+
+// MediaConfig contains settings for the storage of the media attached to words
+type MediaConfig struct {
+	// Backend is the storage used for media, "local" (default) or "s3"
+	Backend string `mapstructure:"backend"`
+	// LocalDir is the directory holding the media with the local backend
+	LocalDir string `mapstructure:"localDir"`
+	// PublicURL is the base URL of the API, used to build the URLs of media served by the local backend
+	PublicURL string `mapstructure:"publicUrl"`
+	// MaxAudioSize is the maximum size in bytes of an audio file
+	MaxAudioSize int64 `mapstructure:"maxAudioSize"`
+	// MaxImageSize is the maximum size in bytes of an image
+	MaxImageSize int64 `mapstructure:"maxImageSize"`
+	// AudioTypes is the list of accepted audio content types
+	AudioTypes []string `mapstructure:"audioTypes"`
+	// ImageTypes is the list of accepted image content types
+	ImageTypes []string `mapstructure:"imageTypes"`
+	// URLExpiry is the validity in seconds of the signed URLs of the s3 backend
+	URLExpiry int `mapstructure:"urlExpiry"`
+	// S3 contains the settings of the s3 backend
+	S3 S3Config `mapstructure:"s3"`
+}
+
+// S3Config contains settings for an S3-compatible object storage (AWS S3, MinIO...)
+type S3Config struct {
+	// Endpoint is the base URL of the storage, e.g. https://s3.eu-west-3.amazonaws.com
+	Endpoint string `mapstructure:"endpoint"`
+	// Region is the region used to sign requests
+	Region string `mapstructure:"region"`
+	// Bucket is the bucket holding the media
+	Bucket string `mapstructure:"bucket"`
+	// AccessKey is the access key ID used to sign requests
+	AccessKey string `mapstructure:"accessKey"`
+	// SecretKey is the secret access key used to sign requests
+	SecretKey string `mapstructure:"secretKey"`
+	// PathStyle puts the bucket in the path instead of the host name, as required by MinIO
+	PathStyle bool `mapstructure:"pathStyle"`
+}
+
 // AuthConfig contains authentication and authorization settings
 type AuthConfig struct {
 	// Keycloak contains Keycloak authentication provider settings
@@ -195,6 +257,17 @@ func loadConfig() (*Config, error) {
 		"auth.apiConfig.allowHeaders":        "APP_API_CONFIG_ALLOW_HEADERS",
 		"auth.apiConfig.accessControlMaxAge": "APP_API_CONFIG_ACCESS_CONTROL_MAX_AGE",
 		"auth.apiConfig.isCredentials":       "APP_API_CONFIG_IS_CREDENTIAL",
+		"import.dataDir":                     "APP_IMPORT_DATA_DIR",
+		"media.backend":                      "APP_MEDIA_BACKEND",
+		"media.localDir":                     "APP_MEDIA_LOCAL_DIR",
+		"media.publicUrl":                    "APP_MEDIA_PUBLIC_URL",
+		"media.s3.endpoint":                  "APP_MEDIA_S3_ENDPOINT",
+		"media.s3.region":                    "APP_MEDIA_S3_REGION",
+		"media.s3.bucket":                    "APP_MEDIA_S3_BUCKET",
+		"media.s3.accessKey":                 "APP_MEDIA_S3_ACCESS_KEY",
+		"media.s3.secretKey":                 "APP_MEDIA_S3_SECRET_KEY",
+		"media.s3.pathStyle":                 "APP_MEDIA_S3_PATH_STYLE",
+		"learning.unlockThreshold":           "APP_LEARNING_UNLOCK_THRESHOLD",
 	}
 	for key, env := range envVars {
 		if err := v.BindEnv(key, env); err != nil {
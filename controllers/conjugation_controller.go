@@ -0,0 +1,46 @@
+// Package controllers implements HTTP handlers for the application API endpoints
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/xanagit/kotoquiz-api/services"
+	"net/http"
+)
+
+// ConjugationController defines the interface for the endpoints conjugating words
+type ConjugationController interface {
+	// ListWordConjugations handles GET requests to list the forms of a verb or an adjective
+	ListWordConjugations(c *gin.Context)
+}
+
+// ConjugationControllerImpl implements the ConjugationController interface
+// It depends on the ConjugationService to conjugate the words
+type ConjugationControllerImpl struct {
+	Service services.ConjugationService
+}
+
+// Make sure that ConjugationControllerImpl implements ConjugationController
+var _ ConjugationController = (*ConjugationControllerImpl)(nil)
+
+// ListWordConjugations handles GET requests to list the forms of a verb or an adjective
+// The word ID is expected as a URL parameter, each form lists its standard answer first, then the accepted variants
+//
+// Responses:
+//   - 200 OK with an array of forms on success, empty if the word does not conjugate
+//   - 400 Bad Request if the ID is invalid
+//   - 404 Not Found if no word with the given ID exists
+//   - 500 Internal Server Error if a server error occurs
+func (cc *ConjugationControllerImpl) ListWordConjugations(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	conjugations, err := cc.Service.ListWordConjugations(id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, conjugations)
+}
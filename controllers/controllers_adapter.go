@@ -4,8 +4,12 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/services"
+	"gorm.io/gorm"
 	"net/http"
 	"strconv"
 	"strings"
@@ -23,6 +27,8 @@ const (
 	DefaultNbIdsList   = 30
 	DefaultLimitWords  = 15
 	DefaultOffsetWords = 0
+	// MaxLimitWords is the largest page of words which can be requested
+	MaxLimitWords = 100
 )
 
 var DefaultQpVals = defaultValues{
@@ -50,6 +56,7 @@ func getQueryParamList(c *gin.Context, paramName string) []string {
 }
 
 // getQueryParamInt extracts an integer query parameter with a default value
+// It answers 400 Bad Request when the parameter is invalid, the caller must then stop handling the request
 //
 // Parameters:
 //   - c: *gin.Context - The Gin context containing the request
@@ -59,7 +66,7 @@ func getQueryParamList(c *gin.Context, paramName string) []string {
 // Returns:
 //   - int - The parsed integer value or default if not found/invalid
 func getQueryParamInt(c *gin.Context, paramName string, defaultValue int) (int, error) {
-	rawParam := c.Param(paramName)
+	rawParam := c.Query(paramName)
 	param := defaultValue
 
 	var err error
@@ -67,7 +74,10 @@ func getQueryParamInt(c *gin.Context, paramName string, defaultValue int) (int,
 		param, err = strconv.Atoi(rawParam)
 	}
 
-	if err != nil || param < 0 {
+	if err == nil && param < 0 {
+		err = fmt.Errorf("negative '%s' parameter", paramName)
+	}
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid '" + paramName + "' parameter"})
 		return 0, err
 	}
@@ -124,3 +134,71 @@ func parseUUIDs(ids []string) ([]uuid.UUID, bool) {
 	}
 	return parsed, true
 }
+
+// setETag exposes the version of a resource as a strong entity tag
+//
+// Parameters:
+//   - c: *gin.Context - The Gin context of the response
+//   - version: int - The version of the returned resource
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// getIfMatchVersion extracts the version required by the If-Match header, writing a 400 response when it is invalid
+//
+// Parameters:
+//   - c: *gin.Context - The Gin context containing the request
+//
+// Returns:
+//   - int - The required version, 0 when the header is absent or is "*"
+//   - bool - false if the header is not an entity tag given by setETag
+func getIfMatchVersion(c *gin.Context) (int, bool) {
+	ifMatch := strings.TrimSpace(c.GetHeader("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, true
+	}
+	unquoted, err := strconv.Unquote(ifMatch)
+	if err == nil {
+		if version, err := strconv.Atoi(unquoted); err == nil && version > 0 {
+			return version, true
+		}
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid If-Match header"})
+	return 0, false
+}
+
+// errorStatus maps an error returned by the services to an HTTP status code
+//
+// Parameters:
+//   - err: error - The error returned by a service
+//
+// Returns:
+//   - int - 400 for invalid input, 403 for forbidden changes, 404 for missing records, 409 for conflicting states,
+//     412 for outdated versions, 413 and 415 for rejected media, 500 otherwise
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidFurigana), errors.Is(err, services.ErrInvalidPartOfSpeech),
+		errors.Is(err, services.ErrInvalidConjugationForm), errors.Is(err, services.ErrInvalidWordMetadata),
+		errors.Is(err, services.ErrInvalidWordRelation), errors.Is(err, services.ErrInvalidLevel),
+		errors.Is(err, services.ErrInvalidRating), errors.Is(err, services.ErrInvalidLabel),
+		errors.Is(err, services.ErrInvalidLearningPath), errors.Is(err, services.ErrLabelCycle),
+		errors.Is(err, services.ErrInvalidLabelMerge), errors.Is(err, services.ErrInvalidWordReference),
+		errors.Is(err, services.ErrInvalidMergePatch), errors.Is(err, services.ErrInvalidBulkOperation):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrWordRelationExists), errors.Is(err, services.ErrLevelNotPublished),
+		errors.Is(err, services.ErrLabelInUse), errors.Is(err, services.ErrKanjiExists):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, services.ErrMediaTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, services.ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
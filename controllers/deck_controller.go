@@ -0,0 +1,307 @@
+// Package controllers implements HTTP handlers for the application API endpoints
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/middlewares"
+	"github.com/xanagit/kotoquiz-api/models"
+	"github.com/xanagit/kotoquiz-api/services"
+	"net/http"
+)
+
+// DeckController defines the interface for the endpoints sharing custom levels as decks
+type DeckController interface {
+	// PublishLevel handles POST requests to publish a custom level of the user as a deck
+	PublishLevel(c *gin.Context)
+	// UnpublishLevel handles DELETE requests to make a deck private again
+	UnpublishLevel(c *gin.Context)
+	// PushLevel handles POST requests to offer the current words of a deck to its clones
+	PushLevel(c *gin.Context)
+	// ReadLevelUpdate handles GET requests to list the changes of the source deck of a clone
+	ReadLevelUpdate(c *gin.Context)
+	// AcceptLevelUpdate handles POST requests to apply the changes of the source deck of a clone
+	AcceptLevelUpdate(c *gin.Context)
+	// ListDecks handles GET requests to browse the public decks
+	ListDecks(c *gin.Context)
+	// ReadDeck handles GET requests to retrieve a deck from its share code
+	ReadDeck(c *gin.Context)
+	// CloneDeck handles POST requests to copy a deck into a level of the user
+	CloneDeck(c *gin.Context)
+	// RateDeck handles PUT requests to rate a deck
+	RateDeck(c *gin.Context)
+}
+
+// DeckControllerImpl implements the DeckController interface
+// It depends on the DeckService for business logic operations
+type DeckControllerImpl struct {
+	Service services.DeckService
+}
+
+// Make sure that DeckControllerImpl implements DeckController
+var _ DeckController = (*DeckControllerImpl)(nil)
+
+// PublishLevel handles POST requests to publish a custom level of the user as a deck
+// The level gets a share code, kept when it is published again, and its current words are pushed to the deck
+//
+// Query Parameters:
+//   - visibility: SHARED to share the deck with its code only, PUBLIC to list it in the gallery (default: SHARED)
+//
+// Responses:
+//   - 200 OK with the published level on success
+//   - 400 Bad Request if the ID or the visibility is invalid
+//   - 401 Unauthorized if the user cannot be identified
+//   - 403 Forbidden if the level is a built-in level or the level of another user
+//   - 404 Not Found if no level with the given ID is visible to the user
+//   - 500 Internal Server Error if a server error occurs
+func (dc *DeckControllerImpl) PublishLevel(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	userID, err := middlewares.GetUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unable to get user ID from token"})
+		return
+	}
+
+	level, err := dc.Service.PublishLevel(userID, id, models.LevelVisibility(c.Query("visibility")))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, level)
+}
+
+// UnpublishLevel handles DELETE requests to make a deck private again
+// Its clones keep their words but no longer receive its updates
+//
+// Responses:
+//   - 204 No Content on success
+//   - 400 Bad Request if the ID is invalid
+//   - 401 Unauthorized if the user cannot be identified
+//   - 403 Forbidden if the level is a built-in level or the level of another user
+//   - 404 Not Found if no level with the given ID is visible to the user
+//   - 500 Internal Server Error if a server error occurs
+func (dc *DeckControllerImpl) UnpublishLevel(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	userID, err := middlewares.GetUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unable to get user ID from token"})
+		return
+	}
+
+	if err := dc.Service.UnpublishLevel(userID, id); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// PushLevel handles POST requests to offer the current words of a deck to its clones
+// The revision of the deck is incremented, the clones accept the changes when they wish
+//
+// Responses:
+//   - 200 OK with the level and its new revision on success
+//   - 400 Bad Request if the ID is invalid
+//   - 401 Unauthorized if the user cannot be identified
+//   - 403 Forbidden if the level is a built-in level or the level of another user
+//   - 404 Not Found if no level with the given ID is visible to the user
+//   - 409 Conflict if the level is not published
+//   - 500 Internal Server Error if a server error occurs
+func (dc *DeckControllerImpl) PushLevel(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	userID, err := middlewares.GetUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unable to get user ID from token"})
+		return
+	}
+
+	level, err := dc.Service.PushLevel(userID, id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, level)
+}
+
+// ReadLevelUpdate handles GET requests to list the changes of the source deck of a clone
+// The words added to and removed from the deck since the clone was last synced are listed
+//
+// Responses:
+//   - 200 OK with the changes on success, empty when the clone is up to date
+//   - 400 Bad Request if the ID is invalid
+//   - 401 Unauthorized if the user cannot be identified
+//   - 403 Forbidden if the level is a built-in level or the level of another user
+//   - 404 Not Found if no level with the given ID is visible to the user
+//   - 409 Conflict if the level is not a clone or if its source deck is no longer published
+//   - 500 Internal Server Error if a server error occurs
+func (dc *DeckControllerImpl) ReadLevelUpdate(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	userID, err := middlewares.GetUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unable to get user ID from token"})
+		return
+	}
+
+	update, err := dc.Service.ReadLevelUpdate(userID, id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, update)
+}
+
+// AcceptLevelUpdate handles POST requests to apply the changes of the source deck of a clone
+// The words the user added or removed on their own are left as they are
+//
+// Responses:
+//   - 200 OK with the updated level on success
+//   - 400 Bad Request if the ID is invalid
+//   - 401 Unauthorized if the user cannot be identified
+//   - 403 Forbidden if the level is a built-in level or the level of another user
+//   - 404 Not Found if no level with the given ID is visible to the user
+//   - 409 Conflict if the level is not a clone or if its source deck is no longer published
+//   - 500 Internal Server Error if a server error occurs
+func (dc *DeckControllerImpl) AcceptLevelUpdate(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	userID, err := middlewares.GetUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unable to get user ID from token"})
+		return
+	}
+
+	level, err := dc.Service.AcceptLevelUpdate(userID, id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, level)
+}
+
+// ListDecks handles GET requests to browse the public decks page by page
+//
+// Query Parameters:
+//   - sort: Order of the decks, by clone count (clones) or by rating (rating) (default: clones)
+//   - limit: Maximum number of decks to return (default: 15, at most 100)
+//   - offset: Number of decks to skip (default: 0)
+//   - lang: Language code for the labels (default: "en")
+//
+// Responses:
+//   - 200 OK with the page of decks and the total number of public decks on success
+//   - 400 Bad Request if the parameters are invalid
+//   - 500 Internal Server Error if a server error occurs
+func (dc *DeckControllerImpl) ListDecks(c *gin.Context) {
+	sort := dto.DeckSort(c.Query("sort"))
+	if sort != "" && !sort.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'sort' parameter"})
+		return
+	}
+	limit, err := getQueryParamInt(c, "limit", DefaultQpVals.LimitWords)
+	if err != nil {
+		return
+	}
+	offset, err := getQueryParamInt(c, "offset", DefaultQpVals.OffsetWords)
+	if err != nil {
+		return
+	}
+	lang := getQueryParamLang(c)
+
+	page, err := dc.Service.ListDecks(sort, min(limit, MaxLimitWords), offset, lang)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// ReadDeck handles GET requests to retrieve a deck from its share code
+//
+// Query Parameters:
+//   - lang: Language code for the labels (default: "en")
+//
+// Responses:
+//   - 200 OK with the deck on success
+//   - 404 Not Found if no deck has the given share code
+//   - 500 Internal Server Error if a server error occurs
+func (dc *DeckControllerImpl) ReadDeck(c *gin.Context) {
+	deck, err := dc.Service.ReadDeck(c.Param("code"), getQueryParamLang(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, deck)
+}
+
+// CloneDeck handles POST requests to copy a deck into a private level of the user
+// The clone has its own words, it follows the deck through the updates the user accepts
+//
+// Responses:
+//   - 201 Created with the created level on success
+//   - 401 Unauthorized if the user cannot be identified
+//   - 404 Not Found if no deck has the given share code
+//   - 500 Internal Server Error if a server error occurs
+func (dc *DeckControllerImpl) CloneDeck(c *gin.Context) {
+	userID, err := middlewares.GetUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unable to get user ID from token"})
+		return
+	}
+
+	level, err := dc.Service.CloneDeck(userID, c.Param("code"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, level)
+}
+
+// RateDeck handles PUT requests to rate a deck, replacing the previous score of the user
+// The score is expected in the request body
+//
+// Query Parameters:
+//   - lang: Language code for the labels (default: "en")
+//
+// Responses:
+//   - 200 OK with the deck and its updated ratings on success
+//   - 400 Bad Request if the score is not between 1 and 5
+//   - 401 Unauthorized if the user cannot be identified
+//   - 403 Forbidden if the deck belongs to the user
+//   - 404 Not Found if no deck has the given share code
+//   - 500 Internal Server Error if a server error occurs
+func (dc *DeckControllerImpl) RateDeck(c *gin.Context) {
+	userID, err := middlewares.GetUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unable to get user ID from token"})
+		return
+	}
+	var request dto.DeckRatingRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deck, err := dc.Service.RateDeck(userID, c.Param("code"), request.Score, getQueryParamLang(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, deck)
+}
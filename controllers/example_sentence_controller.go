@@ -0,0 +1,196 @@
+// Package controllers implements HTTP handlers for the application API endpoints
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/xanagit/kotoquiz-api/models"
+	"github.com/xanagit/kotoquiz-api/services"
+	"net/http"
+)
+
+// ExampleSentenceController defines the interface for example sentence management HTTP endpoints
+type ExampleSentenceController interface {
+	// ListExampleSentences handles GET requests to retrieve all example sentences
+	ListExampleSentences(c *gin.Context)
+	// ReadExampleSentence handles GET requests to retrieve a specific example sentence by ID
+	ReadExampleSentence(c *gin.Context)
+	// CreateExampleSentence handles POST requests to create a new example sentence
+	CreateExampleSentence(c *gin.Context)
+	// UpdateExampleSentence handles PUT requests to update an existing example sentence
+	UpdateExampleSentence(c *gin.Context)
+	// DeleteExampleSentence handles DELETE requests to remove an example sentence
+	DeleteExampleSentence(c *gin.Context)
+	// OverrideFurigana handles PUT requests to replace the computed furigana of a sentence
+	OverrideFurigana(c *gin.Context)
+	// ResetFurigana handles DELETE requests to compute the furigana of a sentence again
+	ResetFurigana(c *gin.Context)
+}
+
+// ExampleSentenceControllerImpl implements the ExampleSentenceController interface
+// It depends on the ExampleSentenceService for business logic operations
+type ExampleSentenceControllerImpl struct {
+	Service services.ExampleSentenceService
+}
+
+// Make sure that ExampleSentenceControllerImpl implements ExampleSentenceController
+var _ ExampleSentenceController = (*ExampleSentenceControllerImpl)(nil)
+
+// ListExampleSentences handles GET requests to retrieve all example sentences
+//
+// Responses:
+//   - 200 OK with an array of example sentences on success
+//   - 500 Internal Server Error if a server error occurs
+func (ec *ExampleSentenceControllerImpl) ListExampleSentences(c *gin.Context) {
+	sentences, err := ec.Service.ListExampleSentences()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sentences)
+}
+
+// ReadExampleSentence handles GET requests to retrieve a specific example sentence by ID
+// The sentence comes with the IDs of the words it illustrates
+//
+// Responses:
+//   - 200 OK with the example sentence on success
+//   - 400 Bad Request if the ID is invalid
+//   - 404 Not Found if no example sentence with the given ID exists
+func (ec *ExampleSentenceControllerImpl) ReadExampleSentence(c *gin.Context) {
+	rawId := c.Param("id")
+	id, ok := parseUUID(rawId)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	sentence, err := ec.Service.ReadExampleSentence(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sentence)
+}
+
+// CreateExampleSentence handles POST requests to create a new example sentence
+// The sentence is linked to the words listed in wordIds
+//
+// Responses:
+//   - 201 Created with the created example sentence on success
+//   - 400 Bad Request if the example sentence data is invalid
+//   - 500 Internal Server Error if a server error occurs
+func (ec *ExampleSentenceControllerImpl) CreateExampleSentence(c *gin.Context) {
+	var sentence models.ExampleSentence
+	if err := c.ShouldBindJSON(&sentence); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ec.Service.CreateExampleSentence(&sentence); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, sentence)
+}
+
+// UpdateExampleSentence handles PUT requests to update an existing example sentence
+// The links to the words are replaced by the ones listed in wordIds
+//
+// Responses:
+//   - 200 OK with the updated example sentence on success
+//   - 400 Bad Request if the ID or example sentence data is invalid
+//   - 500 Internal Server Error if a server error occurs
+func (ec *ExampleSentenceControllerImpl) UpdateExampleSentence(c *gin.Context) {
+	rawId := c.Param("id")
+	id, ok := parseUUID(rawId)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	var sentence models.ExampleSentence
+	if err := c.ShouldBindJSON(&sentence); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sentence.ID = id
+
+	if err := ec.Service.UpdateExampleSentence(&sentence); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sentence)
+}
+
+// DeleteExampleSentence handles DELETE requests to remove an example sentence by ID
+// The words illustrated by the sentence are kept
+//
+// Responses:
+//   - 204 No Content on successful deletion
+//   - 400 Bad Request if the ID is invalid
+//   - 500 Internal Server Error if a server error occurs
+func (ec *ExampleSentenceControllerImpl) DeleteExampleSentence(c *gin.Context) {
+	rawId := c.Param("id")
+	id, ok := parseUUID(rawId)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	if err := ec.Service.DeleteExampleSentence(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// OverrideFurigana handles PUT requests to replace the computed furigana of an example sentence
+// The sentence ID is expected as a URL parameter, and the furigana segments in the request body
+//
+// Responses:
+//   - 200 OK with the updated example sentence on success
+//   - 400 Bad Request if the ID is invalid or the segments do not spell the sentence
+//   - 404 Not Found if no example sentence with the given ID exists
+//   - 500 Internal Server Error if a server error occurs
+func (ec *ExampleSentenceControllerImpl) OverrideFurigana(c *gin.Context) {
+	rawId := c.Param("id")
+	id, ok := parseUUID(rawId)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	var furigana []models.FuriganaSegment
+	if err := c.ShouldBindJSON(&furigana); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sentence, err := ec.Service.OverrideFurigana(id, furigana)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sentence)
+}
+
+// ResetFurigana handles DELETE requests to drop the furigana of an example sentence set by hand
+// The furigana are computed again from the sentence and its reading
+//
+// Responses:
+//   - 200 OK with the updated example sentence on success
+//   - 400 Bad Request if the ID is invalid
+//   - 404 Not Found if no example sentence with the given ID exists
+//   - 500 Internal Server Error if a server error occurs
+func (ec *ExampleSentenceControllerImpl) ResetFurigana(c *gin.Context) {
+	rawId := c.Param("id")
+	id, ok := parseUUID(rawId)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	sentence, err := ec.Service.ResetFurigana(id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sentence)
+}
@@ -0,0 +1,142 @@
+// Package controllers implements HTTP handlers for the application API endpoints
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/services"
+	"net/http"
+)
+
+// ImportController defines the interface for the dictionary data import endpoints
+// Imported files are read from the data directory of the server
+type ImportController interface {
+	// ImportKanjidic handles POST requests to import a KANJIDIC2 XML file
+	ImportKanjidic(c *gin.Context)
+	// ImportKanjiVG handles POST requests to import a KanjiVG directory of SVG files
+	ImportKanjiVG(c *gin.Context)
+	// ImportKradfile handles POST requests to import the kanji components of a KRADFILE
+	ImportKradfile(c *gin.Context)
+	// ImportTatoeba handles POST requests to import example sentences from a Tatoeba TSV file
+	ImportTatoeba(c *gin.Context)
+	// ImportAccents handles POST requests to import the pitch accents of an accent dictionary
+	ImportAccents(c *gin.Context)
+	// ImportJlptLevels handles POST requests to import the JLPT levels of a word list
+	ImportJlptLevels(c *gin.Context)
+	// ImportFrequencyRanks handles POST requests to import the ranks of a corpus frequency list
+	ImportFrequencyRanks(c *gin.Context)
+}
+
+// ImportControllerImpl implements the ImportController interface
+// It depends on the ImportService for the parsing and storage of the data
+type ImportControllerImpl struct {
+	Service services.ImportService
+}
+
+// Make sure that ImportControllerImpl implements ImportController
+var _ ImportController = (*ImportControllerImpl)(nil)
+
+// ImportKanjidic handles POST requests to import a KANJIDIC2 XML file
+// The file path, relative to the data directory, is expected in the request body
+//
+// Responses:
+//   - 200 OK with the import report on success
+//   - 400 Bad Request if the request body is invalid
+//   - 500 Internal Server Error if the file can not be read or stored
+func (ic *ImportControllerImpl) ImportKanjidic(c *gin.Context) {
+	ic.runImport(c, ic.Service.ImportKanjidic)
+}
+
+// ImportKanjiVG handles POST requests to import a KanjiVG directory of SVG files
+// The directory path, relative to the data directory, is expected in the request body
+//
+// Responses:
+//   - 200 OK with the import report on success
+//   - 400 Bad Request if the request body is invalid
+//   - 500 Internal Server Error if the files can not be read or stored
+func (ic *ImportControllerImpl) ImportKanjiVG(c *gin.Context) {
+	ic.runImport(c, ic.Service.ImportKanjiVG)
+}
+
+// ImportKradfile handles POST requests to import the kanji components of a UTF-8 KRADFILE
+// The file path, relative to the data directory, is expected in the request body
+//
+// Responses:
+//   - 200 OK with the import report on success
+//   - 400 Bad Request if the request body is invalid
+//   - 500 Internal Server Error if the file can not be read or stored
+func (ic *ImportControllerImpl) ImportKradfile(c *gin.Context) {
+	ic.runImport(c, ic.Service.ImportKradfile)
+}
+
+// ImportTatoeba handles POST requests to import example sentences from a Tatoeba sentence pairs TSV file
+// The file path, relative to the data directory, is expected in the request body
+// New sentences are linked to the words they contain
+//
+// Query Parameters:
+//   - lang: Language of the translations of the file, "en" or "fr" (default: "en")
+//
+// Responses:
+//   - 200 OK with the import report on success
+//   - 400 Bad Request if the request body or the language is invalid
+//   - 500 Internal Server Error if the file can not be read or stored
+func (ic *ImportControllerImpl) ImportTatoeba(c *gin.Context) {
+	lang := getQueryParamLang(c)
+	if lang != "en" && lang != "fr" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported language"})
+		return
+	}
+
+	ic.runImport(c, func(path string) (*dto.ImportReport, error) {
+		return ic.Service.ImportTatoeba(path, lang)
+	})
+}
+
+// ImportAccents handles POST requests to import the pitch accents of a tab-separated accent dictionary
+// The file path, relative to the data directory, is expected in the request body
+//
+// Responses:
+//   - 200 OK with the import report on success
+//   - 400 Bad Request if the request body is invalid
+//   - 500 Internal Server Error if the file can not be read or stored
+func (ic *ImportControllerImpl) ImportAccents(c *gin.Context) {
+	ic.runImport(c, ic.Service.ImportAccents)
+}
+
+// ImportJlptLevels handles POST requests to import the JLPT levels of a tab-separated word list
+// The file path, relative to the data directory, is expected in the request body
+//
+// Responses:
+//   - 200 OK with the import report on success
+//   - 400 Bad Request if the request body is invalid
+//   - 500 Internal Server Error if the file can not be read or stored
+func (ic *ImportControllerImpl) ImportJlptLevels(c *gin.Context) {
+	ic.runImport(c, ic.Service.ImportJlptLevels)
+}
+
+// ImportFrequencyRanks handles POST requests to import the ranks of a tab-separated corpus frequency list
+// The file path, relative to the data directory, is expected in the request body
+//
+// Responses:
+//   - 200 OK with the import report on success
+//   - 400 Bad Request if the request body is invalid
+//   - 500 Internal Server Error if the file can not be read or stored
+func (ic *ImportControllerImpl) ImportFrequencyRanks(c *gin.Context) {
+	ic.runImport(c, ic.Service.ImportFrequencyRanks)
+}
+
+// runImport binds the import request and runs the given import function
+func (ic *ImportControllerImpl) runImport(c *gin.Context, importFunc func(path string) (*dto.ImportReport, error)) {
+	var request dto.ImportRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := importFunc(request.Path)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
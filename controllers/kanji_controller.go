@@ -0,0 +1,195 @@
+// Package controllers implements HTTP handlers for the application API endpoints
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/xanagit/kotoquiz-api/models"
+	"github.com/xanagit/kotoquiz-api/services"
+	"net/http"
+)
+
+// KanjiController defines the interface for kanji-related HTTP endpoints
+// It provides admin management of kanji and their consultation by app users
+type KanjiController interface {
+	// ListKanji handles GET requests to retrieve all kanji
+	ListKanji(c *gin.Context)
+	// ReadKanji handles GET requests to retrieve a specific kanji by ID
+	ReadKanji(c *gin.Context)
+	// CreateKanji handles POST requests to create a new kanji
+	CreateKanji(c *gin.Context)
+	// UpdateKanji handles PUT requests to update an existing kanji
+	UpdateKanji(c *gin.Context)
+	// DeleteKanji handles DELETE requests to remove a kanji
+	DeleteKanji(c *gin.Context)
+	// ReadKanjiDto handles GET requests to retrieve a kanji with the words using it
+	ReadKanjiDto(c *gin.Context)
+	// LookupKanji handles GET requests to find kanji by their components
+	LookupKanji(c *gin.Context)
+}
+
+// KanjiControllerImpl implements the KanjiController interface
+// It depends on the KanjiService for business logic operations
+type KanjiControllerImpl struct {
+	Service services.KanjiService
+}
+
+// Make sure that KanjiControllerImpl implements KanjiController
+var _ KanjiController = (*KanjiControllerImpl)(nil)
+
+// ListKanji handles GET requests to retrieve all kanji
+// Kanji are sorted by stroke count
+//
+// Responses:
+//   - 200 OK with an array of kanji on success
+//   - 500 Internal Server Error if a server error occurs
+func (kc *KanjiControllerImpl) ListKanji(c *gin.Context) {
+	kanji, err := kc.Service.ListKanji()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, kanji)
+}
+
+// ReadKanji handles GET requests to retrieve a specific kanji by ID
+// The kanji ID is expected as a URL parameter
+//
+// Responses:
+//   - 200 OK with the kanji data on success
+//   - 400 Bad Request if the ID is invalid
+//   - 404 Not Found if no kanji with the given ID exists
+func (kc *KanjiControllerImpl) ReadKanji(c *gin.Context) {
+	rawId := c.Param("id")
+	id, ok := parseUUID(rawId)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	kanji, err := kc.Service.ReadKanji(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, kanji)
+}
+
+// CreateKanji handles POST requests to create a new kanji
+// The kanji is linked to the existing words containing its character
+//
+// Responses:
+//   - 201 Created with the created kanji on success
+//   - 400 Bad Request if the kanji data is invalid
+//   - 409 Conflict if a kanji with the same character already exists
+//   - 500 Internal Server Error if a server error occurs
+func (kc *KanjiControllerImpl) CreateKanji(c *gin.Context) {
+	var kanji models.Kanji
+	if err := c.ShouldBindJSON(&kanji); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := kc.Service.CreateKanji(&kanji); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, kanji)
+}
+
+// UpdateKanji handles PUT requests to update an existing kanji
+// The kanji ID is expected as a URL parameter, and the updated kanji data in the request body
+//
+// Responses:
+//   - 200 OK with the updated kanji on success
+//   - 400 Bad Request if the ID or kanji data is invalid
+//   - 404 Not Found if no kanji with the given ID exists
+//   - 409 Conflict if another kanji has the same character
+//   - 500 Internal Server Error if a server error occurs
+func (kc *KanjiControllerImpl) UpdateKanji(c *gin.Context) {
+	rawId := c.Param("id")
+	id, ok := parseUUID(rawId)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	var kanji models.Kanji
+	if err := c.ShouldBindJSON(&kanji); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	kanji.ID = id
+
+	if err := kc.Service.UpdateKanji(&kanji); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, kanji)
+}
+
+// DeleteKanji handles DELETE requests to remove a kanji by ID
+// The words using the kanji are kept
+//
+// Responses:
+//   - 204 No Content on successful deletion
+//   - 400 Bad Request if the ID is invalid
+//   - 500 Internal Server Error if a server error occurs
+func (kc *KanjiControllerImpl) DeleteKanji(c *gin.Context) {
+	rawId := c.Param("id")
+	id, ok := parseUUID(rawId)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	if err := kc.Service.DeleteKanji(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ReadKanjiDto handles GET requests to retrieve a kanji with all the words using it
+// The URL parameter can either be the kanji ID or the kanji character itself
+//
+// Query Parameters:
+//   - lang: Language code for meanings and translations (default: "en")
+//
+// Responses:
+//   - 200 OK with the kanji DTO on success
+//   - 404 Not Found if the kanji does not exist
+//   - 500 Internal Server Error if a server error occurs
+func (kc *KanjiControllerImpl) ReadKanjiDto(c *gin.Context) {
+	lang := getQueryParamLang(c)
+
+	kanjiDto, err := kc.Service.ReadKanjiDto(c.Param("id"), lang)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, kanjiDto)
+}
+
+// LookupKanji handles GET requests to find the kanji containing all the given components
+// Results are sorted by stroke count and come with the words using them
+//
+// Query Parameters:
+//   - components: Comma-separated list of components, e.g. "氵,木"
+//   - lang: Language code for meanings and translations (default: "en")
+//
+// Responses:
+//   - 200 OK with an array of kanji DTOs on success
+//   - 400 Bad Request if no component is provided
+//   - 500 Internal Server Error if a server error occurs
+func (kc *KanjiControllerImpl) LookupKanji(c *gin.Context) {
+	components := getQueryParamList(c, "components")
+	if len(components) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one component is required"})
+		return
+	}
+	lang := getQueryParamLang(c)
+
+	kanjiDtos, err := kc.Service.LookupKanji(components, lang)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, kanjiDtos)
+}
@@ -0,0 +1,144 @@
+// Package controllers implements HTTP handlers for the application API endpoints
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/xanagit/kotoquiz-api/models"
+	"github.com/xanagit/kotoquiz-api/services"
+	"net/http"
+)
+
+// LabelController defines the interface for the HTTP endpoints managing the labels of one type,
+// like the categories or the level names of the levels
+type LabelController interface {
+	// ListLabels handles GET requests to retrieve all the labels of the type
+	ListLabels(c *gin.Context)
+	// CreateLabel handles POST requests to create a new label
+	CreateLabel(c *gin.Context)
+	// ReadLabel handles GET requests to retrieve a specific label by ID
+	ReadLabel(c *gin.Context)
+	// UpdateLabel handles PUT requests to update an existing label
+	UpdateLabel(c *gin.Context)
+	// DeleteLabel handles DELETE requests to remove a label
+	DeleteLabel(c *gin.Context)
+}
+
+// LabelControllerImpl implements the LabelController interface for the labels of the given type
+// It depends on the LabelService for business logic operations
+type LabelControllerImpl struct {
+	Service services.LabelService
+	Type    models.LabelType
+}
+
+// Make sure that LabelControllerImpl implements LabelController
+var _ LabelController = (*LabelControllerImpl)(nil)
+
+// ListLabels handles GET requests to retrieve all the labels of the type
+//
+// Responses:
+//   - 200 OK with an array of labels on success
+//   - 500 Internal Server Error if a server error occurs
+func (lc *LabelControllerImpl) ListLabels(c *gin.Context) {
+	labels, err := lc.Service.ListLabels(lc.Type)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, labels)
+}
+
+// CreateLabel handles POST requests to create a new label
+// The label data is expected in the request body as JSON, its type is set by the controller
+//
+// Responses:
+//   - 201 Created with the created label on success
+//   - 400 Bad Request if the label data is invalid or the parent of a tag is not a tag
+//   - 404 Not Found if the parent of a tag does not exist
+//   - 500 Internal Server Error if a server error occurs
+func (lc *LabelControllerImpl) CreateLabel(c *gin.Context) {
+	var label models.Label
+	if err := c.ShouldBindJSON(&label); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := lc.Service.CreateLabel(&label, lc.Type); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, label)
+}
+
+// ReadLabel handles GET requests to retrieve a specific label by ID
+//
+// Responses:
+//   - 200 OK with the label on success
+//   - 400 Bad Request if the ID is invalid
+//   - 404 Not Found if no label of the type has the given ID
+//   - 500 Internal Server Error if a server error occurs
+func (lc *LabelControllerImpl) ReadLabel(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	label, err := lc.Service.ReadLabel(id, lc.Type)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, label)
+}
+
+// UpdateLabel handles PUT requests to update the texts of an existing label
+// The change is seen by all the levels using the label
+//
+// Responses:
+//   - 200 OK with the updated label on success
+//   - 400 Bad Request if the ID or label data is invalid
+//   - 404 Not Found if no label of the type has the given ID
+//   - 500 Internal Server Error if a server error occurs
+func (lc *LabelControllerImpl) UpdateLabel(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	var label models.Label
+	if err := c.ShouldBindJSON(&label); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	label.ID = id
+
+	if err := lc.Service.UpdateLabel(&label, lc.Type); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, label)
+}
+
+// DeleteLabel handles DELETE requests to remove a label by ID
+// A label still used by words or levels is only deleted when forced
+//
+// Query Parameters:
+//   - force: true to delete the label anyway, it is detached from the words and levels using it once purged from the trash
+//
+// Responses:
+//   - 204 No Content on successful deletion
+//   - 400 Bad Request if the ID is invalid
+//   - 404 Not Found if no label of the type has the given ID
+//   - 409 Conflict if the label is still used and the deletion is not forced
+//   - 500 Internal Server Error if a server error occurs
+func (lc *LabelControllerImpl) DeleteLabel(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	if err := lc.Service.DeleteLabel(id, lc.Type, c.Query("force") == "true"); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
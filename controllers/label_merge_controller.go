@@ -0,0 +1,93 @@
+// Package controllers implements HTTP handlers for the application API endpoints
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"github.com/xanagit/kotoquiz-api/services"
+	"net/http"
+)
+
+// maxLabelDistance bounds the edits allowed between near-duplicate labels, short labels being all close beyond
+const maxLabelDistance = 3
+
+// LabelMergeController defines the interface for the endpoints cleaning up duplicate labels
+type LabelMergeController interface {
+	// ListDuplicateLabels handles GET requests to find the duplicate labels of a type
+	ListDuplicateLabels(c *gin.Context)
+	// MergeLabels handles POST requests to merge labels into a surviving label
+	MergeLabels(c *gin.Context)
+}
+
+// LabelMergeControllerImpl implements the LabelMergeController interface
+// It depends on the LabelService for business logic operations
+type LabelMergeControllerImpl struct {
+	Service services.LabelService
+}
+
+// Make sure that LabelMergeControllerImpl implements LabelMergeController
+var _ LabelMergeController = (*LabelMergeControllerImpl)(nil)
+
+// ListDuplicateLabels handles GET requests to find the duplicate labels of a type
+// Labels are duplicates when their texts are the same ignoring case and spaces, or within maxDistance edits in both languages
+// The labels of custom levels belong to their owners and are not reported
+//
+// Query Parameters:
+//   - type: Type of the labels to compare, TAG, CATEGORY, LEVEL_NAME or TRANSLATION (default: TAG)
+//   - maxDistance: Number of edits allowed between near-duplicates, from 0 to 3 (default: 0, exact duplicates only)
+//
+// Responses:
+//   - 200 OK with the groups of duplicate labels, the most used label of each group first
+//   - 400 Bad Request if the parameters are invalid
+//   - 500 Internal Server Error if a server error occurs
+func (mc *LabelMergeControllerImpl) ListDuplicateLabels(c *gin.Context) {
+	labelType := models.LabelType(c.Query("type"))
+	if labelType == "" {
+		labelType = models.Tag
+	}
+	if !labelType.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'type' parameter"})
+		return
+	}
+	maxDistance, err := getQueryParamInt(c, "maxDistance", 0)
+	if err != nil {
+		return
+	}
+	if maxDistance < 0 || maxDistance > maxLabelDistance {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'maxDistance' parameter"})
+		return
+	}
+
+	report, err := mc.Service.ListDuplicateLabels(labelType, maxDistance)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// MergeLabels handles POST requests to merge labels into a surviving label
+// The words, senses, sentences, levels and sub-tags using the merged labels are repointed to the surviving label
+// in a single transaction, then the merged labels are deleted
+//
+// Responses:
+//   - 200 OK with the surviving label and the number of repointed references on success
+//   - 400 Bad Request if the labels are not distinct labels of the same type, if a tag is merged into one of its sub-tags,
+//     or if one of the labels is used by a custom level
+//   - 404 Not Found if one of the labels does not exist
+//   - 500 Internal Server Error if a server error occurs
+func (mc *LabelMergeControllerImpl) MergeLabels(c *gin.Context) {
+	var request dto.LabelMergeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := mc.Service.MergeLabels(&request)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,174 @@
+// Package controllers implements HTTP handlers for the application API endpoints
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/xanagit/kotoquiz-api/middlewares"
+	"github.com/xanagit/kotoquiz-api/models"
+	"github.com/xanagit/kotoquiz-api/services"
+	"net/http"
+)
+
+// LearningPathController defines the interface for learning path HTTP endpoints
+type LearningPathController interface {
+	// ListLearningPaths handles GET requests to retrieve all learning paths
+	ListLearningPaths(c *gin.Context)
+	// ReadLearningPath handles GET requests to retrieve a specific learning path by ID
+	ReadLearningPath(c *gin.Context)
+	// CreateLearningPath handles POST requests to create a new learning path
+	CreateLearningPath(c *gin.Context)
+	// UpdateLearningPath handles PUT requests to update an existing learning path
+	UpdateLearningPath(c *gin.Context)
+	// DeleteLearningPath handles DELETE requests to remove a learning path
+	DeleteLearningPath(c *gin.Context)
+	// ReadLearningPathProgress handles GET requests to retrieve the progress of the user through a learning path
+	ReadLearningPathProgress(c *gin.Context)
+}
+
+// LearningPathControllerImpl implements the LearningPathController interface
+// It depends on the LearningPathService for business logic operations
+type LearningPathControllerImpl struct {
+	Service services.LearningPathService
+}
+
+// Make sure that LearningPathControllerImpl implements LearningPathController
+var _ LearningPathController = (*LearningPathControllerImpl)(nil)
+
+// ListLearningPaths handles GET requests to retrieve all learning paths
+// Each path comes with its levels in order
+//
+// Responses:
+//   - 200 OK with an array of learning paths on success
+//   - 500 Internal Server Error if a server error occurs
+func (pc *LearningPathControllerImpl) ListLearningPaths(c *gin.Context) {
+	paths, err := pc.Service.ListLearningPaths()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, paths)
+}
+
+// ReadLearningPath handles GET requests to retrieve a specific learning path by ID
+//
+// Responses:
+//   - 200 OK with the learning path on success
+//   - 400 Bad Request if the ID is invalid
+//   - 404 Not Found if no learning path with the given ID exists
+//   - 500 Internal Server Error if a server error occurs
+func (pc *LearningPathControllerImpl) ReadLearningPath(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	path, err := pc.Service.ReadLearningPath(id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, path)
+}
+
+// CreateLearningPath handles POST requests to create a new learning path
+// The steps are ordered by their positions, then numbered from 1
+//
+// Responses:
+//   - 201 Created with the created learning path on success
+//   - 400 Bad Request if the threshold is not between 0 and 1, or if a level is a custom level or is given twice
+//   - 404 Not Found if one of the levels does not exist
+//   - 500 Internal Server Error if a server error occurs
+func (pc *LearningPathControllerImpl) CreateLearningPath(c *gin.Context) {
+	var path models.LearningPath
+	if err := c.ShouldBindJSON(&path); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := pc.Service.CreateLearningPath(&path); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, path)
+}
+
+// UpdateLearningPath handles PUT requests to update an existing learning path
+// The steps of the path are replaced by the given ones
+//
+// Responses:
+//   - 200 OK with the updated learning path on success
+//   - 400 Bad Request if the ID or the learning path data is invalid
+//   - 404 Not Found if the learning path or one of the levels does not exist
+//   - 500 Internal Server Error if a server error occurs
+func (pc *LearningPathControllerImpl) UpdateLearningPath(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	var path models.LearningPath
+	if err := c.ShouldBindJSON(&path); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	path.ID = id
+
+	if err := pc.Service.UpdateLearningPath(&path); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, path)
+}
+
+// DeleteLearningPath handles DELETE requests to remove a learning path by ID
+// The levels of the path are kept
+//
+// Responses:
+//   - 204 No Content on successful deletion
+//   - 400 Bad Request if the ID is invalid
+//   - 404 Not Found if no learning path with the given ID exists
+//   - 500 Internal Server Error if a server error occurs
+func (pc *LearningPathControllerImpl) DeleteLearningPath(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	if err := pc.Service.DeleteLearningPath(id); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ReadLearningPathProgress handles GET requests to retrieve the progress of the user through a learning path
+// A level is unlocked once the user reviews or masters the configured share of the words of the previous level
+//
+// Query Parameters:
+//   - lang: Language code for the labels (default: "en")
+//
+// Responses:
+//   - 200 OK with the progress of each level of the path on success
+//   - 400 Bad Request if the ID is invalid
+//   - 401 Unauthorized if the user cannot be identified
+//   - 404 Not Found if no learning path with the given ID exists
+//   - 500 Internal Server Error if a server error occurs
+func (pc *LearningPathControllerImpl) ReadLearningPathProgress(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	userID, err := middlewares.GetUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unable to get user ID from token"})
+		return
+	}
+
+	progress, err := pc.Service.ReadLearningPathProgress(userID, id, getQueryParamLang(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, progress)
+}
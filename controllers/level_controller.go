@@ -3,6 +3,8 @@ package controllers
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/middlewares"
 	"github.com/xanagit/kotoquiz-api/models"
 	"github.com/xanagit/kotoquiz-api/services"
 	"net/http"
@@ -19,8 +21,22 @@ type LevelController interface {
 	ReadLevel(c *gin.Context)
 	// UpdateLevel handles PUT requests to update an existing level
 	UpdateLevel(c *gin.Context)
+	// PatchLevel handles PATCH requests to update some fields of a level
+	PatchLevel(c *gin.Context)
 	// DeleteLevel handles DELETE requests to remove a level
 	DeleteLevel(c *gin.Context)
+	// ReadUserLevel handles GET requests to retrieve a level visible to the user
+	ReadUserLevel(c *gin.Context)
+	// CreateUserLevel handles POST requests to create a custom level owned by the user
+	CreateUserLevel(c *gin.Context)
+	// UpdateUserLevel handles PUT requests to update a custom level owned by the user
+	UpdateUserLevel(c *gin.Context)
+	// DeleteUserLevel handles DELETE requests to remove a custom level owned by the user
+	DeleteUserLevel(c *gin.Context)
+	// AddLevelWords handles POST requests to add words to a custom level owned by the user
+	AddLevelWords(c *gin.Context)
+	// RemoveLevelWord handles DELETE requests to remove a word from a custom level owned by the user
+	RemoveLevelWord(c *gin.Context)
 }
 
 // LevelControllerImpl implements the LevelController interface
@@ -33,13 +49,19 @@ type LevelControllerImpl struct {
 var _ LevelController = (*LevelControllerImpl)(nil)
 
 // ListLevels handles GET requests to retrieve all levels
-// It returns a list of all level categories and their associated level names
+// It returns the built-in levels and the custom levels of the user, with their categories and level names
 //
 // Responses:
 //   - 200 OK with an array of levels on success
+//   - 401 Unauthorized if the user cannot be identified
 //   - 500 Internal Server Error if a server error occurs
 func (lc *LevelControllerImpl) ListLevels(c *gin.Context) {
-	levels, err := lc.Service.ListLevels()
+	userID, err := middlewares.GetUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unable to get user ID from token"})
+		return
+	}
+	levels, err := lc.Service.ListLevels(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -70,7 +92,7 @@ func (lc *LevelControllerImpl) CreateLevel(c *gin.Context) {
 }
 
 // ReadLevel handles GET requests to retrieve a specific level by ID
-// The level ID is expected as a URL parameter
+// The level ID is expected as a URL parameter, the version of the level is returned in the ETag header
 //
 // Responses:
 //   - 200 OK with the level data on success
@@ -89,11 +111,13 @@ func (lc *LevelControllerImpl) ReadLevel(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
+	setETag(c, level.Version)
 	c.JSON(http.StatusOK, level)
 }
 
 // UpdateLevel handles PUT requests to update an existing level
 // The level ID is expected as a URL parameter, and the updated level data in the request body
+// The category and the level names of a built-in level are linked by ID, their texts are kept
 //
 // Responses:
 //   - 200 OK with the updated level on success
@@ -115,9 +139,46 @@ func (lc *LevelControllerImpl) UpdateLevel(c *gin.Context) {
 	level.ID = id
 
 	if err := lc.Service.UpdateLevel(&level); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	setETag(c, level.Version)
+	c.JSON(http.StatusOK, level)
+}
+
+// PatchLevel handles PATCH requests to update some fields of a level
+// The body is a JSON merge patch (RFC 7396) of the level: members set to null are cleared,
+// missing members are kept and arrays, like the level names, are replaced as a whole.
+// The If-Match header optionally gives the ETag of the version the patch is based on
+//
+// Responses:
+//   - 200 OK with the updated level on success
+//   - 400 Bad Request if the ID, the If-Match header, the patch or the patched level are invalid
+//   - 404 Not Found if no level with the given ID exists
+//   - 412 Precondition Failed if the level was modified since the version given by If-Match
+//   - 500 Internal Server Error if a server error occurs
+func (lc *LevelControllerImpl) PatchLevel(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	version, ok := getIfMatchVersion(c)
+	if !ok {
+		return
+	}
+	patch, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	level, err := lc.Service.PatchLevel(id, patch, version)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
+	setETag(c, level.Version)
 	c.JSON(http.StatusOK, level)
 }
 
@@ -142,3 +203,192 @@ func (lc *LevelControllerImpl) DeleteLevel(c *gin.Context) {
 	}
 	c.Status(http.StatusNoContent)
 }
+
+// ReadUserLevel handles GET requests to retrieve a level visible to the user
+// Custom levels come with the IDs of their words, the private levels of other users are reported as missing
+//
+// Responses:
+//   - 200 OK with the level data on success
+//   - 400 Bad Request if the ID is invalid
+//   - 401 Unauthorized if the user cannot be identified
+//   - 404 Not Found if no level with the given ID is visible to the user
+//   - 500 Internal Server Error if a server error occurs
+func (lc *LevelControllerImpl) ReadUserLevel(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	userID, err := middlewares.GetUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unable to get user ID from token"})
+		return
+	}
+
+	level, err := lc.Service.ReadUserLevel(userID, id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, level)
+}
+
+// CreateUserLevel handles POST requests to create a custom level owned by the user
+// The level is private unless another visibility (SHARED, PUBLIC) is given
+//
+// Responses:
+//   - 201 Created with the created level on success
+//   - 400 Bad Request if the level data is invalid
+//   - 401 Unauthorized if the user cannot be identified
+//   - 500 Internal Server Error if a server error occurs
+func (lc *LevelControllerImpl) CreateUserLevel(c *gin.Context) {
+	userID, err := middlewares.GetUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unable to get user ID from token"})
+		return
+	}
+	var level models.Level
+	if err := c.ShouldBindJSON(&level); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := lc.Service.CreateUserLevel(userID, &level); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, level)
+}
+
+// UpdateUserLevel handles PUT requests to update a custom level owned by the user
+// The words of the level are managed with AddLevelWords and RemoveLevelWord
+//
+// Responses:
+//   - 200 OK with the updated level on success
+//   - 400 Bad Request if the ID or level data is invalid
+//   - 401 Unauthorized if the user cannot be identified
+//   - 403 Forbidden if the level is a built-in level or the level of another user
+//   - 404 Not Found if no level with the given ID is visible to the user
+//   - 500 Internal Server Error if a server error occurs
+func (lc *LevelControllerImpl) UpdateUserLevel(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	userID, err := middlewares.GetUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unable to get user ID from token"})
+		return
+	}
+	var level models.Level
+	if err := c.ShouldBindJSON(&level); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	level.ID = id
+
+	if err := lc.Service.UpdateUserLevel(userID, &level); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, level)
+}
+
+// DeleteUserLevel handles DELETE requests to remove a custom level owned by the user
+// The words of the level are kept
+//
+// Responses:
+//   - 204 No Content on successful deletion
+//   - 400 Bad Request if the ID is invalid
+//   - 401 Unauthorized if the user cannot be identified
+//   - 403 Forbidden if the level is a built-in level or the level of another user
+//   - 404 Not Found if no level with the given ID is visible to the user
+//   - 500 Internal Server Error if a server error occurs
+func (lc *LevelControllerImpl) DeleteUserLevel(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	userID, err := middlewares.GetUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unable to get user ID from token"})
+		return
+	}
+
+	if err := lc.Service.DeleteUserLevel(userID, id); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// AddLevelWords handles POST requests to add words to a custom level owned by the user
+// The word IDs are expected in the request body, the words already in the level are ignored
+//
+// Responses:
+//   - 200 OK with the updated level on success
+//   - 400 Bad Request if the ID or the word IDs are invalid
+//   - 401 Unauthorized if the user cannot be identified
+//   - 403 Forbidden if the level is a built-in level or the level of another user
+//   - 404 Not Found if the level is not visible to the user or if one of the words does not exist
+//   - 500 Internal Server Error if a server error occurs
+func (lc *LevelControllerImpl) AddLevelWords(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	userID, err := middlewares.GetUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unable to get user ID from token"})
+		return
+	}
+	var request dto.LevelWordsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	level, err := lc.Service.AddUserLevelWords(userID, id, request.WordIDs)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, level)
+}
+
+// RemoveLevelWord handles DELETE requests to remove a word from a custom level owned by the user
+// The word itself is kept
+//
+// Responses:
+//   - 204 No Content on successful removal
+//   - 400 Bad Request if one of the IDs is invalid
+//   - 401 Unauthorized if the user cannot be identified
+//   - 403 Forbidden if the level is a built-in level or the level of another user
+//   - 404 Not Found if the level is not visible to the user or if the word is not in the level
+//   - 500 Internal Server Error if a server error occurs
+func (lc *LevelControllerImpl) RemoveLevelWord(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	wordID, ok := parseUUID(c.Param("wordId"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	userID, err := middlewares.GetUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unable to get user ID from token"})
+		return
+	}
+
+	if err := lc.Service.RemoveUserLevelWord(userID, id, wordID); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
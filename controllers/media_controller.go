@@ -0,0 +1,132 @@
+// Package controllers implements HTTP handlers for the application API endpoints
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/services"
+	"io"
+	"net/http"
+)
+
+// mediaFormField is the multipart form field holding the uploaded file
+const mediaFormField = "file"
+
+// MediaController defines the interface for the upload of the media attached to words
+type MediaController interface {
+	// UploadWordAudio handles POST requests to upload the pronunciation audio of a word
+	UploadWordAudio(c *gin.Context)
+	// DeleteWordAudio handles DELETE requests to remove the pronunciation audio of a word
+	DeleteWordAudio(c *gin.Context)
+	// UploadWordImage handles POST requests to upload the image of a word
+	UploadWordImage(c *gin.Context)
+	// DeleteWordImage handles DELETE requests to remove the uploaded image of a word
+	DeleteWordImage(c *gin.Context)
+}
+
+// MediaControllerImpl implements the MediaController interface
+// It depends on the MediaService for the validation and storage of the media
+type MediaControllerImpl struct {
+	Service services.MediaService
+}
+
+// Make sure that MediaControllerImpl implements MediaController
+var _ MediaController = (*MediaControllerImpl)(nil)
+
+// UploadWordAudio handles POST requests to upload the pronunciation audio of a word
+// The word ID is expected as a URL parameter, and the audio file in the "file" field of a multipart form
+// The previous audio of the word is replaced
+//
+// Responses:
+//   - 200 OK with the key and URL of the stored audio on success
+//   - 400 Bad Request if the ID or the form is invalid
+//   - 404 Not Found if no word with the given ID exists
+//   - 413 Request Entity Too Large if the file exceeds the maximum audio size
+//   - 415 Unsupported Media Type if the file is not an accepted audio format
+//   - 500 Internal Server Error if the file can not be stored
+func (mc *MediaControllerImpl) UploadWordAudio(c *gin.Context) {
+	mc.uploadMedia(c, mc.Service.UploadWordAudio)
+}
+
+// DeleteWordAudio handles DELETE requests to remove the pronunciation audio of a word
+// The word ID is expected as a URL parameter
+//
+// Responses:
+//   - 204 No Content on successful deletion, or if the word has no audio
+//   - 400 Bad Request if the ID is invalid
+//   - 404 Not Found if no word with the given ID exists
+//   - 500 Internal Server Error if a server error occurs
+func (mc *MediaControllerImpl) DeleteWordAudio(c *gin.Context) {
+	mc.deleteMedia(c, mc.Service.DeleteWordAudio)
+}
+
+// UploadWordImage handles POST requests to upload the image of a word
+// The word ID is expected as a URL parameter, and the image file in the "file" field of a multipart form
+// The image is stored without its metadata in several sizes, and takes precedence over the image URL of the word
+//
+// Responses:
+//   - 200 OK with the key and the URLs of each size of the stored image on success
+//   - 400 Bad Request if the ID or the form is invalid
+//   - 404 Not Found if no word with the given ID exists
+//   - 413 Request Entity Too Large if the file or the image dimensions exceed the limits
+//   - 415 Unsupported Media Type if the file is not an accepted image format or can not be decoded
+//   - 500 Internal Server Error if the file can not be stored
+func (mc *MediaControllerImpl) UploadWordImage(c *gin.Context) {
+	mc.uploadMedia(c, mc.Service.UploadWordImage)
+}
+
+// DeleteWordImage handles DELETE requests to remove the uploaded image of a word
+// The word ID is expected as a URL parameter, the image files are kept while other words use the same image
+//
+// Responses:
+//   - 204 No Content on successful deletion, or if the word has no uploaded image
+//   - 400 Bad Request if the ID is invalid
+//   - 404 Not Found if no word with the given ID exists
+//   - 500 Internal Server Error if a server error occurs
+func (mc *MediaControllerImpl) DeleteWordImage(c *gin.Context) {
+	mc.deleteMedia(c, mc.Service.DeleteWordImage)
+}
+
+// uploadMedia reads the uploaded file of the multipart form and passes it to the given upload function
+func (mc *MediaControllerImpl) uploadMedia(c *gin.Context, uploadFunc func(uuid.UUID, string, io.Reader) (*dto.MediaDTO, error)) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	fileHeader, err := c.FormFile(mediaFormField)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing '" + mediaFormField + "' file: " + err.Error()})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	media, err := uploadFunc(id, fileHeader.Header.Get("Content-Type"), file)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, media)
+}
+
+// deleteMedia runs the given delete function for the word of the URL
+func (mc *MediaControllerImpl) deleteMedia(c *gin.Context, deleteFunc func(uuid.UUID) error) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	if err := deleteFunc(id); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,104 @@
+// Package controllers implements HTTP handlers for the application API endpoints
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/middlewares"
+	"github.com/xanagit/kotoquiz-api/models"
+	"github.com/xanagit/kotoquiz-api/services"
+	"net/http"
+)
+
+// QuizController defines the interface for the endpoints building quiz questions
+// Answers to the questions are sent back through the quiz results endpoint
+type QuizController interface {
+	// ListClozeQuestions handles GET requests to build fill-in-the-blank questions for words
+	ListClozeQuestions(c *gin.Context)
+	// ListConjugationQuestions handles GET requests to build conjugation drill questions for verbs and adjectives
+	ListConjugationQuestions(c *gin.Context)
+}
+
+// QuizControllerImpl implements the QuizController interface
+// It depends on the ClozeService and the ConjugationService to build the questions
+type QuizControllerImpl struct {
+	ClozeService       services.ClozeService
+	ConjugationService services.ConjugationService
+}
+
+// Make sure that QuizControllerImpl implements QuizController
+var _ QuizController = (*QuizControllerImpl)(nil)
+
+// ListClozeQuestions handles GET requests to build fill-in-the-blank questions from example sentences
+// Words without any example sentence containing them get no question
+//
+// Query Parameters:
+//   - ids: Comma-separated list of word IDs to build questions for
+//   - lang: Language code for the hints and translations (default: "en")
+//
+// Responses:
+//   - 200 OK with an array of cloze questions on success
+//   - 400 Bad Request if the IDs are invalid
+//   - 500 Internal Server Error if a server error occurs
+func (qc *QuizControllerImpl) ListClozeQuestions(c *gin.Context) {
+	ids, ok := parseUUIDs(getQueryParamList(c, "ids"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	if len(ids) == 0 {
+		c.JSON(http.StatusOK, []*dto.ClozeQuestion{})
+		return
+	}
+	lang := getQueryParamLang(c)
+
+	questions, err := qc.ClozeService.ListClozeQuestions(ids, lang)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, questions)
+}
+
+// ListConjugationQuestions handles GET requests to build questions asking for a form of verbs and adjectives
+// The form of each word is chosen among the forms never asked to the user or due for review
+// Words which do not conjugate get no question
+//
+// Query Parameters:
+//   - ids: Comma-separated list of word IDs to build questions for
+//   - forms: Comma-separated list of forms to choose from (default: every form of the word)
+//   - lang: Language code for the hints (default: "en")
+//
+// Responses:
+//   - 200 OK with an array of conjugation questions on success
+//   - 400 Bad Request if the IDs or the forms are invalid
+//   - 401 Unauthorized if the user ID can not be read from the token
+//   - 500 Internal Server Error if a server error occurs
+func (qc *QuizControllerImpl) ListConjugationQuestions(c *gin.Context) {
+	ids, ok := parseUUIDs(getQueryParamList(c, "ids"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	var forms []models.ConjugationForm
+	for _, form := range getQueryParamList(c, "forms") {
+		forms = append(forms, models.ConjugationForm(form))
+	}
+	userID, err := middlewares.GetUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unable to get user ID from token"})
+		return
+	}
+	if len(ids) == 0 {
+		c.JSON(http.StatusOK, []*dto.ConjugationQuestion{})
+		return
+	}
+	lang := getQueryParamLang(c)
+
+	questions, err := qc.ConjugationService.ListConjugationQuestions(userID, ids, forms, lang)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, questions)
+}
@@ -19,8 +19,14 @@ type TagController interface {
 	ReadTag(c *gin.Context)
 	// UpdateTag handles PUT requests to update an existing tag
 	UpdateTag(c *gin.Context)
+	// PatchTag handles PATCH requests to update some fields of a tag
+	PatchTag(c *gin.Context)
 	// DeleteTag handles DELETE requests to remove a tag
 	DeleteTag(c *gin.Context)
+	// ListTagTree handles GET requests to retrieve the root tags with their sub-tags
+	ListTagTree(c *gin.Context)
+	// ReadTagTree handles GET requests to retrieve a tag with its sub-tags
+	ReadTagTree(c *gin.Context)
 }
 
 // TagControllerImpl implements the TagController interface
@@ -48,11 +54,12 @@ func (tc *TagControllerImpl) ListTags(c *gin.Context) {
 }
 
 // CreateTag handles POST requests to create a new tag
-// The tag data is expected in the request body as JSON
+// The tag data is expected in the request body as JSON, the tag may be nested under a parent tag
 //
 // Responses:
 //   - 201 Created with the created tag on success
-//   - 400 Bad Request if the tag data is invalid
+//   - 400 Bad Request if the tag data is invalid or if the parent is not a tag
+//   - 404 Not Found if the parent does not exist
 //   - 500 Internal Server Error if a server error occurs
 func (tc *TagControllerImpl) CreateTag(c *gin.Context) {
 	var tag models.Label
@@ -62,7 +69,7 @@ func (tc *TagControllerImpl) CreateTag(c *gin.Context) {
 	}
 
 	if err := tc.Service.CreateLabel(&tag, models.Tag); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusCreated, tag)
@@ -83,21 +90,23 @@ func (tc *TagControllerImpl) ReadTag(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid UUID format"})
 		return
 	}
-	tag, err := tc.Service.ReadLabel(id)
+	tag, err := tc.Service.ReadLabel(id, models.Tag)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
+	setETag(c, tag.Version)
 	c.JSON(http.StatusOK, tag)
 }
 
 // UpdateTag handles PUT requests to update an existing tag
 // The tag ID is expected as a URL parameter, and the updated tag data in the request body
+// A tag cannot be nested under itself or one of its sub-tags
 //
 // Responses:
 //   - 200 OK with the updated tag on success
-//   - 400 Bad Request if the ID or tag data is invalid
-//   - 404 Not Found if no tag with the given ID exists
+//   - 400 Bad Request if the ID or tag data is invalid, if the parent is not a tag or if the nesting makes a cycle
+//   - 404 Not Found if the tag or its parent does not exist
 //   - 500 Internal Server Error if a server error occurs
 func (tc *TagControllerImpl) UpdateTag(c *gin.Context) {
 	rawId := c.Param("id")
@@ -112,20 +121,61 @@ func (tc *TagControllerImpl) UpdateTag(c *gin.Context) {
 		return
 	}
 	tag.ID = id
-	if err := tc.Service.UpdateLabel(&tag); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := tc.Service.UpdateLabel(&tag, models.Tag); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
+	setETag(c, tag.Version)
+	c.JSON(http.StatusOK, tag)
+}
+
+// PatchTag handles PATCH requests to update some fields of a tag
+// The body is a JSON merge patch (RFC 7396) of the tag, a null parentId moving the tag to the root.
+// The If-Match header optionally gives the ETag of the version the patch is based on
+//
+// Responses:
+//   - 200 OK with the updated tag on success
+//   - 400 Bad Request if the ID, the If-Match header or the patch are invalid, or if the nesting makes a cycle
+//   - 404 Not Found if the tag or its parent does not exist
+//   - 412 Precondition Failed if the tag was modified since the version given by If-Match
+//   - 500 Internal Server Error if a server error occurs
+func (tc *TagControllerImpl) PatchTag(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	version, ok := getIfMatchVersion(c)
+	if !ok {
+		return
+	}
+	patch, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tag, err := tc.Service.PatchLabel(id, models.Tag, patch, version)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	setETag(c, tag.Version)
 	c.JSON(http.StatusOK, tag)
 }
 
 // DeleteTag handles DELETE requests to remove a tag by ID
-// The tag ID is expected as a URL parameter
+// The tag ID is expected as a URL parameter, a tag still used by words is only deleted when forced
+// The sub-tags of the deleted tag are moved up to its parent
+//
+// Query Parameters:
+//   - force: true to remove the tag from its words and delete it anyway
 //
 // Responses:
 //   - 204 No Content on successful deletion
 //   - 400 Bad Request if the ID is invalid
 //   - 404 Not Found if no tag with the given ID exists
+//   - 409 Conflict if the tag is still used and the deletion is not forced
 //   - 500 Internal Server Error if a server error occurs
 func (tc *TagControllerImpl) DeleteTag(c *gin.Context) {
 	rawId := c.Param("id")
@@ -134,9 +184,46 @@ func (tc *TagControllerImpl) DeleteTag(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid UUID format"})
 		return
 	}
-	if err := tc.Service.DeleteLabel(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := tc.Service.DeleteLabel(id, models.Tag, c.Query("force") == "true"); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
+
+// ListTagTree handles GET requests to retrieve the root tags with their sub-tags
+// Sub-tags are nested at any depth, ordered by position
+//
+// Responses:
+//   - 200 OK with an array of root tags on success
+//   - 500 Internal Server Error if a server error occurs
+func (tc *TagControllerImpl) ListTagTree(c *gin.Context) {
+	tree, err := tc.Service.ListTagTree()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tree)
+}
+
+// ReadTagTree handles GET requests to retrieve a tag with its sub-tags
+// The tag ID is expected as a URL parameter
+//
+// Responses:
+//   - 200 OK with the tag and its sub-tags on success
+//   - 400 Bad Request if the ID is invalid
+//   - 404 Not Found if no tag with the given ID exists
+//   - 500 Internal Server Error if a server error occurs
+func (tc *TagControllerImpl) ReadTagTree(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	node, err := tc.Service.ReadTagTree(id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, node)
+}
@@ -0,0 +1,120 @@
+// Package controllers implements HTTP handlers for the application API endpoints
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/services"
+	"net/http"
+)
+
+// TrashController defines the interface for the endpoints managing the deleted words, labels and levels
+type TrashController interface {
+	// ListTrash handles GET requests to list the deleted items
+	ListTrash(c *gin.Context)
+	// RestoreWord handles POST requests to take a word out of the trash
+	RestoreWord(c *gin.Context)
+	// RestoreLabel handles POST requests to take a label out of the trash
+	RestoreLabel(c *gin.Context)
+	// RestoreLevel handles POST requests to take a level out of the trash
+	RestoreLevel(c *gin.Context)
+	// PurgeTrash handles POST requests to permanently delete the expired items
+	PurgeTrash(c *gin.Context)
+}
+
+// TrashControllerImpl implements the TrashController interface
+// It depends on the TrashService for business logic operations
+type TrashControllerImpl struct {
+	Service services.TrashService
+}
+
+// Make sure that TrashControllerImpl implements TrashController
+var _ TrashController = (*TrashControllerImpl)(nil)
+
+// ListTrash handles GET requests to list the deleted items, the last deleted first
+//
+// Query Parameters:
+//   - kind: Kind of the items to list, WORD, LABEL or LEVEL (default: all of them)
+//
+// Responses:
+//   - 200 OK with the deleted items and the time they will be purged at
+//   - 400 Bad Request if the kind is invalid
+//   - 500 Internal Server Error if a server error occurs
+func (tc *TrashControllerImpl) ListTrash(c *gin.Context) {
+	kind := dto.TrashKind(c.Query("kind"))
+	if kind != "" && !kind.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'kind' parameter"})
+		return
+	}
+
+	items, err := tc.Service.ListTrash(kind)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// RestoreWord handles POST requests to take a word out of the trash with its links
+//
+// Responses:
+//   - 204 No Content on success
+//   - 400 Bad Request if the ID is invalid
+//   - 404 Not Found if the word is not in the trash
+//   - 500 Internal Server Error if a server error occurs
+func (tc *TrashControllerImpl) RestoreWord(c *gin.Context) {
+	tc.restore(c, tc.Service.RestoreWord)
+}
+
+// RestoreLabel handles POST requests to take a label out of the trash
+// The label comes back with its links to the words and the levels, and its sub-tags
+//
+// Responses:
+//   - 204 No Content on success
+//   - 400 Bad Request if the ID is invalid
+//   - 404 Not Found if the label is not in the trash
+//   - 500 Internal Server Error if a server error occurs
+func (tc *TrashControllerImpl) RestoreLabel(c *gin.Context) {
+	tc.restore(c, tc.Service.RestoreLabel)
+}
+
+// RestoreLevel handles POST requests to take a level out of the trash with its words, level names and category
+//
+// Responses:
+//   - 204 No Content on success
+//   - 400 Bad Request if the ID is invalid
+//   - 404 Not Found if the level is not in the trash
+//   - 500 Internal Server Error if a server error occurs
+func (tc *TrashControllerImpl) RestoreLevel(c *gin.Context) {
+	tc.restore(c, tc.Service.RestoreLevel)
+}
+
+// PurgeTrash handles POST requests to permanently delete the items kept in the trash for longer than the retention period
+// The purge also runs on a schedule, this endpoint runs it at once
+//
+// Responses:
+//   - 200 OK with the number of purged words, labels and levels on success
+//   - 500 Internal Server Error if a server error occurs
+func (tc *TrashControllerImpl) PurgeTrash(c *gin.Context) {
+	report, err := tc.Service.PurgeTrash()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// restore takes the item whose ID is given as a URL parameter out of the trash
+func (tc *TrashControllerImpl) restore(c *gin.Context, restore func(id uuid.UUID) error) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	if err := restore(id); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -3,6 +3,7 @@ package controllers
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/xanagit/kotoquiz-api/dto"
 	"github.com/xanagit/kotoquiz-api/models"
 	"github.com/xanagit/kotoquiz-api/services"
 	"net/http"
@@ -12,7 +13,11 @@ type WordController interface {
 	ReadWord(c *gin.Context)
 	CreateWord(c *gin.Context)
 	UpdateWord(c *gin.Context)
+	PatchWord(c *gin.Context)
 	DeleteWord(c *gin.Context)
+	OverrideFurigana(c *gin.Context)
+	ResetFurigana(c *gin.Context)
+	ApplyBulkOperation(c *gin.Context)
 }
 
 // WordControllerImpl implements the WordController interface
@@ -25,7 +30,7 @@ type WordControllerImpl struct {
 var _ WordController = (*WordControllerImpl)(nil)
 
 // ReadWord handles GET requests to retrieve a word by ID
-// The word ID is expected as a URL parameter
+// The word ID is expected as a URL parameter, the version of the word is returned in the ETag header
 //
 // Responses:
 //   - 200 OK with the word data on success
@@ -44,36 +49,41 @@ func (s *WordControllerImpl) ReadWord(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
+	setETag(c, word.Version)
 	c.JSON(http.StatusOK, word)
 }
 
 // CreateWord handles POST requests to create a new word
-// The word data is expected in the request body as JSON
+// The word data is expected in the request body as JSON. Its translation, tags and levels are referenced by ID,
+// a translation or a tag without ID being created with the word
 //
 // Responses:
 //   - 201 Created with the created word on success
-//   - 400 Bad Request if the word data is invalid
+//   - 400 Bad Request if the word data, its part of speech, its furigana or one of its references are invalid, or if it has no translation
 //   - 500 Internal Server Error if a server error occurs
 func (s *WordControllerImpl) CreateWord(c *gin.Context) {
-	var word models.Word
-	if err := c.ShouldBindJSON(&word); err != nil {
+	var request dto.WordWriteRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := s.Service.CreateWord(&word); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	word, err := s.Service.CreateWord(&request)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
+	setETag(c, word.Version)
 	c.JSON(http.StatusCreated, word)
 }
 
 // UpdateWord handles PUT requests to update an existing word
-// The word ID is expected as a URL parameter, and the updated word data in the request body
+// The word ID is expected as a URL parameter, and the updated word data in the request body.
+// The tags, built-in levels, senses, readings and pitch accents of the word are replaced by the given ones
 //
 // Responses:
 //   - 200 OK with the updated word on success
-//   - 400 Bad Request if the ID or word data is invalid
+//   - 400 Bad Request if the ID, the word data or one of its references are invalid, or if it has no translation
 //   - 404 Not Found if no word with the given ID exists
 //   - 500 Internal Server Error if a server error occurs
 func (s *WordControllerImpl) UpdateWord(c *gin.Context) {
@@ -84,18 +94,55 @@ func (s *WordControllerImpl) UpdateWord(c *gin.Context) {
 		return
 	}
 
-	var word models.Word
-	if err := c.ShouldBindJSON(&word); err != nil {
+	var request dto.WordWriteRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Ensure ID in the URL matches the ID in the body
-	word.ID = id
-	if err := s.Service.UpdateWord(&word); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	word, err := s.Service.UpdateWord(id, &request)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
+	setETag(c, word.Version)
+	c.JSON(http.StatusOK, word)
+}
+
+// PatchWord handles PATCH requests to update some fields of a word
+// The body is a JSON merge patch (RFC 7396) of the body of UpdateWord: members set to null are cleared,
+// missing members are kept and arrays, like tags or levels, are replaced as a whole.
+// The texts of the translation cannot be patched, a new translation is given with "id": null
+// The If-Match header optionally gives the ETag of the version the patch is based on
+//
+// Responses:
+//   - 200 OK with the updated word on success
+//   - 400 Bad Request if the ID, the If-Match header, the patch or the patched word are invalid
+//   - 404 Not Found if no word with the given ID exists
+//   - 412 Precondition Failed if the word was modified since the version given by If-Match
+//   - 500 Internal Server Error if a server error occurs
+func (s *WordControllerImpl) PatchWord(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	version, ok := getIfMatchVersion(c)
+	if !ok {
+		return
+	}
+	patch, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	word, err := s.Service.PatchWord(id, patch, version)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	setETag(c, word.Version)
 	c.JSON(http.StatusOK, word)
 }
 
@@ -120,3 +167,80 @@ func (s *WordControllerImpl) DeleteWord(c *gin.Context) {
 	}
 	c.Status(http.StatusNoContent)
 }
+
+// OverrideFurigana handles PUT requests to replace the computed furigana of a word
+// The word ID is expected as a URL parameter, and the furigana segments in the request body
+//
+// Responses:
+//   - 200 OK with the updated word on success
+//   - 400 Bad Request if the ID is invalid or the segments do not spell the kanji of the word
+//   - 404 Not Found if no word with the given ID exists
+//   - 500 Internal Server Error if a server error occurs
+func (s *WordControllerImpl) OverrideFurigana(c *gin.Context) {
+	rawId := c.Param("id")
+	id, ok := parseUUID(rawId)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	var furigana []models.FuriganaSegment
+	if err := c.ShouldBindJSON(&furigana); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	word, err := s.Service.OverrideFurigana(id, furigana)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, word)
+}
+
+// ResetFurigana handles DELETE requests to drop the furigana of a word set by hand
+// The furigana are computed again from the kanji and the reading of the word
+//
+// Responses:
+//   - 200 OK with the updated word on success
+//   - 400 Bad Request if the ID is invalid
+//   - 404 Not Found if no word with the given ID exists
+//   - 500 Internal Server Error if a server error occurs
+func (s *WordControllerImpl) ResetFurigana(c *gin.Context) {
+	rawId := c.Param("id")
+	id, ok := parseUUID(rawId)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	word, err := s.Service.ResetFurigana(id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, word)
+}
+
+// ApplyBulkOperation handles POST requests applying one operation to many words
+// The request body gives the operation, its tags, levels or reading type, and either the IDs of the words or a filter
+// selecting them. All the words are changed within a single transaction
+//
+// Responses:
+//   - 200 OK with the report of the outcome for each word on success
+//   - 400 Bad Request if the operation, its parameters or the selection of the words are invalid, or if too many words are selected
+//   - 500 Internal Server Error if a server error occurs
+func (s *WordControllerImpl) ApplyBulkOperation(c *gin.Context) {
+	var request dto.WordBulkRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := s.Service.ApplyBulkOperation(&request)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -4,6 +4,8 @@ package controllers
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/middlewares"
+	"github.com/xanagit/kotoquiz-api/models"
 	"github.com/xanagit/kotoquiz-api/services"
 	"net/http"
 )
@@ -17,6 +19,8 @@ type WordDtoController interface {
 	ReadDtoWord(c *gin.Context)
 	// ListWordsIDs handles GET requests to retrieve a list of word IDs with filtering
 	ListDtoWords(c *gin.Context)
+	// ListCatalogue handles GET requests to browse the words page by page, with filtering and sorting
+	ListCatalogue(c *gin.Context)
 }
 
 // WordDtoControllerImpl implements the WordDtoController interface
@@ -33,18 +37,32 @@ var _ WordDtoController = (*WordDtoControllerImpl)(nil)
 //
 // Query Parameters:
 //   - tags: Comma-separated list of tag IDs to filter by
-//   - levels: Comma-separated list of level IDs to filter by
-//   - limit: Maximum number of results to return (default: 30)
-//   - offset: Number of results to skip (default: 0)
+//   - includeChildren: true to also keep the words of the sub-tags of the given tags, at any depth
+//   - levels: Comma-separated list of level IDs to filter by, built-in or custom, the custom levels the user cannot see being ignored
+//   - levelNames: Comma-separated list of level name IDs to filter by
+//   - readingTypes: Comma-separated list of reading types (ONYOMI, KUNYOMI) the words must have
+//   - reading: Reading (in kana) the words must have, primary or not
+//   - partsOfSpeech: Comma-separated list of parts of speech (NOUN, GODAN_VERB, I_ADJECTIVE...) the words must have one of
+//   - transitivity: Transitivity (TRANSITIVE, INTRANSITIVE) of the verbs to keep
+//   - jlptLevels: Comma-separated list of JLPT levels (N5...N1) the words must have one of
+//   - maxFrequencyRank: Keeps the words ranked up to this rank in the frequency list
+//   - sort: Selects the most common words first, by frequency rank (frequency) or by JLPT level (jlpt)
+//   - nb: Number of word IDs to return (default: 30)
 //
 // Responses:
 //   - 200 OK with an array of word IDs on success
 //   - 400 Bad Request if the parameters are invalid
+//   - 401 Unauthorized if the user cannot be identified
 //   - 500 Internal Server Error if a server error occurs
 func (s *WordDtoControllerImpl) ListWordsIDs(c *gin.Context) {
-	tagIds := getQueryParamList(c, "tags")
-	levelNameIds := getQueryParamList(c, "levelNames")
-	nb, _ := getQueryParamInt(c, "nb", DefaultQpVals.NbIdsList)
+	filter, ok := parseWordFilter(c)
+	if !ok {
+		return
+	}
+	nb, err := getQueryParamInt(c, "nb", DefaultQpVals.NbIdsList)
+	if err != nil {
+		return
+	}
 
 	userIDStr := c.Query("userId")
 	userID, ok := parseUUID(userIDStr)
@@ -53,7 +71,7 @@ func (s *WordDtoControllerImpl) ListWordsIDs(c *gin.Context) {
 		return
 	}
 
-	wordIdsList, err := s.WordDtoService.ListWordsIDs(userID, tagIds, levelNameIds, nb)
+	wordIdsList, err := s.WordDtoService.ListWordsIDs(userID, filter, nb)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -62,12 +80,109 @@ func (s *WordDtoControllerImpl) ListWordsIDs(c *gin.Context) {
 	c.JSON(http.StatusOK, wordIdsList)
 }
 
+// ListCatalogue handles GET requests to browse the words page by page
+// It accepts the same filters as ListWordsIDs, words are sorted by reading unless another order is requested
+//
+// Query Parameters:
+//   - tags, includeChildren, levels, levelNames, readingTypes, reading, partsOfSpeech, transitivity, jlptLevels, maxFrequencyRank: Filters of ListWordsIDs
+//   - sort: Order of the words, by frequency rank (frequency) or by JLPT level (jlpt)
+//   - limit: Maximum number of words to return (default: 15, at most 100)
+//   - offset: Number of words to skip (default: 0)
+//   - lang: Language code for translations (default: "en")
+//   - include: Comma-separated list of optional sections to add (examples, related)
+//
+// Responses:
+//   - 200 OK with the page of word DTOs and the total number of matching words on success
+//   - 400 Bad Request if the parameters are invalid
+//   - 401 Unauthorized if the user cannot be identified
+//   - 500 Internal Server Error if a server error occurs
+func (s *WordDtoControllerImpl) ListCatalogue(c *gin.Context) {
+	filter, ok := parseWordFilter(c)
+	if !ok {
+		return
+	}
+	limit, err := getQueryParamInt(c, "limit", DefaultQpVals.LimitWords)
+	if err != nil {
+		return
+	}
+	offset, err := getQueryParamInt(c, "offset", DefaultQpVals.OffsetWords)
+	if err != nil {
+		return
+	}
+	lang := getQueryParamLang(c)
+	includes := getQueryParamList(c, "include")
+
+	page, err := s.WordDtoService.ListCatalogue(filter, min(limit, MaxLimitWords), offset, lang, includes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// parseWordFilter reads the criteria selecting words from the query parameters, for the user of the token
+// It answers 400 Bad Request when a criterion is invalid, the caller must then stop handling the request
+func parseWordFilter(c *gin.Context) (*dto.WordFilter, bool) {
+	userID, err := middlewares.GetUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unable to get user ID from token"})
+		return nil, false
+	}
+	filter := &dto.WordFilter{
+		UserID:          userID,
+		TagIds:          getQueryParamList(c, "tags"),
+		IncludeChildren: c.Query("includeChildren") == "true",
+		LevelIds:        getQueryParamList(c, "levels"),
+		LevelNameIds:    getQueryParamList(c, "levelNames"),
+		Reading:         c.Query("reading"),
+		Transitivity:    models.Transitivity(c.Query("transitivity")),
+		Sort:            dto.WordSort(c.Query("sort")),
+	}
+	if _, ok := parseUUIDs(filter.LevelIds); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'levels' parameter"})
+		return nil, false
+	}
+	for _, readingType := range getQueryParamList(c, "readingTypes") {
+		filter.ReadingTypes = append(filter.ReadingTypes, models.YomiType(readingType))
+	}
+	for _, partOfSpeech := range getQueryParamList(c, "partsOfSpeech") {
+		if !models.PartOfSpeech(partOfSpeech).IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'partsOfSpeech' parameter"})
+			return nil, false
+		}
+		filter.PartsOfSpeech = append(filter.PartsOfSpeech, models.PartOfSpeech(partOfSpeech))
+	}
+	if filter.Transitivity != "" && !filter.Transitivity.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'transitivity' parameter"})
+		return nil, false
+	}
+	for _, rawLevel := range getQueryParamList(c, "jlptLevels") {
+		level, ok := models.ParseJlptLevel(rawLevel)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'jlptLevels' parameter"})
+			return nil, false
+		}
+		filter.JlptLevels = append(filter.JlptLevels, level)
+	}
+	maxFrequencyRank, err := getQueryParamInt(c, "maxFrequencyRank", 0)
+	if err != nil {
+		return nil, false
+	}
+	filter.MaxFrequencyRank = maxFrequencyRank
+	if filter.Sort != "" && !filter.Sort.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'sort' parameter"})
+		return nil, false
+	}
+	return filter, true
+}
+
 // ListDtoWords handles GET requests to retrieve words by IDs in DTO format
 // It accepts query parameters for IDs and language
 //
 // Query Parameters:
 //   - ids: Comma-separated list of word IDs to retrieve
 //   - lang: Language code for translations (default: "en")
+//   - include: Comma-separated list of optional sections to add (examples, related)
 //
 // Responses:
 //   - 200 OK with an array of word DTOs on success
@@ -80,12 +195,13 @@ func (s *WordDtoControllerImpl) ListDtoWords(c *gin.Context) {
 		return
 	}
 	lang := getQueryParamLang(c)
+	includes := getQueryParamList(c, "include")
 
 	var words []*dto.WordDTO
 	var err error
 
 	if len(ids) > 0 {
-		words, err = s.WordDtoService.ListWordsDtoByIDs(ids, lang)
+		words, err = s.WordDtoService.ListWordsDtoByIDs(ids, lang, includes)
 	} else {
 		words = []*dto.WordDTO{}
 	}
@@ -106,6 +222,7 @@ func (s *WordDtoControllerImpl) ListDtoWords(c *gin.Context) {
 //
 // Query Parameters:
 //   - lang: Language code for translations (default: "en")
+//   - include: Comma-separated list of optional sections to add (examples, related)
 //
 // Responses:
 //   - 200 OK with the word DTO on success
@@ -120,8 +237,9 @@ func (s *WordDtoControllerImpl) ReadDtoWord(c *gin.Context) {
 		return
 	}
 	lang := getQueryParamLang(c)
+	includes := getQueryParamList(c, "include")
 
-	wordDto, err := s.WordDtoService.ReadWord(id, lang)
+	wordDto, err := s.WordDtoService.ReadWord(id, lang, includes)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -47,7 +47,7 @@ var _ WordLearningHistoryController = (*WordLearningHistoryControllerImpl)(nil)
 //
 // Possible responses:
 //   - 200 OK: Quiz results successfully processed
-//   - 400 Bad Request: Invalid request format
+//   - 400 Bad Request: Invalid request format, or invalid conjugation form
 //   - 401 Unauthorized: Missing or invalid authentication token
 //   - 500 Internal Server Error: Error processing quiz results
 func (ctrl *WordLearningHistoryControllerImpl) ProcessQuizResults(c *gin.Context) {
@@ -65,7 +65,7 @@ func (ctrl *WordLearningHistoryControllerImpl) ProcessQuizResults(c *gin.Context
 	}
 
 	if err := ctrl.Service.ProcessQuizResults(userID, quizResults.Results); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
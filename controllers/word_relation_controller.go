@@ -0,0 +1,165 @@
+// Package controllers implements HTTP handlers for the application API endpoints
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/xanagit/kotoquiz-api/models"
+	"github.com/xanagit/kotoquiz-api/services"
+	"net/http"
+)
+
+// WordRelationController defines the interface for word relation management HTTP endpoints
+type WordRelationController interface {
+	// ListWordRelations handles GET requests to retrieve the relations of a word
+	ListWordRelations(c *gin.Context)
+	// ReadWordRelation handles GET requests to retrieve a specific relation by ID
+	ReadWordRelation(c *gin.Context)
+	// CreateWordRelation handles POST requests to link two words
+	CreateWordRelation(c *gin.Context)
+	// UpdateWordRelation handles PUT requests to update an existing relation
+	UpdateWordRelation(c *gin.Context)
+	// DeleteWordRelation handles DELETE requests to remove a relation
+	DeleteWordRelation(c *gin.Context)
+	// DetectHomophones handles POST requests to link all the words sharing their reading
+	DetectHomophones(c *gin.Context)
+}
+
+// WordRelationControllerImpl implements the WordRelationController interface
+// It depends on the WordRelationService for business logic operations
+type WordRelationControllerImpl struct {
+	Service services.WordRelationService
+}
+
+// Make sure that WordRelationControllerImpl implements WordRelationController
+var _ WordRelationController = (*WordRelationControllerImpl)(nil)
+
+// ListWordRelations handles GET requests to retrieve the relations of a word
+// The word ID is expected as a URL parameter, the word may be on either side of the relations
+//
+// Responses:
+//   - 200 OK with an array of relations on success
+//   - 400 Bad Request if the ID is invalid
+//   - 404 Not Found if no word with the given ID exists
+//   - 500 Internal Server Error if a server error occurs
+func (rc *WordRelationControllerImpl) ListWordRelations(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	relations, err := rc.Service.ListWordRelations(id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, relations)
+}
+
+// ReadWordRelation handles GET requests to retrieve a specific relation by ID
+//
+// Responses:
+//   - 200 OK with the relation on success
+//   - 400 Bad Request if the ID is invalid
+//   - 404 Not Found if no relation with the given ID exists
+//   - 500 Internal Server Error if a server error occurs
+func (rc *WordRelationControllerImpl) ReadWordRelation(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	relation, err := rc.Service.ReadWordRelation(id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, relation)
+}
+
+// CreateWordRelation handles POST requests to link two words
+// The words of the created relation may be swapped, relations being stored with the lowest word ID first
+//
+// Responses:
+//   - 201 Created with the created relation on success
+//   - 400 Bad Request if the relation data is invalid or if a word is related to itself
+//   - 404 Not Found if one of the words does not exist
+//   - 409 Conflict if the words are already linked by a relation of the same type
+//   - 500 Internal Server Error if a server error occurs
+func (rc *WordRelationControllerImpl) CreateWordRelation(c *gin.Context) {
+	var relation models.WordRelation
+	if err := c.ShouldBindJSON(&relation); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := rc.Service.CreateWordRelation(&relation); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, relation)
+}
+
+// UpdateWordRelation handles PUT requests to update an existing relation
+// An updated relation is considered as set by hand, the homophone detection no longer removes it
+//
+// Responses:
+//   - 200 OK with the updated relation on success
+//   - 400 Bad Request if the ID or relation data is invalid
+//   - 404 Not Found if the relation or one of the words does not exist
+//   - 409 Conflict if the words are already linked by another relation of the same type
+//   - 500 Internal Server Error if a server error occurs
+func (rc *WordRelationControllerImpl) UpdateWordRelation(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	var relation models.WordRelation
+	if err := c.ShouldBindJSON(&relation); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	relation.ID = id
+
+	if err := rc.Service.UpdateWordRelation(&relation); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, relation)
+}
+
+// DeleteWordRelation handles DELETE requests to remove a relation by ID
+// A detected homophone removed by hand comes back with the next detection
+//
+// Responses:
+//   - 204 No Content on successful deletion
+//   - 400 Bad Request if the ID is invalid
+//   - 404 Not Found if no relation with the given ID exists
+//   - 500 Internal Server Error if a server error occurs
+func (rc *WordRelationControllerImpl) DeleteWordRelation(c *gin.Context) {
+	id, ok := parseUUID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	if err := rc.Service.DeleteWordRelation(id); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DetectHomophones handles POST requests to link all the words sharing their reading
+// Detected homophones whose readings no longer match are removed, relations set by hand are kept
+//
+// Responses:
+//   - 200 OK with the number of created and removed homophones on success
+//   - 500 Internal Server Error if a server error occurs
+func (rc *WordRelationControllerImpl) DetectHomophones(c *gin.Context) {
+	report, err := rc.Service.DetectHomophones()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,13 @@
+package dto
+
+import "github.com/google/uuid"
+
+// ClozeQuestion is a fill-in-the-blank question built from an example sentence
+// The target word is replaced by a blank in Sentence and must be answered with SentenceID in the quiz results
+type ClozeQuestion struct {
+	WordID      uuid.UUID `json:"wordId"`
+	SentenceID  uuid.UUID `json:"sentenceId"`
+	Sentence    string    `json:"sentence"`
+	Translation string    `json:"translation"`
+	Hint        string    `json:"hint"`
+}
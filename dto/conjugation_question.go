@@ -0,0 +1,23 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/models"
+)
+
+// ConjugationQuestion asks for a form of a verb or an adjective
+// It must be answered with Form and the CONJUGATION mode in the quiz results
+type ConjugationQuestion struct {
+	WordID       uuid.UUID              `json:"wordId"`
+	Kanji        string                 `json:"kanji"`
+	Yomi         string                 `json:"yomi"`
+	PartOfSpeech models.PartOfSpeech    `json:"partOfSpeech"`
+	Form         models.ConjugationForm `json:"form"`
+	Hint         string                 `json:"hint"`
+}
+
+// ConjugationDTO is a form of a word, the first answer being the standard one
+type ConjugationDTO struct {
+	Form    models.ConjugationForm `json:"form"`
+	Answers []string               `json:"answers"`
+}
@@ -0,0 +1,57 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/models"
+)
+
+// DeckDTO represents a published level, with its labels in the requested language
+type DeckDTO struct {
+	ID            uuid.UUID              `json:"id"`
+	ShareCode     string                 `json:"shareCode"`
+	Category      string                 `json:"category"`
+	LevelNames    []string               `json:"levelNames"`
+	Visibility    models.LevelVisibility `json:"visibility"`
+	Revision      int                    `json:"revision"`
+	WordCount     int64                  `json:"wordCount"`
+	CloneCount    int                    `json:"cloneCount"`
+	RatingCount   int                    `json:"ratingCount"`
+	RatingAverage float64                `json:"ratingAverage"`
+}
+
+// DeckPage is a page of the public decks, with the total number of public decks
+type DeckPage struct {
+	Decks  []*DeckDTO `json:"decks"`
+	Total  int64      `json:"total"`
+	Limit  int        `json:"limit"`
+	Offset int        `json:"offset"`
+}
+
+// DeckSort is the order of the public decks
+type DeckSort string
+
+const (
+	// SortByClones orders the decks from the most cloned one
+	SortByClones DeckSort = "clones"
+	// SortByRating orders the decks from the best rated one
+	SortByRating DeckSort = "rating"
+)
+
+// IsValid tells whether the order is a known one
+func (s DeckSort) IsValid() bool {
+	return s == SortByClones || s == SortByRating
+}
+
+// DeckUpdate lists the words added to and removed from the source deck of a clone since the clone was last synced
+type DeckUpdate struct {
+	SourceLevelID  uuid.UUID   `json:"sourceLevelId"`
+	SourceRevision int         `json:"sourceRevision"`
+	Revision       int         `json:"revision"`
+	AddedWordIDs   []uuid.UUID `json:"addedWordIds"`
+	RemovedWordIDs []uuid.UUID `json:"removedWordIds"`
+}
+
+// DeckRatingRequest is the score, from 1 to 5, given to a deck
+type DeckRatingRequest struct {
+	Score int `json:"score" binding:"required"`
+}
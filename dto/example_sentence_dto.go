@@ -0,0 +1,17 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/models"
+)
+
+// ExampleSentenceDTO represents an example sentence with its translation in the requested language
+type ExampleSentenceDTO struct {
+	ID          uuid.UUID `json:"id"`
+	Japanese    string    `json:"japanese"`
+	Reading     string    `json:"reading"`
+	Translation string    `json:"translation"`
+	Source      string    `json:"source"`
+	// Furigana split the Japanese text into segments carrying their reading
+	Furigana []models.FuriganaSegment `json:"furigana"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+// ImportRequest designates the file or directory to import, relative to the import data directory
+type ImportRequest struct {
+	Path string `json:"path" binding:"required"`
+}
+
+// ImportReport summarizes the outcome of a data import
+type ImportReport struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+import "github.com/google/uuid"
+
+// KanjiDTO represents a kanji with its meanings in the requested language
+// and the words of the vocabulary using it
+type KanjiDTO struct {
+	ID          uuid.UUID  `json:"id"`
+	Character   string     `json:"character"`
+	StrokeCount int        `json:"strokeCount"`
+	Radical     string     `json:"radical"`
+	Components  []string   `json:"components"`
+	OnReadings  []string   `json:"onReadings"`
+	KunReadings []string   `json:"kunReadings"`
+	Meanings    []string   `json:"meanings"`
+	JLPT        int        `json:"jlpt"`
+	Grade       int        `json:"grade"`
+	Frequency   int        `json:"frequency"`
+	StrokePaths []string   `json:"strokePaths"`
+	Words       []*WordDTO `json:"words"`
+}
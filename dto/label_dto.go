@@ -0,0 +1,40 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/models"
+)
+
+// LabelDuplicateReport lists the groups of labels of a type whose texts are equal or close
+type LabelDuplicateReport struct {
+	Type models.LabelType `json:"type"`
+	// MaxDistance is the number of edits allowed between the texts of near-duplicate labels, 0 keeping exact duplicates
+	MaxDistance int                    `json:"maxDistance"`
+	Groups      []*LabelDuplicateGroup `json:"groups"`
+}
+
+// LabelDuplicateGroup is a set of labels which could be merged, the most used one first
+// Exact tells whether all the labels have the same texts, ignoring case and spaces
+type LabelDuplicateGroup struct {
+	Exact  bool          `json:"exact"`
+	Labels []*LabelUsage `json:"labels"`
+}
+
+// LabelUsage is a label with the number of words, senses, sentences and levels using it
+type LabelUsage struct {
+	models.Label
+	Usages int64 `json:"usages"`
+}
+
+// LabelMergeRequest designates the labels to merge into the surviving label
+type LabelMergeRequest struct {
+	TargetID  uuid.UUID   `json:"targetId" binding:"required"`
+	SourceIDs []uuid.UUID `json:"sourceIds" binding:"required"`
+}
+
+// LabelMergeReport tells how many labels were merged into the surviving label, and how many references were repointed
+type LabelMergeReport struct {
+	Target    *models.Label `json:"target"`
+	Merged    int           `json:"merged"`
+	Repointed int64         `json:"repointed"`
+}
@@ -0,0 +1,29 @@
+package dto
+
+import "github.com/google/uuid"
+
+// LearningPathProgress represents the progress of a user through a learning path, with its labels in the requested language
+type LearningPathProgress struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	// UnlockThreshold is the share of the words of a level to review or master to unlock the next level
+	UnlockThreshold float64 `json:"unlockThreshold"`
+	// CurrentLevelID is the last unlocked level, nil when the path has no level
+	CurrentLevelID *uuid.UUID                  `json:"currentLevelId"`
+	Completed      bool                        `json:"completed"`
+	Steps          []*LearningPathStepProgress `json:"steps"`
+}
+
+// LearningPathStepProgress is the progress of a user on a level of a learning path
+type LearningPathStepProgress struct {
+	LevelID    uuid.UUID `json:"levelId"`
+	Position   int       `json:"position"`
+	Category   string    `json:"category"`
+	LevelNames []string  `json:"levelNames"`
+	// WordCount counts the words of the level, LearnedCount the ones the user reviews or masters
+	WordCount    int64   `json:"wordCount"`
+	LearnedCount int64   `json:"learnedCount"`
+	Share        float64 `json:"share"`
+	Unlocked     bool    `json:"unlocked"`
+	Completed    bool    `json:"completed"`
+}
@@ -1,6 +1,13 @@
 package dto
 
+import "github.com/google/uuid"
+
 type LevelDTO struct {
 	Category   string   `json:"category"`
 	LevelNames []string `json:"level_names"`
 }
+
+// LevelWordsRequest lists the words to add to a custom level
+type LevelWordsRequest struct {
+	WordIDs []uuid.UUID `json:"wordIds" binding:"required"`
+}
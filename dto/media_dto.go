@@ -0,0 +1,21 @@
+package dto
+
+// MediaDTO describes a media uploaded to the media storage
+type MediaDTO struct {
+	// Key locates the media in the storage
+	Key string `json:"key"`
+	// URL is the address the media can be downloaded from, possibly signed and expiring
+	URL string `json:"url"`
+	// ImageURLs gives the URL of each size of an uploaded image
+	ImageURLs *ImageURLs `json:"image_urls,omitempty"`
+}
+
+// ImageURLs gives the URLs of the sizes an uploaded image is stored in
+type ImageURLs struct {
+	// Small is a thumbnail for lists
+	Small string `json:"small"`
+	// Medium fits quiz cards
+	Medium string `json:"medium"`
+	// Large is the full image, metadata removed and its size capped
+	Large string `json:"large"`
+}
@@ -0,0 +1,14 @@
+package dto
+
+import "github.com/xanagit/kotoquiz-api/models"
+
+// PitchDTO represents a pitch accent of a word, split into morae so that clients can draw the accent line
+type PitchDTO struct {
+	Pattern  models.PitchPattern `json:"pattern"`
+	Downstep int                 `json:"downstep"`
+	Morae    []string            `json:"morae"`
+	// High tells for each mora whether it is pronounced high
+	High []bool `json:"high"`
+	// ParticleHigh tells whether a particle following the word is pronounced high
+	ParticleHigh bool `json:"particleHigh"`
+}
@@ -1,6 +1,9 @@
 package dto
 
-import "github.com/google/uuid"
+import (
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/models"
+)
 
 type ResultStatus string
 
@@ -10,9 +13,28 @@ const (
 	Unanswered ResultStatus = "UNANSWERED"
 )
 
+// QuizMode tells what kind of answer was expected for a word
+type QuizMode string
+
+const (
+	MeaningMode QuizMode = "MEANING"
+	ReadingMode QuizMode = "READING"
+	ClozeMode   QuizMode = "CLOZE"
+	// ConjugationMode asks for a form of a verb or an adjective, tracked apart from the word itself
+	ConjugationMode QuizMode = "CONJUGATION"
+)
+
+// WordQuizResult is the result of a quiz question
+// When Answer is provided, the status is computed by the API according to Mode
+// In cloze mode, SentenceID designates the sentence of the question
+// In conjugation mode, Form designates the asked form
 type WordQuizResult struct {
-	WordID uuid.UUID    `json:"wordId"`
-	Status ResultStatus `json:"type"`
+	WordID     uuid.UUID              `json:"wordId"`
+	Status     ResultStatus           `json:"type"`
+	Mode       QuizMode               `json:"mode,omitempty"`
+	Answer     string                 `json:"answer,omitempty"`
+	SentenceID *uuid.UUID             `json:"sentenceId,omitempty"`
+	Form       models.ConjugationForm `json:"form,omitempty"`
 }
 
 type QuizResults struct {
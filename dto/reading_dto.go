@@ -0,0 +1,10 @@
+package dto
+
+import "github.com/xanagit/kotoquiz-api/models"
+
+// ReadingDTO represents one of the valid readings of a word
+type ReadingDTO struct {
+	Reading string          `json:"reading"`
+	Type    models.YomiType `json:"type"`
+	Primary bool            `json:"primary"`
+}
@@ -0,0 +1,8 @@
+package dto
+
+// SenseDTO represents one meaning of a word with its translations in the requested language
+type SenseDTO struct {
+	Translations []string `json:"translations"`
+	PartOfSpeech string   `json:"partOfSpeech"`
+	Notes        string   `json:"notes"`
+}
@@ -0,0 +1,11 @@
+package dto
+
+import "github.com/google/uuid"
+
+// TagNode is a tag of the tag tree, with its sub-tags
+type TagNode struct {
+	ID       uuid.UUID  `json:"id"`
+	En       string     `json:"en"`
+	Fr       string     `json:"fr"`
+	Children []*TagNode `json:"children"`
+}
@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// TrashKind is the kind of a deleted item kept in the trash
+type TrashKind string
+
+const (
+	TrashWord  TrashKind = "WORD"
+	TrashLabel TrashKind = "LABEL"
+	TrashLevel TrashKind = "LEVEL"
+)
+
+// IsValid tells whether the kind is a known one
+func (k TrashKind) IsValid() bool {
+	return k == TrashWord || k == TrashLabel || k == TrashLevel
+}
+
+// TrashItem is a deleted word, label or level which can still be restored until PurgeAt
+// Type is the type of a label or of a level, Name the kanji and the reading of a word or the English text of a label
+// or of the category of a level
+type TrashItem struct {
+	ID        uuid.UUID `json:"id"`
+	Kind      TrashKind `json:"kind"`
+	Type      string    `json:"type,omitempty"`
+	Name      string    `json:"name"`
+	DeletedAt time.Time `json:"deletedAt"`
+	PurgeAt   time.Time `json:"purgeAt"`
+}
+
+// TrashPurgeReport counts the items permanently deleted by a purge of the trash
+type TrashPurgeReport struct {
+	Words  int `json:"words"`
+	Labels int `json:"labels"`
+	Levels int `json:"levels"`
+}
@@ -0,0 +1,72 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/models"
+)
+
+// WordBulkRequest applies one operation to a set of words, given either by their IDs or by a filter
+// TagIDs are used by the tag operations, LevelIDs by the level operations and YomiType by SET_YOMI_TYPE
+type WordBulkRequest struct {
+	Operation WordBulkOperation `json:"operation"`
+	WordIDs   []uuid.UUID       `json:"wordIds"`
+	Filter    *WordFilter       `json:"filter"`
+	TagIDs    []uuid.UUID       `json:"tagIds"`
+	LevelIDs  []uuid.UUID       `json:"levelIds"`
+	YomiType  models.YomiType   `json:"yomiType"`
+}
+
+// WordBulkOperation is the change applied to each word of a bulk request
+type WordBulkOperation string
+
+const (
+	// AddTags links the words to the given tags
+	AddTags WordBulkOperation = "ADD_TAGS"
+	// RemoveTags unlinks the words from the given tags
+	RemoveTags WordBulkOperation = "REMOVE_TAGS"
+	// AddLevels links the words to the given built-in levels
+	AddLevels WordBulkOperation = "ADD_LEVELS"
+	// RemoveLevels unlinks the words from the given built-in levels
+	RemoveLevels WordBulkOperation = "REMOVE_LEVELS"
+	// SetYomiType changes the reading type of the words and of their primary reading
+	SetYomiType WordBulkOperation = "SET_YOMI_TYPE"
+	// DeleteWords moves the words to the trash
+	DeleteWords WordBulkOperation = "DELETE"
+)
+
+// IsValid tells whether the operation is a known one
+func (o WordBulkOperation) IsValid() bool {
+	switch o {
+	case AddTags, RemoveTags, AddLevels, RemoveLevels, SetYomiType, DeleteWords:
+		return true
+	}
+	return false
+}
+
+// WordBulkReport gives the outcome of a bulk operation for each selected word
+type WordBulkReport struct {
+	Operation WordBulkOperation `json:"operation"`
+	Matched   int               `json:"matched"`
+	Changed   int               `json:"changed"`
+	Results   []*WordBulkResult `json:"results"`
+}
+
+// WordBulkResult is the outcome of a bulk operation for one word
+type WordBulkResult struct {
+	WordID uuid.UUID      `json:"wordId"`
+	Status WordBulkStatus `json:"status"`
+}
+
+// WordBulkStatus tells what a bulk operation did to a word
+type WordBulkStatus string
+
+const (
+	// BulkUpdated means that the word has been changed
+	BulkUpdated WordBulkStatus = "UPDATED"
+	// BulkUnchanged means that the word already was in the requested state
+	BulkUnchanged WordBulkStatus = "UNCHANGED"
+	// BulkDeleted means that the word has been moved to the trash
+	BulkDeleted WordBulkStatus = "DELETED"
+	// BulkNotFound means that no word has the given ID, it is skipped
+	BulkNotFound WordBulkStatus = "NOT_FOUND"
+)
@@ -13,12 +13,36 @@ import (
 // and is structured for efficient serialization and deserialization
 type WordDTO struct {
 	// ID is the unique identifier of the word
-	ID          uuid.UUID       `json:"id"`
-	Kanji       string          `json:"kanji"`
-	Yomi        string          `json:"yomi"`
-	YomiType    models.YomiType `json:"yomiType"`
-	ImageURL    string          `json:"image_url"`
-	Translation string          `json:"translation"`
-	Tags        []string        `json:"tags"`
-	Levels      []*LevelDTO     `json:"levels"`
+	ID       uuid.UUID       `json:"id"`
+	Kanji    string          `json:"kanji"`
+	Yomi     string          `json:"yomi"`
+	YomiType models.YomiType `json:"yomiType"`
+	ImageURL string          `json:"image_url"`
+	AudioURL string          `json:"audio_url"`
+	// ImageURLs gives the URL of each size of the uploaded image, ImageURL being the large one
+	ImageURLs   *ImageURLs    `json:"image_urls,omitempty"`
+	Translation string        `json:"translation"`
+	Tags        []string      `json:"tags"`
+	Levels      []*LevelDTO   `json:"levels"`
+	Senses      []*SenseDTO   `json:"senses"`
+	Readings    []*ReadingDTO `json:"readings"`
+	// PartOfSpeech and Transitivity classify the word for grammar drills, they are empty when unknown
+	PartOfSpeech models.PartOfSpeech `json:"partOfSpeech"`
+	Transitivity models.Transitivity `json:"transitivity,omitempty"`
+	// Furigana split the kanji field into segments carrying their reading, to be displayed as ruby text
+	Furigana []models.FuriganaSegment `json:"furigana"`
+	Pitch    []*PitchDTO              `json:"pitch"`
+	// Examples are only provided when requested with include=examples
+	Examples []*ExampleSentenceDTO `json:"examples,omitempty"`
+	// JlptLevel (5 for N5 to 1 for N1) and FrequencyRank (1 for the most common word) are omitted when unknown
+	JlptLevel     models.JlptLevel `json:"jlptLevel,omitempty"`
+	FrequencyRank int              `json:"frequencyRank,omitempty"`
+	// Related words are only provided when requested with include=related
+	Related []*RelatedWordDTO `json:"related,omitempty"`
 }
+
+// Optional sections of a WordDTO, requested with the include query parameter
+const (
+	IncludeExamples = "examples"
+	IncludeRelated  = "related"
+)
@@ -0,0 +1,41 @@
+package dto
+
+import "github.com/xanagit/kotoquiz-api/models"
+
+// WordFilter gathers the criteria used to select words
+// Empty criteria are ignored
+type WordFilter struct {
+	TagIds []string `json:"tagIds"`
+	// IncludeChildren also keeps the words of the descendants of the given tags
+	IncludeChildren bool              `json:"includeChildren"`
+	LevelIds        []string          `json:"levelIds"`
+	LevelNameIds    []string          `json:"levelNameIds"`
+	ReadingTypes    []models.YomiType `json:"readingTypes"`
+	Reading         string            `json:"reading"`
+	// PartsOfSpeech keeps the words of one of the given grammatical classes
+	PartsOfSpeech []models.PartOfSpeech `json:"partsOfSpeech"`
+	Transitivity  models.Transitivity   `json:"transitivity"`
+	// JlptLevels keeps the words of one of the given levels, MaxFrequencyRank the words ranked up to the given rank
+	JlptLevels       []models.JlptLevel `json:"jlptLevels"`
+	MaxFrequencyRank int                `json:"maxFrequencyRank"`
+	// Sort orders the words, common words first, words without the sorting metadata coming last
+	Sort WordSort `json:"sort"`
+	// UserID restricts LevelIds to the levels the user can see: built-in, shared, public or owned levels
+	// It is left empty by the technical endpoints, which see every level
+	UserID string `json:"-"`
+}
+
+// WordSort is the order of a list of words
+type WordSort string
+
+const (
+	// SortByFrequency orders the words from the most common one
+	SortByFrequency WordSort = "frequency"
+	// SortByJlpt orders the words from the easiest JLPT level, then by frequency
+	SortByJlpt WordSort = "jlpt"
+)
+
+// IsValid tells whether the order is a known one
+func (s WordSort) IsValid() bool {
+	return s == SortByFrequency || s == SortByJlpt
+}
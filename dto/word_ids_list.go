@@ -3,3 +3,12 @@ package dto
 type WordIdsList struct {
 	Ids []string `json:"ids"`
 }
+
+// WordPage is a page of the catalogue of words
+// Total is the number of words matching the filter, on every page
+type WordPage struct {
+	Words  []*WordDTO `json:"words"`
+	Total  int64      `json:"total"`
+	Limit  int        `json:"limit"`
+	Offset int        `json:"offset"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/models"
+)
+
+// RelatedWordDTO represents a word linked to another one, with its translation in the requested language
+type RelatedWordDTO struct {
+	ID          uuid.UUID               `json:"id"`
+	Kanji       string                  `json:"kanji"`
+	Yomi        string                  `json:"yomi"`
+	Translation string                  `json:"translation"`
+	Type        models.WordRelationType `json:"type"`
+}
+
+// HomophoneDetectionReport summarizes the changes made to the detected homophones
+type HomophoneDetectionReport struct {
+	Created int64 `json:"created"`
+	Removed int64 `json:"removed"`
+}
@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/models"
+)
+
+// WordWriteRequest is the body of the creation and of the update of a word
+// The translation, the tags and the levels refer to existing records by ID and are never modified through the word.
+// A translation or a tag given without ID is created with the word, levels are only referenced.
+// Senses, readings and pitch accents belong to the word and are replaced on update
+type WordWriteRequest struct {
+	Kanji            string                    `json:"kanji"`
+	Yomi             string                    `json:"yomi"`
+	YomiType         models.YomiType           `json:"yomiType"`
+	ImageURL         string                    `json:"imageURL"`
+	PartOfSpeech     models.PartOfSpeech       `json:"partOfSpeech,omitempty"`
+	Transitivity     models.Transitivity       `json:"transitivity,omitempty"`
+	JlptLevel        models.JlptLevel          `json:"jlptLevel,omitempty"`
+	FrequencyRank    int                       `json:"frequencyRank,omitempty"`
+	Translation      LabelInput                `json:"translation"`
+	Tags             []*LabelInput             `json:"tags"`
+	Levels           []*LevelRef               `json:"levels"`
+	Senses           []*models.WordSense       `json:"senses,omitempty"`
+	Readings         []*models.WordReading     `json:"readings,omitempty"`
+	PitchAccents     []*models.WordPitchAccent `json:"pitchAccents,omitempty"`
+	Furigana         []models.FuriganaSegment  `json:"furigana,omitempty"`
+	FuriganaOverride bool                      `json:"furiganaOverride,omitempty"`
+}
+
+// LabelInput refers to an existing label by ID, or gives the texts of a label to create when the ID is empty
+// The texts of a referenced label are ignored
+type LabelInput struct {
+	ID uuid.UUID `json:"id"`
+	En string    `json:"en"`
+	Fr string    `json:"fr"`
+}
+
+// LevelRef refers to an existing built-in level by ID
+type LevelRef struct {
+	ID uuid.UUID `json:"id"`
+}
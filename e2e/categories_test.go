@@ -0,0 +1,159 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"net/http"
+	"testing"
+)
+
+func Test_should_reuse_level_name_and_delete_it_when_unused_or_forced(t *testing.T) {
+	t.Parallel()
+	var httpResCode int
+
+	// The texts are unique to this test, so that the duplicate report only groups its level names
+	text := "Level name " + uuid.New().String()
+	levelName := models.Label{En: text, Fr: text, Type: models.Tag}
+	var insertedLevelName models.Label
+	httpResCode = post("/api/v1/tech/level-names", ToJson(&levelName), &insertedLevelName)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+	assert.Equal(t, models.LevelName, insertedLevelName.Type)
+
+	// The level name is reused by ID, with its stored texts
+	level := GenerateLevel()
+	level.LevelNames = []*models.Label{{ID: insertedLevelName.ID, En: "Ignored"}}
+	var insertedLevel models.Level
+	httpResCode = post("/api/v1/tech/levels", ToJson(&level), &insertedLevel)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+	if assert.Equal(t, 1, len(insertedLevel.LevelNames)) {
+		assert.Equal(t, insertedLevelName.ID, insertedLevel.LevelNames[0].ID)
+		assert.Equal(t, text, insertedLevel.LevelNames[0].En)
+	}
+
+	// A label of another type cannot be a level name, nor can a level name be a category or the parent of a tag
+	category := generateLabel(models.Category)
+	var insertedCategory models.Label
+	post("/api/v1/tech/categories", ToJson(&category), &insertedCategory)
+	level = GenerateLevel()
+	level.LevelNames = []*models.Label{{ID: insertedCategory.ID}}
+	httpResCode = post("/api/v1/tech/levels", ToJson(&level), &models.Level{})
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+	level = GenerateLevel()
+	level.Category = models.Label{ID: insertedLevelName.ID}
+	httpResCode = post("/api/v1/tech/levels", ToJson(&level), &models.Level{})
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+	tag := generateLabel(models.Tag)
+	tag.ParentID = &insertedLevelName.ID
+	httpResCode = post("/api/v1/tech/tags", ToJson(&tag), &models.Label{})
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+
+	// The duplicate report counts the levels using each level name
+	unusedLevelName := models.Label{En: text, Fr: text}
+	var insertedUnusedLevelName models.Label
+	post("/api/v1/tech/level-names", ToJson(&unusedLevelName), &insertedUnusedLevelName)
+	var report dto.LabelDuplicateReport
+	httpResCode = get("/api/v1/tech/labels/duplicates?type=LEVEL_NAME", &report)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	usages := map[uuid.UUID]int64{}
+	for _, group := range report.Groups {
+		for _, label := range group.Labels {
+			usages[label.ID] = label.Usages
+		}
+	}
+	assert.Equal(t, int64(1), usages[insertedLevelName.ID])
+	assert.Equal(t, int64(0), usages[insertedUnusedLevelName.ID])
+
+	// An unused level name is deleted at once, a used one only when forced
+	httpResCode = del("/api/v1/tech/level-names/" + insertedUnusedLevelName.ID.String())
+	assert.Equal(t, http.StatusNoContent, httpResCode)
+	httpResCode = del("/api/v1/tech/level-names/" + insertedLevelName.ID.String())
+	assert.Equal(t, http.StatusConflict, httpResCode)
+	httpResCode = del("/api/v1/tech/level-names/" + insertedLevelName.ID.String() + "?force=true")
+	assert.Equal(t, http.StatusNoContent, httpResCode)
+
+	httpResCode = get("/api/v1/tech/level-names/"+insertedLevelName.ID.String(), &models.Label{})
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+	var fetchedLevel models.Level
+	httpResCode = get("/api/v1/tech/levels/"+insertedLevel.ID.String(), &fetchedLevel)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Empty(t, fetchedLevel.LevelNames)
+}
+
+func Test_should_not_read_label_of_another_type(t *testing.T) {
+	t.Parallel()
+
+	tag := generateLabel(models.Tag)
+	var insertedTag models.Label
+	post("/api/v1/tech/tags", ToJson(&tag), &insertedTag)
+
+	var fetchedCategory models.Label
+	httpResCode := get("/api/v1/tech/categories/"+insertedTag.ID.String(), &fetchedCategory)
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+}
+
+func Test_should_reuse_existing_category_across_levels(t *testing.T) {
+	t.Parallel()
+	var httpResCode int
+
+	category := generateLabel(models.Category)
+	var insertedCategory models.Label
+	httpResCode = post("/api/v1/tech/categories", ToJson(&category), &insertedCategory)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	insertedLevels := make([]models.Level, 2)
+	for idx := range insertedLevels {
+		level := GenerateLevel()
+		level.ID = uuid.Nil
+		level.Category = models.Label{ID: insertedCategory.ID}
+		httpResCode = post("/api/v1/tech/levels", ToJson(&level), &insertedLevels[idx])
+		assert.Equal(t, http.StatusCreated, httpResCode)
+		assert.Equal(t, insertedCategory, insertedLevels[idx].Category)
+	}
+
+	var fetchedLevel models.Level
+	httpResCode = get("/api/v1/tech/levels/"+insertedLevels[1].ID.String(), &fetchedLevel)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, insertedCategory.ID, fetchedLevel.Category.ID)
+}
+
+func Test_should_refuse_to_create_level_with_label_of_another_type(t *testing.T) {
+	t.Parallel()
+
+	tag := generateLabel(models.Tag)
+	var insertedTag models.Label
+	post("/api/v1/tech/tags", ToJson(&tag), &insertedTag)
+
+	level := GenerateLevel()
+	level.Category = models.Label{ID: insertedTag.ID}
+	var insertedLevel models.Level
+	httpResCode := post("/api/v1/tech/levels", ToJson(&level), &insertedLevel)
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+}
+
+func Test_should_refuse_to_delete_category_in_use_unless_forced(t *testing.T) {
+	t.Parallel()
+	var httpResCode int
+
+	category := generateLabel(models.Category)
+	var insertedCategory models.Label
+	post("/api/v1/tech/categories", ToJson(&category), &insertedCategory)
+
+	level := GenerateLevel()
+	level.Category = models.Label{ID: insertedCategory.ID}
+	var insertedLevel models.Level
+	httpResCode = post("/api/v1/tech/levels", ToJson(&level), &insertedLevel)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	httpResCode = del("/api/v1/tech/categories/" + insertedCategory.ID.String())
+	assert.Equal(t, http.StatusConflict, httpResCode)
+
+	httpResCode = del("/api/v1/tech/categories/" + insertedCategory.ID.String() + "?force=true")
+	assert.Equal(t, http.StatusNoContent, httpResCode)
+
+	var fetchedLevel models.Level
+	httpResCode = get("/api/v1/tech/levels/"+insertedLevel.ID.String(), &fetchedLevel)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, uuid.Nil, fetchedLevel.Category.ID)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"net/http"
+	"testing"
+)
+
+func Test_should_list_conjugations_of_word(t *testing.T) {
+	t.Parallel()
+
+	verb := GenerateWord()
+	verb.Kanji = "書く"
+	verb.Yomi = "かく"
+	verb.PartOfSpeech = models.GodanVerb
+	var insertedVerb models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&verb), &insertedVerb)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	var conjugations []*dto.ConjugationDTO
+	httpResCode = get("/api/v1/app/words/"+insertedVerb.ID.String()+"/conjugations", &conjugations)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, len(models.VerbForms), len(conjugations))
+	forms := make(map[models.ConjugationForm][]string)
+	for _, conjugation := range conjugations {
+		forms[conjugation.Form] = conjugation.Answers
+	}
+	assert.Equal(t, []string{"書きます", "かきます"}, forms[models.PoliteForm])
+	assert.Equal(t, []string{"書いて", "かいて"}, forms[models.TeForm])
+	assert.Equal(t, []string{"書かない", "かかない"}, forms[models.NegativeForm])
+	assert.Equal(t, []string{"書こう", "かこう"}, forms[models.VolitionalForm])
+
+	noun := GenerateWord()
+	noun.PartOfSpeech = models.Noun
+	var insertedNoun models.Word
+	post("/api/v1/tech/words", ToJson(&noun), &insertedNoun)
+
+	httpResCode = get("/api/v1/app/words/"+insertedNoun.ID.String()+"/conjugations", &conjugations)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Empty(t, conjugations)
+}
+
+func Test_should_list_conjugations_of_compound_words(t *testing.T) {
+	t.Parallel()
+
+	adjective := GenerateWord()
+	adjective.Kanji = "格好いい"
+	adjective.Yomi = "かっこいい"
+	adjective.PartOfSpeech = models.IAdjective
+	verb := GenerateWord()
+	verb.Kanji = "持って行く"
+	verb.Yomi = "もっていく"
+	verb.PartOfSpeech = models.GodanVerb
+
+	expected := map[*models.Word]map[models.ConjugationForm][]string{
+		&adjective: {
+			models.PastForm:     {"格好よかった", "かっこよかった"},
+			models.NegativeForm: {"格好よくない", "かっこよくない"},
+		},
+		&verb: {
+			models.TeForm:   {"持って行って", "もっていって"},
+			models.PastForm: {"持って行った", "もっていった"},
+		},
+	}
+	for word, expectedForms := range expected {
+		var insertedWord models.Word
+		httpResCode := post("/api/v1/tech/words", ToJson(word), &insertedWord)
+		assert.Equal(t, http.StatusCreated, httpResCode)
+
+		var conjugations []*dto.ConjugationDTO
+		httpResCode = get("/api/v1/app/words/"+insertedWord.ID.String()+"/conjugations", &conjugations)
+		assert.Equal(t, http.StatusOK, httpResCode)
+		forms := make(map[models.ConjugationForm][]string)
+		for _, conjugation := range conjugations {
+			forms[conjugation.Form] = conjugation.Answers
+		}
+		for form, answers := range expectedForms {
+			assert.Equal(t, answers, forms[form])
+		}
+	}
+}
+
+func Test_should_drill_conjugation_of_verb(t *testing.T) {
+	t.Parallel()
+
+	verb := GenerateWord()
+	verb.Kanji = "来る"
+	verb.Yomi = "くる"
+	verb.PartOfSpeech = models.KuruVerb
+	var insertedVerb models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&verb), &insertedVerb)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	var questions []*dto.ConjugationQuestion
+	httpResCode = get("/api/v1/app/quiz/conjugation?ids="+insertedVerb.ID.String()+"&forms=NEGATIVE&lang=fr", &questions)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 1, len(questions))
+	assert.Equal(t, models.NegativeForm, questions[0].Form)
+	assert.Equal(t, "来る", questions[0].Kanji)
+	assert.Equal(t, "Translation Fr", questions[0].Hint)
+
+	// The form is accepted in kanji or in kana
+	for _, answer := range []string{"来ない", "コナイ"} {
+		results := dto.QuizResults{
+			Results: []dto.WordQuizResult{
+				{WordID: insertedVerb.ID, Mode: dto.ConjugationMode, Form: models.NegativeForm, Answer: answer},
+			},
+		}
+		httpResCode = postNoContent("/api/v1/app/quiz/results", ToJson(&results))
+		assert.Equal(t, http.StatusOK, httpResCode)
+	}
+
+	// Once practised, the negative form is not due anymore and the other form is asked
+	httpResCode = get("/api/v1/app/quiz/conjugation?ids="+insertedVerb.ID.String()+"&forms=NEGATIVE,TE", &questions)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 1, len(questions))
+	assert.Equal(t, models.TeForm, questions[0].Form)
+
+	// Adjective forms are not asked for verbs
+	httpResCode = get("/api/v1/app/quiz/conjugation?ids="+insertedVerb.ID.String()+"&forms=PAST_NEGATIVE", &questions)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Empty(t, questions)
+
+	httpResCode = get("/api/v1/app/quiz/conjugation?ids="+insertedVerb.ID.String()+"&forms=IMPERATIVE", &questions)
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+
+	invalidResults := dto.QuizResults{
+		Results: []dto.WordQuizResult{
+			{WordID: insertedVerb.ID, Mode: dto.ConjugationMode, Answer: "来ない"},
+		},
+	}
+	httpResCode = postNoContent("/api/v1/app/quiz/results", ToJson(&invalidResults))
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+}
+
+func Test_should_reset_conjugation_streak_after_error(t *testing.T) {
+	t.Parallel()
+
+	verb := GenerateWord()
+	verb.Kanji = "見る"
+	verb.Yomi = "みる"
+	verb.PartOfSpeech = models.IchidanVerb
+	var insertedVerb models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&verb), &insertedVerb)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	userID := uuid.New().String()
+	for _, answer := range []string{"見ない", "見ない", "見る"} {
+		results := dto.QuizResults{
+			UserID: userID,
+			Results: []dto.WordQuizResult{
+				{WordID: insertedVerb.ID, Mode: dto.ConjugationMode, Form: models.NegativeForm, Answer: answer},
+			},
+		}
+		httpResCode = postNoContent("/api/v1/app/quiz/results", ToJson(&results))
+		assert.Equal(t, http.StatusOK, httpResCode)
+	}
+
+	// The streak reset to 0 by the wrong answer is stored, the best streak is kept
+	var history models.ConjugationLearningHistory
+	err := db.Where("user_id = ? AND word_id = ? AND form = ?", userID, insertedVerb.ID, models.NegativeForm).First(&history).Error
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, history.CurrentStreak)
+		assert.Equal(t, 2, history.BestStreak)
+		assert.Equal(t, 2, history.NbSuccess)
+		assert.Equal(t, 1, history.NbErrors)
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"net/http"
+	"testing"
+)
+
+func Test_should_publish_and_clone_deck(t *testing.T) {
+	t.Parallel()
+
+	deck, words := insertUserLevelWithWords(t, 3)
+	request := dto.LevelWordsRequest{WordIDs: []uuid.UUID{words[0].ID, words[1].ID}}
+	post("/api/v1/app/levels/"+deck.ID.String()+"/words", ToJson(&request), &deck)
+
+	var publishedDeck models.Level
+	httpResCode := post("/api/v1/app/levels/"+deck.ID.String()+"/publish?visibility=PUBLIC", "", &publishedDeck)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.NotEmpty(t, publishedDeck.ShareCode)
+	assert.Equal(t, models.Public, publishedDeck.Visibility)
+	assert.Equal(t, 1, publishedDeck.Revision)
+
+	var clone models.Level
+	httpResCode = post("/api/v1/app/decks/"+publishedDeck.ShareCode+"/clone", "", &clone)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+	assert.NotEqual(t, deck.ID, clone.ID)
+	assert.Equal(t, &deck.ID, clone.SourceLevelID)
+	assert.Equal(t, models.Private, clone.Visibility)
+	assert.ElementsMatch(t, request.WordIDs, clone.WordIDs)
+
+	var sharedDeck dto.DeckDTO
+	httpResCode = get("/api/v1/app/decks/"+publishedDeck.ShareCode+"?lang=fr", &sharedDeck)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, int64(2), sharedDeck.WordCount)
+	assert.Equal(t, 1, sharedDeck.CloneCount)
+	assert.Equal(t, "Category Fr", sharedDeck.Category)
+
+	// Cloning the deck again does not count the same user twice
+	httpResCode = post("/api/v1/app/decks/"+publishedDeck.ShareCode+"/clone", "", &clone)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+	httpResCode = requestAs("other-user", http.MethodPost, "/api/v1/app/decks/"+publishedDeck.ShareCode+"/clone", "", &clone)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+	get("/api/v1/app/decks/"+publishedDeck.ShareCode+"?lang=fr", &sharedDeck)
+	assert.Equal(t, 2, sharedDeck.CloneCount)
+
+	var gallery dto.DeckPage
+	httpResCode = get("/api/v1/app/decks?sort=clones&limit=100", &gallery)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Positive(t, gallery.Total)
+
+	// The owner of a deck cannot rate it
+	rating := dto.DeckRatingRequest{Score: 5}
+	httpResCode = put("/api/v1/app/decks/"+publishedDeck.ShareCode+"/rating", ToJson(&rating), &sharedDeck)
+	assert.Equal(t, http.StatusForbidden, httpResCode)
+	rating.Score = 6
+	httpResCode = put("/api/v1/app/decks/"+publishedDeck.ShareCode+"/rating", ToJson(&rating), &sharedDeck)
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+}
+
+func Test_should_sync_clone_with_pushed_deck(t *testing.T) {
+	t.Parallel()
+
+	deck, words := insertUserLevelWithWords(t, 4)
+	request := dto.LevelWordsRequest{WordIDs: []uuid.UUID{words[0].ID, words[1].ID}}
+	post("/api/v1/app/levels/"+deck.ID.String()+"/words", ToJson(&request), &deck)
+	post("/api/v1/app/levels/"+deck.ID.String()+"/publish", "", &deck)
+
+	var clone models.Level
+	post("/api/v1/app/decks/"+deck.ShareCode+"/clone", "", &clone)
+
+	// The owner of the clone adds a word of their own
+	request = dto.LevelWordsRequest{WordIDs: []uuid.UUID{words[3].ID}}
+	post("/api/v1/app/levels/"+clone.ID.String()+"/words", ToJson(&request), &clone)
+
+	// The author changes the deck, the changes are offered once pushed
+	request = dto.LevelWordsRequest{WordIDs: []uuid.UUID{words[2].ID}}
+	post("/api/v1/app/levels/"+deck.ID.String()+"/words", ToJson(&request), &deck)
+	del("/api/v1/app/levels/" + deck.ID.String() + "/words/" + words[0].ID.String())
+
+	var update dto.DeckUpdate
+	httpResCode := get("/api/v1/app/levels/"+clone.ID.String()+"/update", &update)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Empty(t, update.AddedWordIDs)
+	assert.Empty(t, update.RemovedWordIDs)
+
+	httpResCode = post("/api/v1/app/levels/"+deck.ID.String()+"/push", "", &deck)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 2, deck.Revision)
+
+	httpResCode = get("/api/v1/app/levels/"+clone.ID.String()+"/update", &update)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 1, update.SourceRevision)
+	assert.Equal(t, 2, update.Revision)
+	assert.Equal(t, []uuid.UUID{words[2].ID}, update.AddedWordIDs)
+	assert.Equal(t, []uuid.UUID{words[0].ID}, update.RemovedWordIDs)
+
+	httpResCode = post("/api/v1/app/levels/"+clone.ID.String()+"/update", "", &clone)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 2, clone.SourceRevision)
+	assert.ElementsMatch(t, []uuid.UUID{words[1].ID, words[2].ID, words[3].ID}, clone.WordIDs)
+
+	// A level which is not a clone has no update
+	httpResCode = get("/api/v1/app/levels/"+deck.ID.String()+"/update", &update)
+	assert.Equal(t, http.StatusConflict, httpResCode)
+}
+
+func Test_should_leave_trashed_words_out_of_decks(t *testing.T) {
+	t.Parallel()
+
+	deck, words := insertUserLevelWithWords(t, 3)
+	request := dto.LevelWordsRequest{WordIDs: []uuid.UUID{words[0].ID, words[1].ID}}
+	post("/api/v1/app/levels/"+deck.ID.String()+"/words", ToJson(&request), &deck)
+	post("/api/v1/app/levels/"+deck.ID.String()+"/publish", "", &deck)
+
+	// A published word moved to the trash is not cloned
+	del("/api/v1/tech/words/" + words[0].ID.String())
+	var clone models.Level
+	httpResCode := post("/api/v1/app/decks/"+deck.ShareCode+"/clone", "", &clone)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+	assert.Equal(t, []uuid.UUID{words[1].ID}, clone.WordIDs)
+
+	// Nor is it pushed or offered as a change
+	request = dto.LevelWordsRequest{WordIDs: []uuid.UUID{words[2].ID}}
+	post("/api/v1/app/levels/"+deck.ID.String()+"/words", ToJson(&request), &deck)
+	del("/api/v1/tech/words/" + words[1].ID.String())
+	post("/api/v1/app/levels/"+deck.ID.String()+"/push", "", &deck)
+	var update dto.DeckUpdate
+	httpResCode = get("/api/v1/app/levels/"+clone.ID.String()+"/update", &update)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, []uuid.UUID{words[2].ID}, update.AddedWordIDs)
+	assert.Empty(t, update.RemovedWordIDs)
+
+	// The trashed words come back to the levels once restored
+	postNoContent("/api/v1/tech/trash/words/"+words[0].ID.String()+"/restore", "")
+	var fetchedDeck models.Level
+	get("/api/v1/app/levels/"+deck.ID.String(), &fetchedDeck)
+	assert.ElementsMatch(t, []uuid.UUID{words[0].ID, words[2].ID}, fetchedDeck.WordIDs)
+}
+
+func Test_should_rate_decks_and_sort_gallery_by_rating(t *testing.T) {
+	t.Parallel()
+
+	var codes []string
+	for i := 0; i < 2; i++ {
+		deck, _ := insertUserLevelWithWords(t, 1)
+		var publishedDeck models.Level
+		httpResCode := post("/api/v1/app/levels/"+deck.ID.String()+"/publish?visibility=PUBLIC", "", &publishedDeck)
+		assert.Equal(t, http.StatusOK, httpResCode)
+		codes = append(codes, publishedDeck.ShareCode)
+	}
+
+	// Each user has a single rating per deck, rating again replaces it
+	var ratedDeck dto.DeckDTO
+	for _, rating := range []struct {
+		userID string
+		code   string
+		score  int
+	}{
+		{"rating-user-1", codes[0], 4},
+		{"rating-user-2", codes[0], 2},
+		{"rating-user-1", codes[0], 5},
+		{"rating-user-1", codes[1], 1},
+	} {
+		request := dto.DeckRatingRequest{Score: rating.score}
+		httpResCode := requestAs(rating.userID, http.MethodPut, "/api/v1/app/decks/"+rating.code+"/rating", ToJson(&request), &ratedDeck)
+		assert.Equal(t, http.StatusOK, httpResCode)
+	}
+	assert.Equal(t, 1, ratedDeck.RatingCount)
+	assert.Equal(t, 1.0, ratedDeck.RatingAverage)
+
+	var bestDeck dto.DeckDTO
+	get("/api/v1/app/decks/"+codes[0], &bestDeck)
+	assert.Equal(t, 2, bestDeck.RatingCount)
+	assert.Equal(t, 3.5, bestDeck.RatingAverage)
+
+	// The best rated decks come first
+	var gallery dto.DeckPage
+	httpResCode := get("/api/v1/app/decks?sort=rating&limit=100", &gallery)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	positions := map[string]int{}
+	for i, deck := range gallery.Decks {
+		positions[deck.ShareCode] = i
+		if i > 0 {
+			assert.GreaterOrEqual(t, gallery.Decks[i-1].RatingAverage, deck.RatingAverage)
+		}
+	}
+	if assert.Contains(t, positions, codes[0]) && assert.Contains(t, positions, codes[1]) {
+		assert.Less(t, positions[codes[0]], positions[codes[1]])
+	}
+
+	// The gallery does not expose the owners of the decks
+	var rawGallery map[string][]map[string]any
+	get("/api/v1/app/decks?sort=rating&limit=1", &rawGallery)
+	if assert.NotEmpty(t, rawGallery["decks"]) {
+		assert.NotContains(t, rawGallery["decks"][0], "ownerId")
+	}
+}
+
+func Test_should_only_change_visibility_by_publishing(t *testing.T) {
+	t.Parallel()
+
+	level := GenerateLevel()
+	level.Visibility = models.Public
+	httpResCode := post("/api/v1/app/levels", ToJson(&level), &models.Level{})
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+
+	// An update does not make a level public
+	deck, _ := insertUserLevelWithWords(t, 1)
+	deckURL := "/api/v1/app/levels/" + deck.ID.String()
+	deck.Visibility = models.Public
+	var updatedDeck models.Level
+	httpResCode = put(deckURL, ToJson(&deck), &updatedDeck)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, models.Private, updatedDeck.Visibility)
+	httpResCode = requestAs("other-user", http.MethodGet, deckURL, "", &models.Level{})
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+
+	// An update does not make a published deck private either, only unpublishing does
+	var publishedDeck models.Level
+	post(deckURL+"/publish?visibility=PUBLIC", "", &publishedDeck)
+	publishedDeck.Visibility = models.Private
+	httpResCode = put(deckURL, ToJson(&publishedDeck), &updatedDeck)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, models.Public, updatedDeck.Visibility)
+	assert.Equal(t, publishedDeck.ShareCode, updatedDeck.ShareCode)
+
+	httpResCode = del(deckURL + "/publish")
+	assert.Equal(t, http.StatusNoContent, httpResCode)
+	httpResCode = requestAs("other-user", http.MethodGet, "/api/v1/app/decks/"+publishedDeck.ShareCode, "", &dto.DeckDTO{})
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+	httpResCode = requestAs("other-user", http.MethodPost, "/api/v1/app/decks/"+publishedDeck.ShareCode+"/clone", "", &models.Level{})
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+}
+
+func insertUserLevelWithWords(t *testing.T, nbWords int) (models.Level, []models.Word) {
+	level := GenerateLevel()
+	var insertedLevel models.Level
+	httpResCode := post("/api/v1/app/levels", ToJson(&level), &insertedLevel)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	words := make([]models.Word, nbWords)
+	for i := range words {
+		word := GenerateWord()
+		post("/api/v1/tech/words", ToJson(&word), &words[i])
+	}
+	return insertedLevel, words
+}
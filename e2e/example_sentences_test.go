@@ -0,0 +1,152 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_should_create_example_sentence(t *testing.T) {
+	t.Parallel()
+
+	var insertedWord models.Word
+	word := GenerateWord()
+	post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+
+	sentence := generateExampleSentence(insertedWord.ID)
+	var insertedSentence models.ExampleSentence
+	httpResCode := post("/api/v1/tech/examples", ToJson(&sentence), &insertedSentence)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	var fetchedSentence models.ExampleSentence
+	httpResCode = get("/api/v1/tech/examples/"+insertedSentence.ID.String(), &fetchedSentence)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, insertedSentence, fetchedSentence)
+}
+
+func Test_should_delete_example_sentence(t *testing.T) {
+	t.Parallel()
+
+	sentence := generateExampleSentence()
+	var insertedSentence models.ExampleSentence
+	post("/api/v1/tech/examples", ToJson(&sentence), &insertedSentence)
+
+	httpResCode := del("/api/v1/tech/examples/" + insertedSentence.ID.String())
+	assert.Equal(t, http.StatusNoContent, httpResCode)
+
+	httpResCode = get("/api/v1/tech/examples/"+insertedSentence.ID.String(), &insertedSentence)
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+}
+
+func Test_should_read_wordDto_with_examples_only_when_included(t *testing.T) {
+	t.Parallel()
+
+	var insertedWord models.Word
+	word := GenerateWord()
+	post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+
+	sentence := generateExampleSentence(insertedWord.ID)
+	var insertedSentence models.ExampleSentence
+	post("/api/v1/tech/examples", ToJson(&sentence), &insertedSentence)
+
+	var fetchedWordDto dto.WordDTO
+	httpResCode := get("/api/v1/app/words/"+insertedWord.ID.String()+"?lang=fr", &fetchedWordDto)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Nil(t, fetchedWordDto.Examples)
+
+	httpResCode = get("/api/v1/app/words/"+insertedWord.ID.String()+"?lang=fr&include=examples", &fetchedWordDto)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 1, len(fetchedWordDto.Examples))
+	assert.Equal(t, "今日は暑いです。", fetchedWordDto.Examples[0].Japanese)
+	assert.Equal(t, "Il fait chaud aujourd'hui.", fetchedWordDto.Examples[0].Translation)
+}
+
+func Test_should_build_cloze_question_from_conjugated_verb(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	word.Kanji = "食べる"
+	word.Yomi = "たべる"
+	var insertedWord models.Word
+	post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+
+	sentence := generateExampleSentence(insertedWord.ID)
+	sentence.Japanese = "毎朝パンを食べます。"
+	var insertedSentence models.ExampleSentence
+	post("/api/v1/tech/examples", ToJson(&sentence), &insertedSentence)
+
+	var questions []*dto.ClozeQuestion
+	httpResCode := get("/api/v1/app/quiz/cloze?ids="+insertedWord.ID.String()+"&lang=fr", &questions)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	if !assert.Equal(t, 1, len(questions)) {
+		return
+	}
+	// The whole conjugated form is blanked, not only the dictionary stem
+	assert.Equal(t, insertedWord.ID, questions[0].WordID)
+	assert.Equal(t, "毎朝パンを＿＿。", questions[0].Sentence)
+	assert.Equal(t, sentence.Translation.Fr, questions[0].Translation)
+	assert.Equal(t, "Translation Fr", questions[0].Hint)
+	assert.Equal(t, insertedSentence.ID, questions[0].SentenceID)
+
+	// The blanked form and the dictionary form, also in kana, are accepted, other forms are not
+	expectedStatuses := map[string]dto.ResultStatus{
+		"食べます": dto.Success,
+		"食べる":  dto.Success,
+		"たべる":  dto.Success,
+		"食べた":  dto.Error,
+		"飲みます": dto.Error,
+	}
+	for answer, expectedStatus := range expectedStatuses {
+		status, err := gradeAnswer(dto.WordQuizResult{
+			WordID: insertedWord.ID, Mode: dto.ClozeMode, Answer: answer, SentenceID: &questions[0].SentenceID,
+		})
+		assert.NoError(t, err, answer)
+		assert.Equal(t, expectedStatus, status, answer)
+	}
+}
+
+const tatoebaSample = "74001\t猫が好きです。\t1001\tI like cats.\n" +
+	"74001\t猫が好きです。\t1002\tI love cats.\n" +
+	"74002\t猫を飼っています。\t1003\tI have a cat.\n" +
+	"invalid line\n"
+
+func Test_should_import_tatoeba_sentences(t *testing.T) {
+	word := GenerateWord()
+	word.Kanji = "猫"
+	var insertedWord models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(importDataDir, "tatoeba.tsv"), []byte(tatoebaSample), 0o644))
+
+	var report dto.ImportReport
+	httpResCode = post("/api/v1/tech/import/tatoeba", `{"path": "tatoeba.tsv"}`, &report)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, 2, report.Skipped)
+
+	var fetchedWordDtos []*dto.WordDTO
+	httpResCode = get("/api/v1/app/words?ids="+insertedWord.ID.String()+"&include=examples", &fetchedWordDtos)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 1, len(fetchedWordDtos))
+	assert.Equal(t, 2, len(fetchedWordDtos[0].Examples))
+	assert.Equal(t, "tatoeba", fetchedWordDtos[0].Examples[0].Source)
+}
+
+func generateExampleSentence(wordIDs ...uuid.UUID) models.ExampleSentence {
+	return models.ExampleSentence{
+		Japanese: "今日は暑いです。",
+		Reading:  "きょうはあついです。",
+		Translation: models.Label{
+			ID: uuid.New(),
+			En: "It is hot today.",
+			Fr: "Il fait chaud aujourd'hui.",
+		},
+		WordIDs: wordIDs,
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"net/http"
+	"testing"
+)
+
+func Test_should_read_wordDto_with_furigana(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	word.Kanji = "食べる"
+	word.Yomi = "たべる"
+	var insertedWord models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	var fetchedWordDto dto.WordDTO
+	httpResCode = get("/api/v1/app/words/"+insertedWord.ID.String(), &fetchedWordDto)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, []models.FuriganaSegment{{Text: "食", Reading: "た"}, {Text: "べる"}}, fetchedWordDto.Furigana)
+}
+
+func Test_should_split_furigana_per_kanji_with_known_readings(t *testing.T) {
+	t.Parallel()
+
+	for _, kanji := range []models.Kanji{
+		{Character: "学", StrokeCount: 8, OnReadings: []string{"ガク"}, KunReadings: []string{"まな.ぶ"}},
+		{Character: "校", StrokeCount: 10, OnReadings: []string{"コウ"}},
+	} {
+		httpResCode := post("/api/v1/tech/kanji", ToJson(&kanji), &models.Kanji{})
+		assert.Equal(t, http.StatusCreated, httpResCode)
+	}
+
+	word := GenerateWord()
+	word.Kanji = "学校"
+	word.Yomi = "がっこう"
+	var insertedWord models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+	assert.Equal(t, []models.FuriganaSegment{{Text: "学", Reading: "がっ"}, {Text: "校", Reading: "こう"}}, insertedWord.Furigana)
+}
+
+func Test_should_override_and_reset_word_furigana(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	word.Kanji = "今日"
+	word.Yomi = "きょう"
+	var insertedWord models.Word
+	post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+
+	var updatedWord models.Word
+	httpResCode := put("/api/v1/tech/words/"+insertedWord.ID.String()+"/furigana", `[{"text": "今", "reading": "きょ"}, {"text": "日", "reading": "う"}]`, &updatedWord)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.True(t, updatedWord.FuriganaOverride)
+	assert.Equal(t, `"2"`, getETag("/api/v1/tech/words/"+insertedWord.ID.String()))
+
+	// Segments must spell the kanji of the word
+	httpResCode = put("/api/v1/tech/words/"+insertedWord.ID.String()+"/furigana", `[{"text": "明日", "reading": "あした"}]`, &updatedWord)
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+
+	var fetchedWordDto dto.WordDTO
+	get("/api/v1/app/words/"+insertedWord.ID.String(), &fetchedWordDto)
+	assert.Equal(t, 2, len(fetchedWordDto.Furigana))
+
+	httpResCode = del("/api/v1/tech/words/" + insertedWord.ID.String() + "/furigana")
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, `"3"`, getETag("/api/v1/tech/words/"+insertedWord.ID.String()))
+	get("/api/v1/app/words/"+insertedWord.ID.String(), &fetchedWordDto)
+	assert.Equal(t, []models.FuriganaSegment{{Text: "今日", Reading: "きょう"}}, fetchedWordDto.Furigana)
+}
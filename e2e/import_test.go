@@ -0,0 +1,118 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const kanjidicSample = `<?xml version="1.0" encoding="UTF-8"?>
+<kanjidic2>
+<header><file_version>4</file_version></header>
+<character>
+<literal>亜</literal>
+<radical><rad_value rad_type="classical">7</rad_value><rad_value rad_type="nelson_c">1</rad_value></radical>
+<misc><grade>8</grade><stroke_count>7</stroke_count><freq>1509</freq><jlpt>1</jlpt></misc>
+<reading_meaning><rmgroup>
+<reading r_type="pinyin">ya4</reading>
+<reading r_type="ja_on">ア</reading>
+<reading r_type="ja_kun">つ.ぐ</reading>
+<meaning>Asia</meaning>
+<meaning m_lang="fr">Asie</meaning>
+</rmgroup></reading_meaning>
+</character>
+</kanjidic2>`
+
+const kanjiVGSample = `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="109" height="109" viewBox="0 0 109 109">
+<g id="kvg:StrokePaths_04e9c" style="fill:none;">
+<g id="kvg:04e9c">
+	<path id="kvg:04e9c-s1" d="M15.5,20.5c2.5,0.5,5,0.5,7,0.25"/>
+	<path id="kvg:04e9c-s2" d="M30,25v40"/>
+</g>
+</g>
+</svg>`
+
+// kradfileSample holds a comment, a malformed line, a kanji missing from the database
+// and a substitute kanji standing for the water radical
+const kradfileSample = `# KRADFILE sample
+柳 : 木 卯
+沢 : 汁 尺
+broken line
+鬱 : 木 缶 冖 凵 鬯 彡
+`
+
+func Test_should_import_kanjidic_and_kanjivg(t *testing.T) {
+	word := GenerateWord()
+	word.Kanji = "亜鉛"
+	var insertedWord models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(importDataDir, "kanjidic2.xml"), []byte(kanjidicSample), 0o644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(importDataDir, "kanjivg"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(importDataDir, "kanjivg", "04e9c.svg"), []byte(kanjiVGSample), 0o644))
+
+	var kanjidicReport dto.ImportReport
+	httpResCode = post("/api/v1/tech/import/kanjidic", `{"path": "kanjidic2.xml"}`, &kanjidicReport)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 1, kanjidicReport.Imported)
+
+	var kanjiVGReport dto.ImportReport
+	httpResCode = post("/api/v1/tech/import/kanjivg", `{"path": "kanjivg"}`, &kanjiVGReport)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 1, kanjiVGReport.Imported)
+
+	var fetchedKanjiDto dto.KanjiDTO
+	httpResCode = get("/api/v1/app/kanji/"+url.PathEscape("亜")+"?lang=fr", &fetchedKanjiDto)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 7, fetchedKanjiDto.StrokeCount)
+	assert.Equal(t, "二", fetchedKanjiDto.Radical)
+	assert.Equal(t, []string{"ア"}, fetchedKanjiDto.OnReadings)
+	assert.Equal(t, []string{"Asie"}, fetchedKanjiDto.Meanings)
+	assert.Equal(t, 2, len(fetchedKanjiDto.StrokePaths))
+	assert.Equal(t, 1, len(fetchedKanjiDto.Words))
+	assert.Equal(t, insertedWord.ID, fetchedKanjiDto.Words[0].ID)
+}
+
+func Test_should_import_kradfile(t *testing.T) {
+	word := GenerateWord()
+	word.Kanji = "沢山"
+	var insertedWord models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	for _, character := range []string{"柳", "沢"} {
+		kanji := generateKanji(character)
+		kanji.Components = nil
+		httpResCode = post("/api/v1/tech/kanji", ToJson(&kanji), &models.Kanji{})
+		assert.Equal(t, http.StatusCreated, httpResCode)
+	}
+
+	assert.NoError(t, os.WriteFile(filepath.Join(importDataDir, "kradfile.txt"), []byte(kradfileSample), 0o644))
+	var report dto.ImportReport
+	httpResCode = post("/api/v1/tech/import/kradfile", `{"path": "kradfile.txt"}`, &report)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, 2, report.Skipped)
+
+	var willowKanji dto.KanjiDTO
+	httpResCode = get("/api/v1/app/kanji/"+url.PathEscape("柳"), &willowKanji)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, []string{"木", "卯"}, willowKanji.Components)
+
+	// The substitute kanji is replaced by the radical learners type
+	var foundKanji []dto.KanjiDTO
+	httpResCode = get("/api/v1/app/kanji/lookup?components="+url.QueryEscape("氵,尺"), &foundKanji)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	if assert.Equal(t, 1, len(foundKanji)) {
+		assert.Equal(t, "沢", foundKanji[0].Character)
+		assert.Equal(t, 1, len(foundKanji[0].Words))
+		assert.Equal(t, insertedWord.ID, foundKanji[0].Words[0].ID)
+	}
+}
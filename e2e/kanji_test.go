@@ -0,0 +1,153 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func Test_should_create_kanji(t *testing.T) {
+	t.Parallel()
+
+	kanji := generateKanji("火")
+	var insertedKanji models.Kanji
+	httpResCode := post("/api/v1/tech/kanji", ToJson(&kanji), &insertedKanji)
+
+	assert.Equal(t, http.StatusCreated, httpResCode)
+	kanji.ID = insertedKanji.ID
+	assert.Equal(t, kanji, insertedKanji)
+
+	var fetchedKanji models.Kanji
+	httpResCode = get("/api/v1/tech/kanji/"+insertedKanji.ID.String(), &fetchedKanji)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, insertedKanji, fetchedKanji)
+}
+
+func Test_should_delete_kanji(t *testing.T) {
+	t.Parallel()
+
+	kanji := generateKanji("水")
+	var insertedKanji models.Kanji
+	post("/api/v1/tech/kanji", ToJson(&kanji), &insertedKanji)
+
+	httpResCode := del("/api/v1/tech/kanji/" + insertedKanji.ID.String())
+	assert.Equal(t, http.StatusNoContent, httpResCode)
+
+	httpResCode = get("/api/v1/tech/kanji/"+insertedKanji.ID.String(), &insertedKanji)
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+}
+
+func Test_should_not_update_unknown_kanji(t *testing.T) {
+	t.Parallel()
+
+	kanji := generateKanji("金")
+	unknownURL := "/api/v1/tech/kanji/" + uuid.New().String()
+	httpResCode := put(unknownURL, ToJson(&kanji), &models.Kanji{})
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+
+	httpResCode = get(unknownURL, &models.Kanji{})
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+}
+
+func Test_should_not_duplicate_kanji_character(t *testing.T) {
+	t.Parallel()
+
+	kanji := generateKanji("雷")
+	var insertedKanji models.Kanji
+	httpResCode := post("/api/v1/tech/kanji", ToJson(&kanji), &insertedKanji)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	httpResCode = post("/api/v1/tech/kanji", ToJson(&kanji), &models.Kanji{})
+	assert.Equal(t, http.StatusConflict, httpResCode)
+
+	otherKanji := generateKanji("雲")
+	var insertedOtherKanji models.Kanji
+	httpResCode = post("/api/v1/tech/kanji", ToJson(&otherKanji), &insertedOtherKanji)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	insertedOtherKanji.Character = "雷"
+	httpResCode = put("/api/v1/tech/kanji/"+insertedOtherKanji.ID.String(), ToJson(&insertedOtherKanji), &models.Kanji{})
+	assert.Equal(t, http.StatusConflict, httpResCode)
+
+	// A kanji can still be updated without changing its character
+	httpResCode = put("/api/v1/tech/kanji/"+insertedKanji.ID.String(), ToJson(&insertedKanji), &models.Kanji{})
+	assert.Equal(t, http.StatusOK, httpResCode)
+}
+
+func Test_should_read_kanji_with_its_words(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	word.Kanji = "木曜日"
+	var insertedWord models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	kanji := generateKanji("曜")
+	var insertedKanji models.Kanji
+	httpResCode = post("/api/v1/tech/kanji", ToJson(&kanji), &insertedKanji)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	// Kanji can be fetched by character as well as by ID
+	for _, key := range []string{url.PathEscape("曜"), insertedKanji.ID.String()} {
+		var fetchedKanjiDto dto.KanjiDTO
+		httpResCode = get("/api/v1/app/kanji/"+key+"?lang=fr", &fetchedKanjiDto)
+
+		assert.Equal(t, http.StatusOK, httpResCode)
+		assert.Equal(t, "曜", fetchedKanjiDto.Character)
+		assert.Equal(t, kanji.MeaningsFr, fetchedKanjiDto.Meanings)
+		assert.Equal(t, 1, len(fetchedKanjiDto.Words))
+		assert.Equal(t, insertedWord.ID, fetchedKanjiDto.Words[0].ID)
+	}
+}
+
+func generateKanji(character string) models.Kanji {
+	return models.Kanji{
+		Character:   character,
+		StrokeCount: 4,
+		Radical:     character,
+		Components:  []string{character},
+		OnReadings:  []string{"カ"},
+		KunReadings: []string{"ひ"},
+		MeaningsEn:  []string{"meaning"},
+		MeaningsFr:  []string{"sens"},
+		JLPT:        4,
+		Grade:       1,
+	}
+}
+
+func Test_should_lookup_kanji_by_components(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	word.Kanji = "沐浴"
+	var insertedWord models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	kanjiList := []models.Kanji{generateKanji("沐"), generateKanji("林"), generateKanji("浴")}
+	kanjiList[0].Components = []string{"氵", "木"}
+	kanjiList[0].StrokeCount = 7
+	kanjiList[1].Components = []string{"木"}
+	kanjiList[2].Components = []string{"氵", "谷"}
+	for _, kanji := range kanjiList {
+		var insertedKanji models.Kanji
+		httpResCode = post("/api/v1/tech/kanji", ToJson(&kanji), &insertedKanji)
+		assert.Equal(t, http.StatusCreated, httpResCode)
+	}
+
+	var foundKanji []dto.KanjiDTO
+	httpResCode = get("/api/v1/app/kanji/lookup?components="+url.QueryEscape("氵,木"), &foundKanji)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 1, len(foundKanji))
+	assert.Equal(t, "沐", foundKanji[0].Character)
+	assert.Equal(t, 1, len(foundKanji[0].Words))
+	assert.Equal(t, insertedWord.ID, foundKanji[0].Words[0].ID)
+
+	httpResCode = get("/api/v1/app/kanji/lookup", &foundKanji)
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"net/http"
+	"testing"
+)
+
+func Test_should_create_learning_path_with_ordered_steps(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	var insertedWord models.Word
+	post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+
+	path := models.LearningPath{
+		En: "Path En",
+		Fr: "Path Fr",
+		Steps: []*models.LearningPathStep{
+			{LevelID: insertedWord.Levels[0].ID, Position: 20},
+			{LevelID: insertedWord.Levels[1].ID, Position: 10},
+		},
+	}
+	var insertedPath models.LearningPath
+	httpResCode := post("/api/v1/tech/paths", ToJson(&path), &insertedPath)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+	assert.NotEqual(t, uuid.Nil, insertedPath.ID)
+	assert.Len(t, insertedPath.Steps, 2)
+	assert.Equal(t, insertedWord.Levels[1].ID, insertedPath.Steps[0].LevelID)
+	assert.Equal(t, 1, insertedPath.Steps[0].Position)
+	assert.Equal(t, insertedWord.Levels[0].ID, insertedPath.Steps[1].LevelID)
+	assert.Equal(t, 2, insertedPath.Steps[1].Position)
+
+	// Steps without position follow the positioned ones
+	path.Steps = []*models.LearningPathStep{
+		{LevelID: insertedWord.Levels[0].ID},
+		{LevelID: insertedWord.Levels[1].ID, Position: 5},
+	}
+	var updatedPath models.LearningPath
+	httpResCode = put("/api/v1/tech/paths/"+insertedPath.ID.String(), ToJson(&path), &updatedPath)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	if assert.Len(t, updatedPath.Steps, 2) {
+		assert.Equal(t, insertedWord.Levels[1].ID, updatedPath.Steps[0].LevelID)
+		assert.Equal(t, insertedWord.Levels[0].ID, updatedPath.Steps[1].LevelID)
+		assert.Equal(t, 2, updatedPath.Steps[1].Position)
+	}
+
+	path.Steps = path.Steps[:1]
+	httpResCode = put("/api/v1/tech/paths/"+insertedPath.ID.String(), ToJson(&path), &updatedPath)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Len(t, updatedPath.Steps, 1)
+
+	httpResCode = del("/api/v1/tech/paths/" + insertedPath.ID.String())
+	assert.Equal(t, http.StatusNoContent, httpResCode)
+	httpResCode = get("/api/v1/tech/paths/"+insertedPath.ID.String(), &updatedPath)
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+}
+
+func Test_should_refuse_invalid_learning_path(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	var insertedWord models.Word
+	post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	levelID := insertedWord.Levels[0].ID
+
+	var insertedPath models.LearningPath
+	duplicated := models.LearningPath{Steps: []*models.LearningPathStep{{LevelID: levelID}, {LevelID: levelID}}}
+	httpResCode := post("/api/v1/tech/paths", ToJson(&duplicated), &insertedPath)
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+
+	invalidThreshold := models.LearningPath{UnlockThreshold: 1.5, Steps: []*models.LearningPathStep{{LevelID: levelID}}}
+	httpResCode = post("/api/v1/tech/paths", ToJson(&invalidThreshold), &insertedPath)
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+
+	missingLevel := models.LearningPath{Steps: []*models.LearningPathStep{{LevelID: uuid.New()}}}
+	httpResCode = post("/api/v1/tech/paths", ToJson(&missingLevel), &insertedPath)
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+
+	customLevel := GenerateLevel()
+	var insertedLevel models.Level
+	post("/api/v1/app/levels", ToJson(&customLevel), &insertedLevel)
+	withCustomLevel := models.LearningPath{Steps: []*models.LearningPathStep{{LevelID: insertedLevel.ID}}}
+	httpResCode = post("/api/v1/tech/paths", ToJson(&withCustomLevel), &insertedPath)
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+}
+
+func Test_should_unlock_next_level_of_learning_path(t *testing.T) {
+	t.Parallel()
+
+	words := []models.Word{GenerateWord(), GenerateWord()}
+	for i := range words {
+		post("/api/v1/tech/words", ToJson(&words[i]), &words[i])
+	}
+	path := models.LearningPath{
+		En: "Path En",
+		Fr: "Path Fr",
+		Steps: []*models.LearningPathStep{
+			{LevelID: words[0].Levels[0].ID},
+			{LevelID: words[1].Levels[0].ID},
+		},
+	}
+	var insertedPath models.LearningPath
+	post("/api/v1/tech/paths", ToJson(&path), &insertedPath)
+
+	var progress dto.LearningPathProgress
+	httpResCode := get("/api/v1/app/paths/"+insertedPath.ID.String()+"/progress?lang=fr", &progress)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, "Path Fr", progress.Name)
+	assert.Equal(t, 0.5, progress.UnlockThreshold)
+	assert.Equal(t, words[0].Levels[0].ID, *progress.CurrentLevelID)
+	assert.False(t, progress.Completed)
+	assert.Equal(t, int64(1), progress.Steps[0].WordCount)
+	assert.True(t, progress.Steps[0].Unlocked)
+	assert.False(t, progress.Steps[0].Completed)
+	assert.False(t, progress.Steps[1].Unlocked)
+
+	// Three answers in a row put the word of the first level in review
+	results := dto.QuizResults{Results: []dto.WordQuizResult{{WordID: words[0].ID, Status: dto.Success}}}
+	for i := 0; i < 3; i++ {
+		httpResCode = postNoContent("/api/v1/app/quiz/results", ToJson(&results))
+		assert.Equal(t, http.StatusOK, httpResCode)
+	}
+
+	httpResCode = get("/api/v1/app/paths/"+insertedPath.ID.String()+"/progress", &progress)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, int64(1), progress.Steps[0].LearnedCount)
+	assert.Equal(t, 1.0, progress.Steps[0].Share)
+	assert.True(t, progress.Steps[0].Completed)
+	assert.True(t, progress.Steps[1].Unlocked)
+	assert.Equal(t, words[1].Levels[0].ID, *progress.CurrentLevelID)
+
+	httpResCode = get("/api/v1/app/paths/"+uuid.New().String()+"/progress", &progress)
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+}
+
+func Test_should_order_level_names_by_position(t *testing.T) {
+	t.Parallel()
+
+	level := GenerateLevel()
+	level.LevelNames[0].Position = 2
+	level.LevelNames[1].Position = 1
+	var insertedLevel models.Level
+	post("/api/v1/tech/levels", ToJson(&level), &insertedLevel)
+
+	var fetchedLevel models.Level
+	httpResCode := get("/api/v1/tech/levels/"+insertedLevel.ID.String(), &fetchedLevel)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, level.LevelNames[1].ID, fetchedLevel.LevelNames[0].ID)
+	assert.Equal(t, level.LevelNames[0].ID, fetchedLevel.LevelNames[1].ID)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/xanagit/kotoquiz-api/dto"
 	"github.com/xanagit/kotoquiz-api/models"
 	"net/http"
 	"strconv"
@@ -60,8 +61,15 @@ func Test_should_update_level(t *testing.T) {
 	assert.Equal(t, http.StatusOK, httpResCode)
 	assert.NotEqual(t, level.ID, updatedTag.ID)
 	assert.Equal(t, insertedTag.ID, updatedTag.ID)
-	level.ID = updatedTag.ID
-	assert.Equal(t, level, updatedTag)
+	// The labels of a built-in level can be shared, their texts are only changed through the label endpoints
+	assert.Equal(t, insertedTag.Category, updatedTag.Category)
+	assert.Equal(t, insertedTag.LevelNames, updatedTag.LevelNames)
+	var fetchedLevel models.Level
+	get("/api/v1/tech/levels/"+insertedTag.ID.String(), &fetchedLevel)
+	assert.Equal(t, "Category En", fetchedLevel.Category.En)
+	for _, levelName := range fetchedLevel.LevelNames {
+		assert.NotEqual(t, "LevelNames En Updated", levelName.En)
+	}
 }
 
 func Test_should_delete_level(t *testing.T) {
@@ -107,6 +115,122 @@ func Test_should_list_Levels(t *testing.T) {
 	}
 }
 
+func Test_should_manage_words_of_user_level(t *testing.T) {
+	t.Parallel()
+
+	level := GenerateLevel()
+	var insertedLevel models.Level
+	httpResCode := post("/api/v1/app/levels", ToJson(&level), &insertedLevel)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+	assert.Equal(t, models.CustomLevel, insertedLevel.Type)
+	assert.Equal(t, "test-user", insertedLevel.OwnerID)
+	assert.Equal(t, models.Private, insertedLevel.Visibility)
+
+	words := []models.Word{GenerateWord(), GenerateWord()}
+	request := dto.LevelWordsRequest{}
+	for i := range words {
+		post("/api/v1/tech/words", ToJson(&words[i]), &words[i])
+		request.WordIDs = append(request.WordIDs, words[i].ID)
+	}
+	// A word given twice is only added once
+	addedWordIDs := request.WordIDs
+	request.WordIDs = append(request.WordIDs, words[0].ID)
+	var updatedLevel models.Level
+	httpResCode = post("/api/v1/app/levels/"+insertedLevel.ID.String()+"/words", ToJson(&request), &updatedLevel)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.ElementsMatch(t, addedWordIDs, updatedLevel.WordIDs)
+	assert.Equal(t, `"2"`, getETag("/api/v1/tech/levels/"+insertedLevel.ID.String()))
+
+	// The words of the level can be quizzed
+	var page dto.WordPage
+	httpResCode = get("/api/v1/app/words/catalogue?levels="+insertedLevel.ID.String(), &page)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, int64(2), page.Total)
+
+	httpResCode = del("/api/v1/app/levels/" + insertedLevel.ID.String() + "/words/" + words[0].ID.String())
+	assert.Equal(t, http.StatusNoContent, httpResCode)
+
+	var fetchedLevel models.Level
+	httpResCode = get("/api/v1/app/levels/"+insertedLevel.ID.String(), &fetchedLevel)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, []uuid.UUID{words[1].ID}, fetchedLevel.WordIDs)
+	assert.Equal(t, `"3"`, getETag("/api/v1/tech/levels/"+insertedLevel.ID.String()))
+
+	// The custom level is not listed among the levels of the words
+	var wordDto dto.WordDTO
+	get("/api/v1/app/words/"+words[1].ID.String(), &wordDto)
+	assert.Equal(t, len(words[1].Levels), len(wordDto.Levels))
+
+	httpResCode = del("/api/v1/app/levels/" + insertedLevel.ID.String())
+	assert.Equal(t, http.StatusNoContent, httpResCode)
+	httpResCode = get("/api/v1/app/levels/"+insertedLevel.ID.String(), &fetchedLevel)
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+}
+
+func Test_should_filter_words_by_custom_levels_visible_to_user(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	var insertedWord models.Word
+	post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	request := dto.LevelWordsRequest{WordIDs: []uuid.UUID{insertedWord.ID}}
+
+	var levelIDs []string
+	for i := 0; i < 2; i++ {
+		level := GenerateLevel()
+		var insertedLevel models.Level
+		httpResCode := post("/api/v1/app/levels", ToJson(&level), &insertedLevel)
+		assert.Equal(t, http.StatusCreated, httpResCode)
+		httpResCode = post("/api/v1/app/levels/"+insertedLevel.ID.String()+"/words", ToJson(&request), &models.Level{})
+		assert.Equal(t, http.StatusOK, httpResCode)
+		levelIDs = append(levelIDs, insertedLevel.ID.String())
+	}
+	httpResCode := post("/api/v1/app/levels/"+levelIDs[1]+"/publish?visibility=SHARED", "", &models.Level{})
+	assert.Equal(t, http.StatusOK, httpResCode)
+	privateURL := "/api/v1/app/words/catalogue?levels=" + levelIDs[0]
+	sharedURL := "/api/v1/app/words/catalogue?levels=" + levelIDs[1]
+
+	// The owner sees the words of both levels
+	var page dto.WordPage
+	get(privateURL, &page)
+	assert.Equal(t, int64(1), page.Total)
+
+	// Another user only sees the words of the shared level
+	page = dto.WordPage{}
+	httpResCode = requestAs("other-user", http.MethodGet, privateURL, "", &page)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, int64(0), page.Total)
+	page = dto.WordPage{}
+	requestAs("other-user", http.MethodGet, sharedURL, "", &page)
+	assert.Equal(t, int64(1), page.Total)
+
+	var wordIds dto.WordIdsList
+	requestAs("other-user", http.MethodGet, "/api/v1/app/words/q?levels="+levelIDs[0]+"&userId="+uuid.New().String(), "", &wordIds)
+	assert.Empty(t, wordIds.Ids)
+}
+
+func Test_should_refuse_user_changes_to_built_in_level(t *testing.T) {
+	t.Parallel()
+
+	level := GenerateLevel()
+	var insertedLevel models.Level
+	post("/api/v1/tech/levels", ToJson(&level), &insertedLevel)
+
+	var fetchedLevel models.Level
+	httpResCode := get("/api/v1/app/levels/"+insertedLevel.ID.String(), &fetchedLevel)
+	assert.Equal(t, http.StatusOK, httpResCode)
+
+	httpResCode = put("/api/v1/app/levels/"+insertedLevel.ID.String(), ToJson(&insertedLevel), &fetchedLevel)
+	assert.Equal(t, http.StatusForbidden, httpResCode)
+	httpResCode = del("/api/v1/app/levels/" + insertedLevel.ID.String())
+	assert.Equal(t, http.StatusForbidden, httpResCode)
+
+	customLevel := GenerateLevel()
+	customLevel.Visibility = "EVERYONE"
+	httpResCode = post("/api/v1/app/levels", ToJson(&customLevel), &fetchedLevel)
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+}
+
 func assertLevelExistsInList(t *testing.T, level models.Level, levels []models.Level) {
 	for _, currLevel := range levels {
 		if currLevel.ID == level.ID {
@@ -139,3 +263,28 @@ func GenerateLevel() models.Level {
 	}
 	return restInputLevel
 }
+
+func Test_should_patch_level(t *testing.T) {
+	t.Parallel()
+
+	level := GenerateLevel()
+	var insertedLevel models.Level
+	post("/api/v1/tech/levels", ToJson(&level), &insertedLevel)
+	etag := getETag("/api/v1/tech/levels/" + insertedLevel.ID.String())
+
+	// Level names missing from the given array are removed from the level
+	var patchedLevel models.Level
+	httpResCode, newETag := patch("/api/v1/tech/levels/"+insertedLevel.ID.String(),
+		`{"levelNames": [{"id": "`+insertedLevel.LevelNames[1].ID.String()+`"}]}`, etag, &patchedLevel)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.NotEqual(t, etag, newETag)
+	assert.Equal(t, insertedLevel.Category, patchedLevel.Category)
+	assert.Equal(t, 1, len(patchedLevel.LevelNames))
+	assert.Equal(t, insertedLevel.LevelNames[1].ID, patchedLevel.LevelNames[0].ID)
+
+	httpResCode, _ = patch("/api/v1/tech/levels/"+insertedLevel.ID.String(), `{"levelNames": []}`, etag, &models.Level{})
+	assert.Equal(t, http.StatusPreconditionFailed, httpResCode)
+	var fetchedLevel models.Level
+	get("/api/v1/tech/levels/"+insertedLevel.ID.String(), &fetchedLevel)
+	assert.Equal(t, 1, len(fetchedLevel.LevelNames))
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// wavSample is the header of an empty WAV file
+var wavSample = []byte("RIFF\x24\x00\x00\x00WAVEfmt \x10\x00\x00\x00\x01\x00\x01\x00\x44\xac\x00\x00\x88\x58\x01\x00\x02\x00\x10\x00data\x00\x00\x00\x00")
+
+// pngSample is the signature and header chunk of a 1x1 PNG image, without image data
+var pngSample = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89")
+
+func Test_should_upload_and_delete_word_audio(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	var insertedWord models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	var media dto.MediaDTO
+	httpResCode = postFile("/api/v1/tech/words/"+insertedWord.ID.String()+"/audio", "kanki.wav", "audio/wav", wavSample, &media)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.True(t, strings.HasPrefix(media.Key, "words/"+insertedWord.ID.String()+"/audio-"))
+	assert.True(t, strings.HasSuffix(media.Key, ".wav"))
+	assert.Equal(t, `"2"`, getETag("/api/v1/tech/words/"+insertedWord.ID.String()))
+
+	// The file is stored in the media directory and served by the API
+	stored, err := os.ReadFile(filepath.Join(mediaDir, filepath.FromSlash(media.Key)))
+	assert.NoError(t, err)
+	assert.Equal(t, wavSample, stored)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", strings.TrimPrefix(media.URL, "http://localhost:8080"), nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, wavSample, w.Body.Bytes())
+
+	var fetchedWordDto dto.WordDTO
+	httpResCode = get("/api/v1/app/words/"+insertedWord.ID.String(), &fetchedWordDto)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, media.URL, fetchedWordDto.AudioURL)
+
+	// Updating the word keeps its audio
+	httpResCode = put("/api/v1/tech/words/"+insertedWord.ID.String(), ToJson(&insertedWord), &models.Word{})
+	assert.Equal(t, http.StatusOK, httpResCode)
+	var updatedWord models.Word
+	httpResCode = get("/api/v1/tech/words/"+insertedWord.ID.String(), &updatedWord)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, media.Key, updatedWord.AudioKey)
+
+	httpResCode = del("/api/v1/tech/words/" + insertedWord.ID.String() + "/audio")
+	assert.Equal(t, http.StatusNoContent, httpResCode)
+	_, err = os.Stat(filepath.Join(mediaDir, filepath.FromSlash(media.Key)))
+	assert.True(t, os.IsNotExist(err))
+
+	fetchedWordDto = dto.WordDTO{}
+	httpResCode = get("/api/v1/app/words/"+insertedWord.ID.String(), &fetchedWordDto)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Empty(t, fetchedWordDto.AudioURL)
+}
+
+func Test_should_replace_word_image_with_uploaded_image(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	var insertedWord models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	var firstMedia dto.MediaDTO
+	httpResCode = postFile("/api/v1/tech/words/"+insertedWord.ID.String()+"/image", "kanki.png", "image/png", generatePng(600, 300, 1), &firstMedia)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	var secondMedia dto.MediaDTO
+	httpResCode = postFile("/api/v1/tech/words/"+insertedWord.ID.String()+"/image", "kanki.png", "image/png", generatePng(600, 300, 2), &secondMedia)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.NotEqual(t, firstMedia.Key, secondMedia.Key)
+
+	// The previous image is deleted in every size
+	for _, url := range []string{firstMedia.ImageURLs.Small, firstMedia.ImageURLs.Medium, firstMedia.ImageURLs.Large} {
+		_, err := os.Stat(mediaFile(url))
+		assert.True(t, os.IsNotExist(err))
+	}
+
+	var fetchedWordDto dto.WordDTO
+	httpResCode = get("/api/v1/app/words/"+insertedWord.ID.String(), &fetchedWordDto)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, secondMedia.URL, fetchedWordDto.ImageURL)
+	assert.Equal(t, secondMedia.ImageURLs, fetchedWordDto.ImageURLs)
+
+	// Deleting the word moves it to the trash, its media are kept until it is purged
+	httpResCode = del("/api/v1/tech/words/" + insertedWord.ID.String())
+	assert.Equal(t, http.StatusNoContent, httpResCode)
+	_, err := os.Stat(mediaFile(secondMedia.URL))
+	assert.NoError(t, err)
+}
+
+func Test_should_resize_uploaded_image(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	var insertedWord models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	var media dto.MediaDTO
+	httpResCode = postFile("/api/v1/tech/words/"+insertedWord.ID.String()+"/image", "kanki.png", "image/png", generatePng(600, 300, 3), &media)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.True(t, strings.HasPrefix(media.Key, "images/"))
+
+	for url, expectedSize := range map[string]image.Point{
+		media.ImageURLs.Small:  {X: 160, Y: 80},
+		media.ImageURLs.Medium: {X: 480, Y: 240},
+		media.ImageURLs.Large:  {X: 600, Y: 300},
+	} {
+		file, err := os.Open(mediaFile(url))
+		assert.NoError(t, err)
+		config, format, err := image.DecodeConfig(file)
+		_ = file.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, "png", format)
+		assert.Equal(t, expectedSize, image.Point{X: config.Width, Y: config.Height})
+	}
+}
+
+func Test_should_strip_metadata_of_uploaded_image(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	var insertedWord models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	exifMarker := []byte("KotoquizTestCamera")
+	upload := generateJpegWithExif(300, 200, exifMarker)
+	assert.True(t, bytes.Contains(upload, []byte("Exif\x00\x00")))
+
+	var media dto.MediaDTO
+	httpResCode = postFile("/api/v1/tech/words/"+insertedWord.ID.String()+"/image", "kanki.jpg", "image/jpeg", upload, &media)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.True(t, strings.HasSuffix(media.Key, ".jpg"))
+
+	// Every stored size is a valid JPEG image without the EXIF segment of the upload
+	for _, url := range []string{media.ImageURLs.Small, media.ImageURLs.Medium, media.ImageURLs.Large} {
+		stored, err := os.ReadFile(mediaFile(url))
+		assert.NoError(t, err)
+		_, format, err := image.DecodeConfig(bytes.NewReader(stored))
+		assert.NoError(t, err)
+		assert.Equal(t, "jpeg", format)
+		assert.False(t, bytes.Contains(stored, []byte("Exif\x00\x00")), url)
+		assert.False(t, bytes.Contains(stored, exifMarker), url)
+	}
+}
+
+func Test_should_share_identical_images_between_words(t *testing.T) {
+	t.Parallel()
+
+	var wordIDs []string
+	var keys []string
+	for i := 0; i < 2; i++ {
+		word := GenerateWord()
+		var insertedWord models.Word
+		httpResCode := post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+		assert.Equal(t, http.StatusCreated, httpResCode)
+
+		var media dto.MediaDTO
+		httpResCode = postFile("/api/v1/tech/words/"+insertedWord.ID.String()+"/image", "kanki.png", "image/png", generatePng(200, 200, 4), &media)
+		assert.Equal(t, http.StatusOK, httpResCode)
+		wordIDs = append(wordIDs, insertedWord.ID.String())
+		keys = append(keys, media.Key)
+	}
+	assert.Equal(t, keys[0], keys[1])
+	storedFile := filepath.Join(mediaDir, filepath.FromSlash(keys[0]))
+
+	// The image is kept as long as a word uses it
+	httpResCode := del("/api/v1/tech/words/" + wordIDs[0] + "/image")
+	assert.Equal(t, http.StatusNoContent, httpResCode)
+	_, err := os.Stat(storedFile)
+	assert.NoError(t, err)
+
+	httpResCode = del("/api/v1/tech/words/" + wordIDs[1] + "/image")
+	assert.Equal(t, http.StatusNoContent, httpResCode)
+	_, err = os.Stat(storedFile)
+	assert.True(t, os.IsNotExist(err))
+}
+
+// generatePng encodes a gradient image, the seed making images of the same size different
+func generatePng(width int, height int, seed int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x * seed), G: uint8(y * seed), B: uint8(seed), A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+// generateJpegWithExif encodes a gradient image as a JPEG carrying an EXIF segment with the given camera model
+func generateJpegWithExif(width int, height int, cameraModel []byte) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var encoded bytes.Buffer
+	_ = jpeg.Encode(&encoded, img, nil)
+
+	// Little-endian TIFF header followed by an IFD holding the camera model (tag 0x0110, ASCII)
+	model := append(append([]byte{}, cameraModel...), 0)
+	tiff := []byte("II*\x00\x08\x00\x00\x00")
+	tiff = append(tiff, 1, 0, 0x10, 0x01, 2, 0)
+	tiff = binary.LittleEndian.AppendUint32(tiff, uint32(len(model)))
+	tiff = binary.LittleEndian.AppendUint32(tiff, 26)
+	tiff = append(tiff, 0, 0, 0, 0)
+	tiff = append(tiff, model...)
+	app1 := append([]byte("Exif\x00\x00"), tiff...)
+
+	// The APP1 segment goes right after the start of image marker
+	var withExif bytes.Buffer
+	withExif.Write(encoded.Bytes()[:2])
+	withExif.Write([]byte{0xFF, 0xE1})
+	_ = binary.Write(&withExif, binary.BigEndian, uint16(len(app1)+2))
+	withExif.Write(app1)
+	withExif.Write(encoded.Bytes()[2:])
+	return withExif.Bytes()
+}
+
+// mediaFile returns the file of the local media storage served at the given URL
+func mediaFile(url string) string {
+	key := strings.TrimPrefix(url, "http://localhost:8080/media/")
+	return filepath.Join(mediaDir, filepath.FromSlash(key))
+}
+
+func Test_should_reject_invalid_media(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	var insertedWord models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+	audioURL := "/api/v1/tech/words/" + insertedWord.ID.String() + "/audio"
+
+	// Content type not accepted
+	httpResCode = postFile(audioURL, "kanki.png", "image/png", pngSample, &map[string]string{})
+	assert.Equal(t, http.StatusUnsupportedMediaType, httpResCode)
+
+	// Truncated image
+	httpResCode = postFile("/api/v1/tech/words/"+insertedWord.ID.String()+"/image", "kanki.png", "image/png", pngSample, &map[string]string{})
+	assert.Equal(t, http.StatusUnsupportedMediaType, httpResCode)
+
+	// Content not matching the declared type
+	httpResCode = postFile(audioURL, "kanki.mp3", "audio/mpeg", []byte("<html><body>not audio</body></html>"), &map[string]string{})
+	assert.Equal(t, http.StatusUnsupportedMediaType, httpResCode)
+
+	// File too large
+	largeSample := append(append([]byte{}, wavSample...), make([]byte, 64*1024)...)
+	httpResCode = postFile(audioURL, "kanki.wav", "audio/wav", largeSample, &map[string]string{})
+	assert.Equal(t, http.StatusRequestEntityTooLarge, httpResCode)
+
+	// Missing file
+	httpResCode = post(audioURL, "", &map[string]string{})
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+
+	// Unknown word
+	httpResCode = postFile("/api/v1/tech/words/00000000-0000-0000-0000-000000000001/audio", "kanki.wav", "audio/wav", wavSample, &map[string]string{})
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+
+	var fetchedWord models.Word
+	httpResCode = get("/api/v1/tech/words/"+insertedWord.ID.String(), &fetchedWord)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Empty(t, fetchedWord.AudioKey)
+}
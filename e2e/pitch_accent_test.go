@@ -0,0 +1,60 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_should_read_wordDto_with_pitch_accent(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	word.Kanji = "今日"
+	word.Yomi = "きょう"
+	word.PitchAccents = []*models.WordPitchAccent{{Downstep: 1}}
+	var insertedWord models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+	assert.Equal(t, models.Atamadaka, insertedWord.PitchAccents[0].Pattern)
+
+	var fetchedWordDto dto.WordDTO
+	httpResCode = get("/api/v1/app/words/"+insertedWord.ID.String(), &fetchedWordDto)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 1, len(fetchedWordDto.Pitch))
+	assert.Equal(t, []string{"きょ", "う"}, fetchedWordDto.Pitch[0].Morae)
+	assert.Equal(t, []bool{true, false}, fetchedWordDto.Pitch[0].High)
+	assert.False(t, fetchedWordDto.Pitch[0].ParticleHigh)
+}
+
+const accentsSample = "弟\tおとうと\t4\n" +
+	"桜\tさくら\t(名)0,(名)0\n" +
+	"unknown line\n"
+
+func Test_should_import_pitch_accents(t *testing.T) {
+	word := GenerateWord()
+	word.Kanji = "弟"
+	word.Yomi = "おとうと"
+	var insertedWord models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(importDataDir, "accents.txt"), []byte(accentsSample), 0o644))
+
+	var report dto.ImportReport
+	httpResCode = post("/api/v1/tech/import/accents", `{"path": "accents.txt"}`, &report)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 1, report.Imported)
+
+	var fetchedWordDto dto.WordDTO
+	httpResCode = get("/api/v1/app/words/"+insertedWord.ID.String(), &fetchedWordDto)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 1, len(fetchedWordDto.Pitch))
+	assert.Equal(t, models.Odaka, fetchedWordDto.Pitch[0].Pattern)
+	assert.Equal(t, []bool{false, true, true, true}, fetchedWordDto.Pitch[0].High)
+	assert.Equal(t, `"2"`, getETag("/api/v1/tech/words/"+insertedWord.ID.String()))
+}
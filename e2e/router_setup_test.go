@@ -26,12 +26,25 @@ var (
 	setupOnce   sync.Once
 	ready       sync.WaitGroup
 	logger      *zap.Logger
+	// importDataDir is the directory the import endpoints read their files from
+	importDataDir string
+	// mediaDir is the directory the local media storage writes the uploaded files to
+	mediaDir string
+	// db gives the tests access to what the API does not expose, like the learning histories
+	db *gorm.DB
 )
 
+// testUserHeader lets a test act as another user than "test-user"
+const testUserHeader = "X-Test-User"
+
 func (m *MockAuthMiddleware) AuthRequired() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		subject := c.GetHeader(testUserHeader)
+		if subject == "" {
+			subject = "test-user"
+		}
 		mockClaims := middlewares.Claims{
-			Subject: "test-user",
+			Subject: subject,
 			RealmAccess: struct {
 				Roles []string `json:"roles"`
 			}{
@@ -165,12 +178,25 @@ func setupRouter(logger *zap.Logger) (*gin.Engine, error) {
 	}
 
 	dsn := "host=localhost user=postgres password=password dbname=testdb port=5433 sslmode=disable"
-	db, err := initialisation.DatabaseConnection(dsn, logger)
+	var err error
+	db, err = initialisation.DatabaseConnection(dsn, logger)
 	if err != nil {
 		logger.Error("Failed to migrate database", zap.Error(err))
 		return nil, err
 	}
 
+	importDataDir, err = os.MkdirTemp("", "kotoquiz-import")
+	if err != nil {
+		logger.Error("Failed to create import data directory", zap.Error(err))
+		return nil, err
+	}
+
+	mediaDir, err = os.MkdirTemp("", "kotoquiz-media")
+	if err != nil {
+		logger.Error("Failed to create media directory", zap.Error(err))
+		return nil, err
+	}
+
 	cfg := &config.Config{
 		Database: config.DatabaseConfig{
 			Host:     "localhost",
@@ -179,6 +205,21 @@ func setupRouter(logger *zap.Logger) (*gin.Engine, error) {
 			Name:     "testdb",
 			Port:     5433,
 		},
+		Import: config.ImportConfig{
+			DataDir: importDataDir,
+		},
+		Media: config.MediaConfig{
+			Backend:      "local",
+			LocalDir:     mediaDir,
+			PublicURL:    "http://localhost:8080",
+			MaxAudioSize: 64 * 1024,
+			MaxImageSize: 64 * 1024,
+			AudioTypes:   []string{"audio/mpeg", "audio/ogg", "audio/wav"},
+			ImageTypes:   []string{"image/jpeg", "image/png"},
+		},
+		Learning: config.LearningConfig{
+			UnlockThreshold: 0.5,
+		},
 	}
 
 	components := initialisation.InitializeAppComponents(db, cfg)
@@ -3,8 +3,10 @@ package main
 import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/xanagit/kotoquiz-api/dto"
 	"github.com/xanagit/kotoquiz-api/models"
 	"net/http"
+	"slices"
 	"strconv"
 	"testing"
 )
@@ -118,3 +120,210 @@ func generateLabel(labelType models.LabelType) models.Label {
 	}
 	return restInputLabel
 }
+
+func Test_should_nest_tags_and_filter_words_with_sub_tags(t *testing.T) {
+	t.Parallel()
+	var httpResCode int
+
+	food := generateLabel(models.Tag)
+	var insertedFood models.Label
+	post("/api/v1/tech/tags", ToJson(&food), &insertedFood)
+	fruit := generateLabel(models.Tag)
+	fruit.ParentID = &insertedFood.ID
+	var insertedFruit models.Label
+	httpResCode = post("/api/v1/tech/tags", ToJson(&fruit), &insertedFruit)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+	assert.Equal(t, insertedFood.ID, *insertedFruit.ParentID)
+	citrus := generateLabel(models.Tag)
+	citrus.ParentID = &insertedFruit.ID
+	var insertedCitrus models.Label
+	post("/api/v1/tech/tags", ToJson(&citrus), &insertedCitrus)
+
+	var tree dto.TagNode
+	httpResCode = get("/api/v1/app/tags/"+insertedFood.ID.String()+"/tree", &tree)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Len(t, tree.Children, 1)
+	assert.Equal(t, insertedFruit.ID, tree.Children[0].ID)
+	assert.Len(t, tree.Children[0].Children, 1)
+	assert.Equal(t, insertedCitrus.ID, tree.Children[0].Children[0].ID)
+
+	var roots []dto.TagNode
+	httpResCode = get("/api/v1/app/tags/tree", &roots)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.True(t, slices.ContainsFunc(roots, func(node dto.TagNode) bool { return node.ID == insertedFood.ID }))
+	assert.False(t, slices.ContainsFunc(roots, func(node dto.TagNode) bool { return node.ID == insertedCitrus.ID }))
+
+	// Food cannot be nested under one of its descendants
+	food.ParentID = &insertedCitrus.ID
+	var updatedFood models.Label
+	httpResCode = put("/api/v1/tech/tags/"+insertedFood.ID.String(), ToJson(&food), &updatedFood)
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+
+	word := GenerateWord()
+	word.Tags = []*models.Label{&insertedCitrus}
+	var insertedWord models.Word
+	post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+
+	var wordIds dto.WordIdsList
+	httpResCode = get("/api/v1/app/words/q?tags="+insertedFood.ID.String()+"&userId="+uuid.New().String(), &wordIds)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.NotContains(t, wordIds.Ids, insertedWord.ID.String())
+
+	httpResCode = get("/api/v1/app/words/q?tags="+insertedFood.ID.String()+"&includeChildren=true&userId="+uuid.New().String(), &wordIds)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Contains(t, wordIds.Ids, insertedWord.ID.String())
+
+	// Citrus is under Food while Fruit is in the trash, and back under Fruit once it is restored
+	httpResCode = del("/api/v1/tech/tags/" + insertedFruit.ID.String())
+	assert.Equal(t, http.StatusNoContent, httpResCode)
+	var fetchedCitrus models.Label
+	get("/api/v1/tech/tags/"+insertedCitrus.ID.String(), &fetchedCitrus)
+	assert.Equal(t, insertedFood.ID, *fetchedCitrus.ParentID)
+	tree = dto.TagNode{}
+	get("/api/v1/app/tags/"+insertedFood.ID.String()+"/tree", &tree)
+	if assert.Len(t, tree.Children, 1) {
+		assert.Equal(t, insertedCitrus.ID, tree.Children[0].ID)
+	}
+	httpResCode = get("/api/v1/app/words/q?tags="+insertedFood.ID.String()+"&includeChildren=true&userId="+uuid.New().String(), &wordIds)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Contains(t, wordIds.Ids, insertedWord.ID.String())
+
+	httpResCode = postNoContent("/api/v1/tech/trash/labels/"+insertedFruit.ID.String()+"/restore", "")
+	assert.Equal(t, http.StatusNoContent, httpResCode)
+	get("/api/v1/tech/tags/"+insertedCitrus.ID.String(), &fetchedCitrus)
+	assert.Equal(t, insertedFruit.ID, *fetchedCitrus.ParentID)
+}
+
+func Test_should_report_and_merge_duplicate_tags(t *testing.T) {
+	t.Parallel()
+	var httpResCode int
+
+	text := uuid.NewString()
+	tags := []models.Label{
+		{En: "Fruit " + text, Fr: "Fruit " + text},
+		{En: "fruit  " + text, Fr: "FRUIT " + text},
+		{En: "Fruits " + text, Fr: "Fruits " + text},
+	}
+	for i := range tags {
+		httpResCode = post("/api/v1/tech/tags", ToJson(&tags[i]), &tags[i])
+		assert.Equal(t, http.StatusCreated, httpResCode)
+	}
+	word := GenerateWord()
+	word.Tags = []*models.Label{&tags[1]}
+	var insertedWord models.Word
+	post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+
+	var report dto.LabelDuplicateReport
+	httpResCode = get("/api/v1/tech/labels/duplicates?type=TAG", &report)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	group := findDuplicateGroup(report, tags[0].ID)
+	assert.NotNil(t, group)
+	assert.True(t, group.Exact)
+	assert.Len(t, group.Labels, 2)
+	assert.Equal(t, tags[1].ID, group.Labels[0].ID) // The most used label comes first
+	assert.Equal(t, int64(1), group.Labels[0].Usages)
+
+	httpResCode = get("/api/v1/tech/labels/duplicates?type=TAG&maxDistance=1", &report)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	group = findDuplicateGroup(report, tags[0].ID)
+	assert.NotNil(t, group)
+	assert.False(t, group.Exact)
+	assert.Len(t, group.Labels, 3)
+
+	httpResCode = get("/api/v1/tech/labels/duplicates?maxDistance=10", &report)
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+
+	var mergeReport dto.LabelMergeReport
+	request := dto.LabelMergeRequest{TargetID: tags[0].ID, SourceIDs: []uuid.UUID{tags[1].ID, tags[2].ID}}
+	httpResCode = post("/api/v1/tech/labels/merge", ToJson(&request), &mergeReport)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 2, mergeReport.Merged)
+	assert.Equal(t, int64(1), mergeReport.Repointed)
+
+	var fetchedWord models.Word
+	get("/api/v1/tech/words/"+insertedWord.ID.String(), &fetchedWord)
+	assert.Len(t, fetchedWord.Tags, 1)
+	assert.Equal(t, tags[0].ID, fetchedWord.Tags[0].ID)
+	var fetchedTag models.Label
+	httpResCode = get("/api/v1/tech/tags/"+tags[1].ID.String(), &fetchedTag)
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+
+	// Labels of different types are not merged
+	category := generateLabel(models.Category)
+	post("/api/v1/tech/categories", ToJson(&category), &category)
+	request = dto.LabelMergeRequest{TargetID: tags[0].ID, SourceIDs: []uuid.UUID{category.ID}}
+	httpResCode = post("/api/v1/tech/labels/merge", ToJson(&request), &mergeReport)
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+}
+
+func Test_should_not_report_nor_merge_labels_of_custom_levels(t *testing.T) {
+	t.Parallel()
+	var httpResCode int
+
+	text := uuid.NewString()
+	category := models.Label{En: "Category " + text, Fr: "Category " + text}
+	var insertedCategory models.Label
+	post("/api/v1/tech/categories", ToJson(&category), &insertedCategory)
+	customLevel := GenerateLevel()
+	customLevel.Category = models.Label{En: "category " + text, Fr: "CATEGORY " + text, Type: models.Category}
+	var insertedLevel models.Level
+	httpResCode = post("/api/v1/app/levels", ToJson(&customLevel), &insertedLevel)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	var report dto.LabelDuplicateReport
+	httpResCode = get("/api/v1/tech/labels/duplicates?type=CATEGORY", &report)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Nil(t, findDuplicateGroup(report, insertedCategory.ID))
+	assert.Nil(t, findDuplicateGroup(report, insertedLevel.Category.ID))
+
+	var mergeReport dto.LabelMergeReport
+	request := dto.LabelMergeRequest{TargetID: insertedCategory.ID, SourceIDs: []uuid.UUID{insertedLevel.Category.ID}}
+	httpResCode = post("/api/v1/tech/labels/merge", ToJson(&request), &mergeReport)
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+	request = dto.LabelMergeRequest{TargetID: insertedLevel.Category.ID, SourceIDs: []uuid.UUID{insertedCategory.ID}}
+	httpResCode = post("/api/v1/tech/labels/merge", ToJson(&request), &mergeReport)
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+
+	var fetchedLevel models.Level
+	get("/api/v1/app/levels/"+insertedLevel.ID.String(), &fetchedLevel)
+	assert.Equal(t, insertedLevel.Category.ID, fetchedLevel.Category.ID)
+}
+
+func findDuplicateGroup(report dto.LabelDuplicateReport, labelID uuid.UUID) *dto.LabelDuplicateGroup {
+	for _, group := range report.Groups {
+		for _, label := range group.Labels {
+			if label.ID == labelID {
+				return group
+			}
+		}
+	}
+	return nil
+}
+
+func Test_should_patch_tag(t *testing.T) {
+	t.Parallel()
+
+	parent := generateLabel(models.Tag)
+	var insertedParent models.Label
+	post("/api/v1/tech/tags", ToJson(&parent), &insertedParent)
+	tag := generateLabel(models.Tag)
+	tag.ParentID = &insertedParent.ID
+	var insertedTag models.Label
+	post("/api/v1/tech/tags", ToJson(&tag), &insertedTag)
+	etag := getETag("/api/v1/tech/tags/" + insertedTag.ID.String())
+
+	var patchedTag models.Label
+	httpResCode, newETag := patch("/api/v1/tech/tags/"+insertedTag.ID.String(), `{"fr": "Fr Modifié", "parentId": null}`, etag, &patchedTag)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.NotEqual(t, etag, newETag)
+	assert.Equal(t, "Label En", patchedTag.En)
+	assert.Equal(t, "Fr Modifié", patchedTag.Fr)
+	assert.Nil(t, patchedTag.ParentID)
+
+	httpResCode, _ = patch("/api/v1/tech/tags/"+insertedTag.ID.String(), `{"en": "En Updated"}`, etag, &models.Label{})
+	assert.Equal(t, http.StatusPreconditionFailed, httpResCode)
+	httpResCode, _ = patch("/api/v1/tech/tags/"+insertedParent.ID.String(), `{"parentId": "`+insertedTag.ID.String()+`"}`, "", &models.Label{})
+	assert.Equal(t, http.StatusOK, httpResCode)
+	httpResCode, _ = patch("/api/v1/tech/tags/"+insertedTag.ID.String(), `{"parentId": "`+insertedParent.ID.String()+`"}`, "", &models.Label{})
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+}
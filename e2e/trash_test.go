@@ -0,0 +1,240 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func Test_should_trash_and_restore_word(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	var insertedWord models.Word
+	post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	wordURL := "/api/v1/tech/words/" + insertedWord.ID.String()
+
+	// A word learned by a user can be deleted, its history is kept
+	quizResults := dto.QuizResults{
+		UserID:  uuid.New().String(),
+		Results: []dto.WordQuizResult{{WordID: insertedWord.ID, Status: dto.Success}},
+	}
+	httpResCode := postNoContent("/api/v1/app/quiz/results", ToJson(&quizResults))
+	assert.Equal(t, http.StatusOK, httpResCode)
+	httpResCode = del(wordURL)
+	assert.Equal(t, http.StatusNoContent, httpResCode)
+
+	// The trashed word is left out of the app endpoints
+	httpResCode = get("/api/v1/app/words/"+insertedWord.ID.String(), &dto.WordDTO{})
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+	var wordIds dto.WordIdsList
+	get("/api/v1/app/words/q?tags="+word.Tags[0].ID.String(), &wordIds)
+	assert.NotContains(t, wordIds.Ids, insertedWord.ID.String())
+
+	var trash []*dto.TrashItem
+	httpResCode = get("/api/v1/tech/trash?kind=WORD", &trash)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	index := slices.IndexFunc(trash, func(item *dto.TrashItem) bool { return item.ID == insertedWord.ID })
+	if assert.NotEqual(t, -1, index) {
+		assert.Equal(t, dto.TrashWord, trash[index].Kind)
+		assert.True(t, trash[index].PurgeAt.After(trash[index].DeletedAt))
+	}
+
+	// The restored word comes back with its links
+	httpResCode = postNoContent("/api/v1/tech/trash/words/"+insertedWord.ID.String()+"/restore", "")
+	assert.Equal(t, http.StatusNoContent, httpResCode)
+	var restoredWord models.Word
+	httpResCode = get(wordURL, &restoredWord)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, insertedWord.Translation, restoredWord.Translation)
+	assert.Equal(t, 2, len(restoredWord.Tags))
+	assert.Equal(t, 3, len(restoredWord.Levels))
+	wordIds = dto.WordIdsList{}
+	get("/api/v1/app/words/q?tags="+word.Tags[0].ID.String(), &wordIds)
+	assert.Contains(t, wordIds.Ids, insertedWord.ID.String())
+
+	httpResCode = postNoContent("/api/v1/tech/trash/words/"+insertedWord.ID.String()+"/restore", "")
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+}
+
+func Test_should_trash_and_restore_level(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	var insertedWord models.Word
+	post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	level := word.Levels[0]
+	levelURL := "/api/v1/tech/levels/" + level.ID.String()
+
+	httpResCode := del(levelURL)
+	assert.Equal(t, http.StatusNoContent, httpResCode)
+	httpResCode = get(levelURL, &models.Level{})
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+	// A level in the trash is not found, whatever the version the update is based on
+	httpResCode, _ = patch(levelURL, `{"levelNames": []}`, `"2"`, &models.Level{})
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+	var trashedLevelWord models.Word
+	get("/api/v1/tech/words/"+insertedWord.ID.String(), &trashedLevelWord)
+	assert.Equal(t, 2, len(trashedLevelWord.Levels))
+
+	var trash []*dto.TrashItem
+	get("/api/v1/tech/trash?kind=LEVEL", &trash)
+	assert.True(t, slices.ContainsFunc(trash, func(item *dto.TrashItem) bool { return item.ID == level.ID }))
+
+	httpResCode = postNoContent("/api/v1/tech/trash/levels/"+level.ID.String()+"/restore", "")
+	assert.Equal(t, http.StatusNoContent, httpResCode)
+	var restoredLevel models.Level
+	httpResCode = get(levelURL, &restoredLevel)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, len(level.LevelNames), len(restoredLevel.LevelNames))
+	var restoredWord models.Word
+	get("/api/v1/tech/words/"+insertedWord.ID.String(), &restoredWord)
+	assert.Equal(t, 3, len(restoredWord.Levels))
+}
+
+func Test_should_trash_and_restore_tag(t *testing.T) {
+	t.Parallel()
+
+	tag := models.Label{En: "Trashed tag", Fr: "Tag supprimé"}
+	var insertedTag models.Label
+	post("/api/v1/tech/tags", ToJson(&tag), &insertedTag)
+	tagURL := "/api/v1/tech/tags/" + insertedTag.ID.String()
+	word := GenerateWord()
+	word.Tags = append(word.Tags, &insertedTag)
+	var insertedWord models.Word
+	post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	wordURL := "/api/v1/tech/words/" + insertedWord.ID.String()
+
+	httpResCode := del(tagURL + "?force=true")
+	assert.Equal(t, http.StatusNoContent, httpResCode)
+	httpResCode = get(tagURL, &models.Label{})
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+
+	// The trashed tag is left out of the words and of the filters
+	var taggedWord models.Word
+	get(wordURL, &taggedWord)
+	assert.Equal(t, 2, len(taggedWord.Tags))
+	var wordIds dto.WordIdsList
+	get("/api/v1/app/words/q?tags="+insertedTag.ID.String(), &wordIds)
+	assert.NotContains(t, wordIds.Ids, insertedWord.ID.String())
+
+	var trash []*dto.TrashItem
+	get("/api/v1/tech/trash?kind=LABEL", &trash)
+	index := slices.IndexFunc(trash, func(item *dto.TrashItem) bool { return item.ID == insertedTag.ID })
+	if assert.NotEqual(t, -1, index) {
+		assert.Equal(t, string(models.Tag), trash[index].Type)
+		assert.Equal(t, tag.En, trash[index].Name)
+	}
+
+	// The restored tag comes back on its words
+	httpResCode = postNoContent("/api/v1/tech/trash/labels/"+insertedTag.ID.String()+"/restore", "")
+	assert.Equal(t, http.StatusNoContent, httpResCode)
+	var restoredTag models.Label
+	httpResCode = get(tagURL, &restoredTag)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, tag.En, restoredTag.En)
+	get(wordURL, &taggedWord)
+	assert.Equal(t, 3, len(taggedWord.Tags))
+	assert.True(t, slices.ContainsFunc(taggedWord.Tags, func(label *models.Label) bool { return label.ID == insertedTag.ID }))
+	wordIds = dto.WordIdsList{}
+	get("/api/v1/app/words/q?tags="+insertedTag.ID.String(), &wordIds)
+	assert.Contains(t, wordIds.Ids, insertedWord.ID.String())
+}
+
+func Test_should_purge_expired_items_with_their_media_and_links(t *testing.T) {
+	t.Parallel()
+
+	tag := generateLabel(models.Tag)
+	var insertedTag models.Label
+	post("/api/v1/tech/tags", ToJson(&tag), &insertedTag)
+	word := GenerateWord()
+	var insertedWord models.Word
+	post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	keptWord := GenerateWord()
+	keptWord.Tags = []*models.Label{&insertedTag}
+	var insertedKeptWord models.Word
+	post("/api/v1/tech/words", ToJson(&keptWord), &insertedKeptWord)
+	var media dto.MediaDTO
+	httpResCode := postFile("/api/v1/tech/words/"+insertedWord.ID.String()+"/audio", "kanki.wav", "audio/wav", wavSample, &media)
+	assert.Equal(t, http.StatusOK, httpResCode)
+
+	level := GenerateLevel()
+	var insertedLevel models.Level
+	post("/api/v1/app/levels", ToJson(&level), &insertedLevel)
+
+	del("/api/v1/tech/words/" + insertedWord.ID.String())
+	del("/api/v1/tech/tags/" + insertedTag.ID.String() + "?force=true")
+	del("/api/v1/app/levels/" + insertedLevel.ID.String())
+	// All the items were deleted before the retention period
+	expiredAt := time.Now().AddDate(0, 0, -31)
+	db.Exec("UPDATE words SET deleted_at = ? WHERE id = ?", expiredAt, insertedWord.ID)
+	db.Exec("UPDATE labels SET deleted_at = ? WHERE id = ?", expiredAt, insertedTag.ID)
+	db.Exec("UPDATE levels SET deleted_at = ? WHERE id = ?", expiredAt, insertedLevel.ID)
+
+	var report dto.TrashPurgeReport
+	httpResCode = post("/api/v1/tech/trash/purge", "", &report)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.GreaterOrEqual(t, report.Words, 1)
+	assert.GreaterOrEqual(t, report.Labels, 1)
+	assert.GreaterOrEqual(t, report.Levels, 1)
+
+	_, err := os.Stat(filepath.Join(mediaDir, filepath.FromSlash(media.Key)))
+	assert.True(t, os.IsNotExist(err))
+	var links int64
+	db.Table("word_tag").Where("word_id = ? OR label_id = ?", insertedWord.ID, insertedTag.ID).Count(&links)
+	assert.Equal(t, int64(0), links)
+	db.Table("word_level").Where("word_id = ?", insertedWord.ID).Count(&links)
+	assert.Equal(t, int64(0), links)
+	// The category and the level names of a custom level are deleted with it
+	labelIDs := []uuid.UUID{insertedLevel.Category.ID}
+	for _, levelName := range insertedLevel.LevelNames {
+		labelIDs = append(labelIDs, levelName.ID)
+	}
+	var labels int64
+	db.Unscoped().Model(&models.Label{}).Where("id IN ?", labelIDs).Count(&labels)
+	assert.Equal(t, int64(0), labels)
+
+	httpResCode = postNoContent("/api/v1/tech/trash/words/"+insertedWord.ID.String()+"/restore", "")
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+	httpResCode = postNoContent("/api/v1/tech/trash/labels/"+insertedTag.ID.String()+"/restore", "")
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+	var fetchedKeptWord models.Word
+	httpResCode = get("/api/v1/tech/words/"+insertedKeptWord.ID.String(), &fetchedKeptWord)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Empty(t, fetchedKeptWord.Tags)
+}
+
+func Test_should_keep_recent_items_on_trash_purge(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	var insertedWord models.Word
+	post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	del("/api/v1/tech/words/" + insertedWord.ID.String())
+
+	var report dto.TrashPurgeReport
+	httpResCode := post("/api/v1/tech/trash/purge", "", &report)
+	assert.Equal(t, http.StatusOK, httpResCode)
+
+	// The word was deleted within the retention period, it can still be restored
+	httpResCode = postNoContent("/api/v1/tech/trash/words/"+insertedWord.ID.String()+"/restore", "")
+	assert.Equal(t, http.StatusNoContent, httpResCode)
+}
+
+func Test_should_refuse_invalid_trash_requests(t *testing.T) {
+	t.Parallel()
+
+	httpResCode := get("/api/v1/tech/trash?kind=KANJI", &[]*dto.TrashItem{})
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+	httpResCode = postNoContent("/api/v1/tech/trash/words/not-a-uuid/restore", "")
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+	httpResCode = postNoContent("/api/v1/tech/trash/labels/"+uuid.New().String()+"/restore", "")
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+}
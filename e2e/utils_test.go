@@ -3,9 +3,15 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
 	"go.uber.org/zap"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/textproto"
 )
 
 func get[T any](url string, model *T) int {
@@ -43,6 +49,30 @@ func postNoContent(url string, jsonData string) int {
 	return w.Code
 }
 
+// Upload a file in the "file" field of a multipart form
+func postFile[T any](url string, fileName string, contentType string, content []byte, model *T) int {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="file"; filename="`+fileName+`"`)
+	header.Set("Content-Type", contentType)
+	part, _ := writer.CreatePart(header)
+	_, _ = part.Write(content)
+	_ = writer.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", url, body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	router.ServeHTTP(w, req)
+
+	err := json.Unmarshal(w.Body.Bytes(), model)
+	if err != nil {
+		logger.Error("Could not unmarshall json")
+	}
+
+	return w.Code
+}
+
 func put[T any](url string, jsonData string, model *T) int {
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("PUT", url, bytes.NewBufferString(jsonData))
@@ -57,6 +87,47 @@ func put[T any](url string, jsonData string, model *T) int {
 	return w.Code
 }
 
+// Send a merge patch, conditional on the given ETag when not empty, and return the ETag of the response
+func patch[T any](url string, jsonData string, ifMatch string, model *T) (int, string) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", url, bytes.NewBufferString(jsonData))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	router.ServeHTTP(w, req)
+
+	err := json.Unmarshal(w.Body.Bytes(), model)
+	if err != nil {
+		logger.Error("Could not unmarshall json")
+	}
+
+	return w.Code, w.Header().Get("ETag")
+}
+
+// Send a request as another user, the body being decoded into model when it is not nil
+func requestAs[T any](userID string, method string, url string, jsonData string, model *T) int {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, url, bytes.NewBufferString(jsonData))
+	req.Header.Set(testUserHeader, userID)
+	router.ServeHTTP(w, req)
+
+	if model != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), model); err != nil {
+			logger.Error("Could not unmarshall json")
+		}
+	}
+	return w.Code
+}
+
+// Read the ETag of a resource
+func getETag(url string) string {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", url, nil)
+	router.ServeHTTP(w, req)
+	return w.Header().Get("ETag")
+}
+
 func del(url string) int {
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("DELETE", url, nil)
@@ -65,6 +136,27 @@ func del(url string) int {
 	return w.Code
 }
 
+// Submit a typed answer as a new user and read back the status the API graded it with
+func gradeAnswer(result dto.WordQuizResult) (dto.ResultStatus, error) {
+	results := dto.QuizResults{UserID: uuid.New().String(), Results: []dto.WordQuizResult{result}}
+	if httpResCode := postNoContent("/api/v1/app/quiz/results", ToJson(&results)); httpResCode != http.StatusOK {
+		return "", fmt.Errorf("quiz results refused with status %d", httpResCode)
+	}
+
+	var history models.WordLearningHistory
+	if err := db.Where("user_id = ? AND word_id = ?", results.UserID, result.WordID).First(&history).Error; err != nil {
+		return "", err
+	}
+	switch {
+	case history.NbSuccess > 0:
+		return dto.Success, nil
+	case history.NbErrors > 0:
+		return dto.Error, nil
+	default:
+		return dto.Unanswered, nil
+	}
+}
+
 func ToJson[T any](input *T) string {
 	jsonData, _ := json.MarshalIndent(input, "", "  ")
 	return string(jsonData)
@@ -6,6 +6,7 @@ import (
 	"github.com/xanagit/kotoquiz-api/dto"
 	"github.com/xanagit/kotoquiz-api/models"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"testing"
@@ -284,3 +285,163 @@ func Test_should_handle_mixed_status_quiz_results(t *testing.T) {
 		time.Sleep(100 * time.Millisecond) // Ensure different timestamps
 	}
 }
+
+func Test_should_count_each_result_of_a_word_asked_twice_in_a_quiz(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	var insertedWord models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	// The same word is asked for its meaning and for its reading, once without history and once with
+	results := dto.QuizResults{
+		UserID: uuid.New().String(),
+		Results: []dto.WordQuizResult{
+			{WordID: insertedWord.ID, Status: dto.Success, Mode: dto.MeaningMode},
+			{WordID: insertedWord.ID, Status: dto.Error, Mode: dto.ReadingMode},
+		},
+	}
+	for i := 0; i < 2; i++ {
+		httpResCode = postNoContent("/api/v1/app/quiz/results", ToJson(&results))
+		assert.Equal(t, http.StatusOK, httpResCode)
+	}
+
+	var history models.WordLearningHistory
+	assert.NoError(t, db.Where("user_id = ? AND word_id = ?", results.UserID, insertedWord.ID).First(&history).Error)
+	assert.Equal(t, 4, history.AnswerCount)
+	assert.Equal(t, 2, history.NbSuccess)
+	assert.Equal(t, 2, history.NbErrors)
+}
+
+func Test_should_read_wordDto_with_ordered_senses(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	word.Senses = []*models.WordSense{
+		{
+			PartOfSpeech: "noun",
+			Translations: []*models.Label{{En: "today", Fr: "aujourd'hui"}},
+		}, {
+			PartOfSpeech: "noun",
+			Notes:        "Formal",
+			Translations: []*models.Label{{En: "these days", Fr: "de nos jours"}, {En: "nowadays", Fr: "actuellement"}},
+		},
+	}
+	var insertedWord models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	var fetchedWordDto dto.WordDTO
+	httpResCode = get("/api/v1/app/words/"+insertedWord.ID.String()+"?lang=fr", &fetchedWordDto)
+
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, insertedWord.Translation.Fr, fetchedWordDto.Translation)
+	assert.Equal(t, 2, len(fetchedWordDto.Senses))
+	assert.Equal(t, []string{"aujourd'hui"}, fetchedWordDto.Senses[0].Translations)
+	assert.Equal(t, "Formal", fetchedWordDto.Senses[1].Notes)
+	assert.ElementsMatch(t, []string{"de nos jours", "actuellement"}, fetchedWordDto.Senses[1].Translations)
+}
+
+func Test_should_grade_typed_meaning_answers(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	word.Senses = []*models.WordSense{
+		{Translations: []*models.Label{{En: "to eat", Fr: "manger"}}},
+	}
+	var insertedWord models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	// Primary translation and senses translations are both accepted, ignoring case and the infinitive marker
+	expectedStatuses := map[string]dto.ResultStatus{
+		"Translation En": dto.Success,
+		"eat":            dto.Success,
+		"Manger":         dto.Success,
+		"eats":           dto.Error,
+		"wrong answer":   dto.Error,
+	}
+	for answer, expectedStatus := range expectedStatuses {
+		status, err := gradeAnswer(dto.WordQuizResult{WordID: insertedWord.ID, Mode: dto.MeaningMode, Answer: answer})
+		assert.NoError(t, err, answer)
+		assert.Equal(t, expectedStatus, status, answer)
+	}
+}
+
+func Test_should_list_WordDtoIds_corresponding_to_alternative_reading(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	word.Kanji = "今日"
+	word.Readings = []*models.WordReading{
+		{Reading: "きょう", Type: models.Kunyomi, IsPrimary: true},
+		{Reading: "こんにち", Type: models.Onyomi},
+	}
+	var insertedWord models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+	assert.Equal(t, "きょう", insertedWord.Yomi)
+
+	var fetchedWordDtoIdsList dto.WordIdsList
+	httpResCode = get("/api/v1/app/words/q?reading="+url.QueryEscape("こんにち")+"&userId="+uuid.New().String(), &fetchedWordDtoIdsList)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Contains(t, fetchedWordDtoIdsList.Ids, insertedWord.ID.String())
+
+	var fetchedWordDto dto.WordDTO
+	httpResCode = get("/api/v1/app/words/"+insertedWord.ID.String(), &fetchedWordDto)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 2, len(fetchedWordDto.Readings))
+}
+
+func Test_should_list_WordDtoIds_corresponding_to_part_of_speech(t *testing.T) {
+	t.Parallel()
+
+	verb := GenerateWord()
+	verb.Kanji = "開ける"
+	verb.Yomi = "あける"
+	verb.PartOfSpeech = models.IchidanVerb
+	verb.Transitivity = models.Transitive
+	var insertedVerb models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&verb), &insertedVerb)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	intransitiveVerb := GenerateWord()
+	intransitiveVerb.Kanji = "開く"
+	intransitiveVerb.Yomi = "あく"
+	intransitiveVerb.PartOfSpeech = models.GodanVerb
+	intransitiveVerb.Transitivity = models.Intransitive
+	var insertedIntransitiveVerb models.Word
+	httpResCode = post("/api/v1/tech/words", ToJson(&intransitiveVerb), &insertedIntransitiveVerb)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	var fetchedWordDtoIdsList dto.WordIdsList
+	httpResCode = get("/api/v1/app/words/q?partsOfSpeech=ICHIDAN_VERB,GODAN_VERB&transitivity=TRANSITIVE&userId="+uuid.New().String(), &fetchedWordDtoIdsList)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Contains(t, fetchedWordDtoIdsList.Ids, insertedVerb.ID.String())
+	assert.NotContains(t, fetchedWordDtoIdsList.Ids, insertedIntransitiveVerb.ID.String())
+
+	var fetchedWordDto dto.WordDTO
+	httpResCode = get("/api/v1/app/words/"+insertedVerb.ID.String(), &fetchedWordDto)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, models.IchidanVerb, fetchedWordDto.PartOfSpeech)
+	assert.Equal(t, models.Transitive, fetchedWordDto.Transitivity)
+
+	httpResCode = get("/api/v1/app/words/q?partsOfSpeech=VERB", &dto.WordIdsList{})
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+}
+
+func Test_should_reject_transitivity_of_word_which_is_not_a_verb(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	word.PartOfSpeech = models.Noun
+	word.Transitivity = models.Transitive
+	httpResCode := post("/api/v1/tech/words", ToJson(&word), &models.Word{})
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+
+	word.PartOfSpeech = "VERB"
+	word.Transitivity = ""
+	httpResCode = post("/api/v1/tech/words", ToJson(&word), &models.Word{})
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+}
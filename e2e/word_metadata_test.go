@@ -0,0 +1,100 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const jlptSample = "山\tやま\tN5\n" +
+	"川\tかわ\t5\n" +
+	"川\tかわ\tN3\n" +
+	"空\tそら\tN6\n" +
+	"unknown line\n"
+
+const frequencySample = "川\tかわ\t120\n" +
+	"山\tやま\t450\n" +
+	"山\tやま\t900\n" +
+	"存在しない\t\t1\n"
+
+func Test_should_import_jlpt_levels_and_frequency_ranks(t *testing.T) {
+	mountain := GenerateWord()
+	mountain.Kanji = "山"
+	mountain.Yomi = "やま"
+	var insertedMountain models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&mountain), &insertedMountain)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	river := GenerateWord()
+	river.Kanji = "川"
+	river.Yomi = "かわ"
+	var insertedRiver models.Word
+	httpResCode = post("/api/v1/tech/words", ToJson(&river), &insertedRiver)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(importDataDir, "jlpt.tsv"), []byte(jlptSample), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(importDataDir, "frequency.tsv"), []byte(frequencySample), 0o644))
+
+	var report dto.ImportReport
+	httpResCode = post("/api/v1/tech/import/jlpt", `{"path": "jlpt.tsv"}`, &report)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, 2, report.Skipped)
+
+	httpResCode = post("/api/v1/tech/import/frequency", `{"path": "frequency.tsv"}`, &report)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, 1, report.Skipped)
+
+	// A word listed twice keeps its easiest level and its best rank
+	var fetchedWordDto dto.WordDTO
+	httpResCode = get("/api/v1/app/words/"+insertedRiver.ID.String(), &fetchedWordDto)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, models.JlptN5, fetchedWordDto.JlptLevel)
+	assert.Equal(t, 120, fetchedWordDto.FrequencyRank)
+	assert.Equal(t, `"3"`, getETag("/api/v1/tech/words/"+insertedRiver.ID.String()))
+
+	httpResCode = get("/api/v1/app/words/"+insertedMountain.ID.String(), &fetchedWordDto)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, models.JlptN5, fetchedWordDto.JlptLevel)
+	assert.Equal(t, 450, fetchedWordDto.FrequencyRank)
+
+	// Common words are selected first
+	var fetchedWordDtoIdsList dto.WordIdsList
+	httpResCode = get("/api/v1/app/words/q?jlptLevels=N5&maxFrequencyRank=500&sort=frequency&nb=1&userId="+uuid.New().String(), &fetchedWordDtoIdsList)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, []string{insertedRiver.ID.String()}, fetchedWordDtoIdsList.Ids)
+
+	var page dto.WordPage
+	httpResCode = get("/api/v1/app/words/catalogue?jlptLevels=5&maxFrequencyRank=500&sort=frequency&limit=1&offset=1", &page)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, int64(2), page.Total)
+	assert.Equal(t, 1, len(page.Words))
+	assert.Equal(t, insertedMountain.ID, page.Words[0].ID)
+
+	httpResCode = get("/api/v1/app/words/catalogue?jlptLevels=N6", &page)
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+	httpResCode = get("/api/v1/app/words/catalogue?sort=random", &page)
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+	httpResCode = get("/api/v1/app/words/catalogue?limit=-1", &page)
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+}
+
+func Test_should_reject_invalid_jlpt_level(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	word.JlptLevel = 6
+	httpResCode := post("/api/v1/tech/words", ToJson(&word), &models.Word{})
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+
+	word.JlptLevel = models.JlptN3
+	word.FrequencyRank = -1
+	httpResCode = post("/api/v1/tech/words", ToJson(&word), &models.Word{})
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"net/http"
+	"testing"
+)
+
+func Test_should_detect_homophones_from_readings(t *testing.T) {
+	t.Parallel()
+
+	machine := insertWordWithReading(t, "機械", "きかい")
+	chance := insertWordWithReading(t, "機会", "きかい")
+
+	var fetchedWordDto dto.WordDTO
+	httpResCode := get("/api/v1/app/words/"+machine.ID.String()+"?lang=fr&include=related", &fetchedWordDto)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 1, len(fetchedWordDto.Related))
+	assert.Equal(t, chance.ID, fetchedWordDto.Related[0].ID)
+	assert.Equal(t, "機会", fetchedWordDto.Related[0].Kanji)
+	assert.Equal(t, "Translation Fr", fetchedWordDto.Related[0].Translation)
+	assert.Equal(t, models.Homophone, fetchedWordDto.Related[0].Type)
+
+	// The detected homophone follows the reading of the words
+	chance.Yomi = "ちゃんす"
+	httpResCode = put("/api/v1/tech/words/"+chance.ID.String(), ToJson(&chance), &chance)
+	assert.Equal(t, http.StatusOK, httpResCode)
+
+	var relations []*models.WordRelation
+	httpResCode = get("/api/v1/tech/words/"+machine.ID.String()+"/relations", &relations)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Empty(t, relations)
+
+	var report dto.HomophoneDetectionReport
+	httpResCode = post("/api/v1/tech/relations/homophones", "", &report)
+	assert.Equal(t, http.StatusOK, httpResCode)
+}
+
+func Test_should_manage_word_relations(t *testing.T) {
+	t.Parallel()
+
+	hot := insertWordWithReading(t, "暑い", "あつい")
+	cold := insertWordWithReading(t, "寒い", "さむい")
+
+	relation := models.WordRelation{WordID: hot.ID, RelatedWordID: cold.ID, Type: models.Antonym}
+	var insertedRelation models.WordRelation
+	httpResCode := post("/api/v1/tech/relations", ToJson(&relation), &insertedRelation)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+	assert.False(t, insertedRelation.Automatic)
+
+	// Relations go both ways, the same relation cannot be created from the other word
+	reversed := models.WordRelation{WordID: cold.ID, RelatedWordID: hot.ID, Type: models.Antonym}
+	httpResCode = post("/api/v1/tech/relations", ToJson(&reversed), &models.WordRelation{})
+	assert.Equal(t, http.StatusConflict, httpResCode)
+
+	self := models.WordRelation{WordID: hot.ID, RelatedWordID: hot.ID, Type: models.Synonym}
+	httpResCode = post("/api/v1/tech/relations", ToJson(&self), &models.WordRelation{})
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+
+	var fetchedWordDto dto.WordDTO
+	httpResCode = get("/api/v1/app/words/"+cold.ID.String()+"?include=related", &fetchedWordDto)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 1, len(fetchedWordDto.Related))
+	assert.Equal(t, hot.ID, fetchedWordDto.Related[0].ID)
+	assert.Equal(t, models.Antonym, fetchedWordDto.Related[0].Type)
+
+	insertedRelation.Type = models.Confusable
+	httpResCode = put("/api/v1/tech/relations/"+insertedRelation.ID.String(), ToJson(&insertedRelation), &insertedRelation)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, models.Confusable, insertedRelation.Type)
+
+	httpResCode = del("/api/v1/tech/relations/" + insertedRelation.ID.String())
+	assert.Equal(t, http.StatusNoContent, httpResCode)
+
+	httpResCode = get("/api/v1/tech/relations/"+insertedRelation.ID.String(), &insertedRelation)
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+}
+
+func insertWordWithReading(t *testing.T, kanji string, yomi string) models.Word {
+	word := GenerateWord()
+	word.Kanji = kanji
+	word.Yomi = yomi
+	var insertedWord models.Word
+	httpResCode := post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+	return insertedWord
+}
@@ -3,8 +3,10 @@ package main
 import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/xanagit/kotoquiz-api/dto"
 	"github.com/xanagit/kotoquiz-api/models"
 	"net/http"
+	"slices"
 	"strconv"
 	"testing"
 )
@@ -19,7 +21,9 @@ func Test_should_create_word(t *testing.T) {
 
 	assert.Equal(t, http.StatusCreated, httpResCode)
 	assert.NotEqual(t, word.ID, resWord.ID)
+	assert.NotEqual(t, uuid.Nil, resWord.Translation.ID)
 	word.ID = resWord.ID
+	word.Translation.ID = resWord.Translation.ID
 	word.Translation.Type = models.Translation
 	assert.Equal(t, word, resWord)
 }
@@ -45,10 +49,10 @@ func Test_should_update_word(t *testing.T) {
 
 	word := GenerateWord()
 	word.ID = uuid.Nil
-	var insertedTag models.Word
-	post("/api/v1/tech/words", ToJson(&word), &insertedTag)
+	var insertedWord models.Word
+	post("/api/v1/tech/words", ToJson(&word), &insertedWord)
 
-	var updatedTag models.Word
+	var updatedWord models.Word
 	word.ID, _ = uuid.Parse("99999999-9999-9999-9999-999999999999")
 	word.Kanji = "Kanji Updated"
 	word.Yomi = "Yomi Updated"
@@ -56,24 +60,136 @@ func Test_should_update_word(t *testing.T) {
 	word.ImageURL = "https://kotoquiz.com/image_updated.jpg"
 	word.Translation.En = "Translation En Updated"
 	word.Translation.Fr = "Translation Fr Updated"
-	word.Tags[0].En = "Tag En Updated 1"
-	word.Tags[0].Fr = "Tag Fr Updated 1"
-	word.Tags[1].En = "Tag En Updated 2"
-	word.Tags[1].Fr = "Tag Fr Updated 2"
-	word.Levels[0].Category.En = "Category En Updated 1"
-	word.Levels[0].Category.Fr = "Category Fr Updated 1"
-	word.Levels[0].LevelNames[0].En = "LevelNames En Updated 1"
-	word.Levels[0].LevelNames[0].Fr = "LevelNames Fr Updated 1"
-	word.Levels[0].LevelNames[1].En = "LevelNames En Updated 2"
-	word.Levels[0].LevelNames[1].Fr = "LevelNames Fr Updated 2"
-
-	httpResCode := put("/api/v1/tech/words/"+insertedTag.ID.String(), ToJson(&word), &updatedTag)
+
+	httpResCode := put("/api/v1/tech/words/"+insertedWord.ID.String(), ToJson(&word), &updatedWord)
+
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.NotEqual(t, word.ID, updatedWord.ID)
+	assert.Equal(t, insertedWord.ID, updatedWord.ID)
+	// A translation without ID replaces the previous one
+	assert.NotEqual(t, insertedWord.Translation.ID, updatedWord.Translation.ID)
+	word.ID = updatedWord.ID
+	word.Translation.ID = updatedWord.Translation.ID
+	assert.Equal(t, word, updatedWord)
+}
+
+func Test_should_reference_existing_labels_and_levels_without_changing_them(t *testing.T) {
+	t.Parallel()
+	var httpResCode int
+
+	word := GenerateWord()
+	var insertedWord models.Word
+	post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+
+	// Texts sent with a referenced tag, level or translation are ignored
+	other := GenerateWord()
+	other.Translation = insertedWord.Translation
+	other.Translation.En = "Translation En Changed"
+	other.Tags = []*models.Label{word.Tags[0], {En: "Inline Tag En", Fr: "Inline Tag Fr"}}
+	other.Tags[0].En = "Tag En Changed"
+	other.Levels = []*models.Level{word.Levels[0]}
+	other.Levels[0].Category.En = "Category En Changed"
+	var insertedOther models.Word
+	httpResCode = post("/api/v1/tech/words", ToJson(&other), &insertedOther)
+	assert.Equal(t, http.StatusCreated, httpResCode)
+	assert.Equal(t, insertedWord.Translation, insertedOther.Translation)
+	assert.Equal(t, 2, len(insertedOther.Tags))
+	assert.True(t, slices.ContainsFunc(insertedOther.Tags, func(tag *models.Label) bool {
+		return tag.ID == word.Tags[0].ID && tag.En == "Tag En 1"
+	}))
+	assert.True(t, slices.ContainsFunc(insertedOther.Tags, func(tag *models.Label) bool {
+		return tag.En == "Inline Tag En" && tag.Type == models.Tag
+	}))
+	assert.Equal(t, 1, len(insertedOther.Levels))
+	assert.Equal(t, "Category En", insertedOther.Levels[0].Category.En)
+
+	var fetchedTag models.Label
+	get("/api/v1/tech/tags/"+word.Tags[0].ID.String(), &fetchedTag)
+	assert.Equal(t, "Tag En 1", fetchedTag.En)
+	var fetchedWord models.Word
+	get("/api/v1/tech/words/"+insertedWord.ID.String(), &fetchedWord)
+	assert.Equal(t, insertedWord, fetchedWord)
+}
+
+func Test_should_replace_word_associations_on_update(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	word.Senses = []*models.WordSense{
+		{Translations: []*models.Label{{En: "today", Fr: "aujourd'hui"}}},
+		{Translations: []*models.Label{{En: "nowadays", Fr: "de nos jours"}}},
+	}
+	var insertedWord models.Word
+	post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+
+	userLevel := GenerateLevel()
+	var insertedUserLevel models.Level
+	post("/api/v1/app/levels", ToJson(&userLevel), &insertedUserLevel)
+	request := dto.LevelWordsRequest{WordIDs: []uuid.UUID{insertedWord.ID}}
+	postNoContent("/api/v1/app/levels/"+insertedUserLevel.ID.String()+"/words", ToJson(&request))
+
+	replacement := GenerateWord()
+	insertedWord.Tags = []*models.Label{replacement.Tags[0]}
+	insertedWord.Levels = []*models.Level{replacement.Levels[0], word.Levels[2]}
+	insertedWord.Senses = insertedWord.Senses[1:]
+	var updatedWord models.Word
+	httpResCode := put("/api/v1/tech/words/"+insertedWord.ID.String(), ToJson(&insertedWord), &updatedWord)
 
 	assert.Equal(t, http.StatusOK, httpResCode)
-	assert.NotEqual(t, word.ID, updatedTag.ID)
-	assert.Equal(t, insertedTag.ID, updatedTag.ID)
-	word.ID = updatedTag.ID
-	assert.Equal(t, word, updatedTag)
+	assert.Equal(t, insertedWord.Translation, updatedWord.Translation)
+	assert.Equal(t, 1, len(updatedWord.Tags))
+	assert.Equal(t, replacement.Tags[0].ID, updatedWord.Tags[0].ID)
+	var levelIDs []uuid.UUID
+	for _, level := range updatedWord.Levels {
+		levelIDs = append(levelIDs, level.ID)
+	}
+	assert.ElementsMatch(t, []uuid.UUID{replacement.Levels[0].ID, word.Levels[2].ID}, levelIDs)
+	if assert.Equal(t, 1, len(updatedWord.Senses)) {
+		assert.Equal(t, "nowadays", updatedWord.Senses[0].Translations[0].En)
+	}
+
+	// The removed tag still exists and the word stays in the level of the user
+	var fetchedTag models.Label
+	httpResCode = get("/api/v1/tech/tags/"+word.Tags[0].ID.String(), &fetchedTag)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	var fetchedUserLevel models.Level
+	get("/api/v1/app/levels/"+insertedUserLevel.ID.String(), &fetchedUserLevel)
+	assert.Equal(t, []uuid.UUID{insertedWord.ID}, fetchedUserLevel.WordIDs)
+}
+
+func Test_should_refuse_invalid_word_references(t *testing.T) {
+	t.Parallel()
+
+	unknownTag := GenerateWord()
+	unknownTag.Tags[0].ID = uuid.New()
+	httpResCode := post("/api/v1/tech/words", ToJson(&unknownTag), &models.Word{})
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+
+	unknownLevel := GenerateWord()
+	unknownLevel.Levels[0].ID = uuid.New()
+	httpResCode = post("/api/v1/tech/words", ToJson(&unknownLevel), &models.Word{})
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+
+	missingTranslation := GenerateWord()
+	missingTranslation.Translation = models.Label{}
+	httpResCode = post("/api/v1/tech/words", ToJson(&missingTranslation), &models.Word{})
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+
+	tagAsTranslation := GenerateWord()
+	tagAsTranslation.Translation = *tagAsTranslation.Tags[0]
+	httpResCode = post("/api/v1/tech/words", ToJson(&tagAsTranslation), &models.Word{})
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+
+	userLevel := GenerateLevel()
+	var insertedUserLevel models.Level
+	post("/api/v1/app/levels", ToJson(&userLevel), &insertedUserLevel)
+	customLevel := GenerateWord()
+	customLevel.Levels = []*models.Level{&insertedUserLevel}
+	httpResCode = post("/api/v1/tech/words", ToJson(&customLevel), &models.Word{})
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+
+	httpResCode = put("/api/v1/tech/words/"+uuid.New().String(), ToJson(&customLevel), &models.Word{})
+	assert.Equal(t, http.StatusNotFound, httpResCode)
 }
 
 func Test_should_delete_word(t *testing.T) {
@@ -156,8 +272,18 @@ func assertWordExistsInList(t *testing.T, word models.Word, words []models.Word)
 	}
 }
 
+// GenerateWord builds a word with a new translation, referencing 2 new tags and 3 new built-in levels
 func GenerateWord() models.Word {
-	levels := []models.Level{GenerateLevel(), GenerateLevel(), GenerateLevel()}
+	tags := make([]models.Label, 2)
+	for i := range tags {
+		tag := models.Label{En: "Tag En " + strconv.Itoa(i+1), Fr: "Tag Fr " + strconv.Itoa(i+1)}
+		post("/api/v1/tech/tags", ToJson(&tag), &tags[i])
+	}
+	levels := make([]models.Level, 3)
+	for i := range levels {
+		level := GenerateLevel()
+		post("/api/v1/tech/levels", ToJson(&level), &levels[i])
+	}
 	restInputWord := models.Word{
 		ID:       uuid.New(),
 		Kanji:    "kanki",
@@ -165,28 +291,197 @@ func GenerateWord() models.Word {
 		YomiType: models.Onyomi,
 		ImageURL: "https://kotoquiz.com/image.jpg",
 		Translation: models.Label{
-			ID:   uuid.New(),
 			En:   "Translation En",
 			Fr:   "Translation Fr",
 			Type: models.Translation,
 		},
 		Tags: []*models.Label{
-			{
-				ID:   uuid.New(),
-				En:   "Tag En 1",
-				Fr:   "Tag Fr 1",
-				Type: models.Tag,
-			}, {
-				ID:   uuid.New(),
-				En:   "Tag En 2",
-				Fr:   "Tag Fr 2",
-				Type: models.Tag,
-			},
+			&tags[0], &tags[1],
 		},
 		Levels: []*models.Level{
 			&levels[0], &levels[1], &levels[2],
 		},
 	}
-	GenerateLevel()
 	return restInputWord
 }
+
+func Test_should_patch_word(t *testing.T) {
+	t.Parallel()
+	var httpResCode int
+
+	word := GenerateWord()
+	word.FrequencyRank = 120
+	var insertedWord models.Word
+	post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	etag := getETag("/api/v1/tech/words/" + insertedWord.ID.String())
+	assert.Equal(t, `"1"`, etag)
+
+	// Members missing from the patch are kept
+	var patchedWord models.Word
+	httpResCode, newETag := patch("/api/v1/tech/words/"+insertedWord.ID.String(), `{"kanji": "新しい", "frequencyRank": null}`, etag, &patchedWord)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, `"2"`, newETag)
+	assert.Equal(t, "新しい", patchedWord.Kanji)
+	assert.Equal(t, 0, patchedWord.FrequencyRank)
+	assert.Equal(t, insertedWord.Yomi, patchedWord.Yomi)
+	assert.Equal(t, insertedWord.Translation, patchedWord.Translation)
+	assert.ElementsMatch(t, insertedWord.Tags, patchedWord.Tags)
+	assert.ElementsMatch(t, insertedWord.Levels, patchedWord.Levels)
+
+	// Arrays are replaced as a whole
+	httpResCode, _ = patch("/api/v1/tech/words/"+insertedWord.ID.String(), `{"tags": [{"id": "`+word.Tags[1].ID.String()+`"}]}`, "", &patchedWord)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 1, len(patchedWord.Tags))
+	assert.Equal(t, word.Tags[1].ID, patchedWord.Tags[0].ID)
+	assert.Equal(t, 3, len(patchedWord.Levels))
+
+	// The patch is refused when based on an outdated version
+	httpResCode, _ = patch("/api/v1/tech/words/"+insertedWord.ID.String(), `{"kanji": "古い"}`, newETag, &models.Word{})
+	assert.Equal(t, http.StatusPreconditionFailed, httpResCode)
+	var fetchedWord models.Word
+	get("/api/v1/tech/words/"+insertedWord.ID.String(), &fetchedWord)
+	assert.Equal(t, "新しい", fetchedWord.Kanji)
+
+	httpResCode, _ = patch("/api/v1/tech/words/"+insertedWord.ID.String(), `["kanji"]`, "", &models.Word{})
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+	httpResCode, _ = patch("/api/v1/tech/words/"+insertedWord.ID.String(), `{"translation": null}`, "", &models.Word{})
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+	// The texts of the referenced translation cannot be patched, a new translation is given without ID
+	httpResCode, _ = patch("/api/v1/tech/words/"+insertedWord.ID.String(), `{"translation": {"en": "x"}}`, "", &models.Word{})
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+	httpResCode, _ = patch("/api/v1/tech/words/"+insertedWord.ID.String(), `{"translation": {"id": null, "en": "x"}}`, "", &patchedWord)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.NotEqual(t, insertedWord.Translation.ID, patchedWord.Translation.ID)
+	assert.Equal(t, "x", patchedWord.Translation.En)
+	httpResCode, _ = patch("/api/v1/tech/words/"+insertedWord.ID.String(), `{"kanji": "古い"}`, "W/1", &models.Word{})
+	assert.Equal(t, http.StatusBadRequest, httpResCode)
+	httpResCode, _ = patch("/api/v1/tech/words/"+uuid.New().String(), `{"kanji": "古い"}`, "", &models.Word{})
+	assert.Equal(t, http.StatusNotFound, httpResCode)
+}
+
+func Test_should_apply_bulk_operations_to_words(t *testing.T) {
+	t.Parallel()
+
+	first := GenerateWord()
+	var insertedFirst models.Word
+	post("/api/v1/tech/words", ToJson(&first), &insertedFirst)
+	second := GenerateWord()
+	var insertedSecond models.Word
+	post("/api/v1/tech/words", ToJson(&second), &insertedSecond)
+	unknownID := uuid.New()
+
+	// The tag of the first word is added to both words, the unknown word being skipped
+	addTags := dto.WordBulkRequest{
+		Operation: dto.AddTags,
+		WordIDs:   []uuid.UUID{insertedFirst.ID, insertedSecond.ID, unknownID},
+		TagIDs:    []uuid.UUID{first.Tags[0].ID},
+	}
+	var report dto.WordBulkReport
+	httpResCode := post("/api/v1/tech/words/bulk", ToJson(&addTags), &report)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 2, report.Matched)
+	assert.Equal(t, 1, report.Changed)
+	if assert.Equal(t, 3, len(report.Results)) {
+		assert.Equal(t, dto.BulkUnchanged, report.Results[0].Status)
+		assert.Equal(t, dto.BulkUpdated, report.Results[1].Status)
+		assert.Equal(t, dto.BulkNotFound, report.Results[2].Status)
+	}
+
+	// Both words are now selected by the tag
+	setYomiType := dto.WordBulkRequest{
+		Operation: dto.SetYomiType,
+		Filter:    &dto.WordFilter{TagIds: []string{first.Tags[0].ID.String()}},
+		YomiType:  models.Kunyomi,
+	}
+	report = dto.WordBulkReport{}
+	httpResCode = post("/api/v1/tech/words/bulk", ToJson(&setYomiType), &report)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 2, report.Changed)
+	var fetchedSecond models.Word
+	get("/api/v1/tech/words/"+insertedSecond.ID.String(), &fetchedSecond)
+	assert.Equal(t, models.Kunyomi, fetchedSecond.YomiType)
+	assert.True(t, slices.ContainsFunc(fetchedSecond.Tags, func(tag *models.Label) bool { return tag.ID == first.Tags[0].ID }))
+
+	removeLevels := dto.WordBulkRequest{
+		Operation: dto.RemoveLevels,
+		WordIDs:   []uuid.UUID{insertedSecond.ID},
+		LevelIDs:  []uuid.UUID{second.Levels[0].ID, second.Levels[1].ID},
+	}
+	report = dto.WordBulkReport{}
+	post("/api/v1/tech/words/bulk", ToJson(&removeLevels), &report)
+	assert.Equal(t, 1, report.Changed)
+	fetchedSecond = models.Word{}
+	get("/api/v1/tech/words/"+insertedSecond.ID.String(), &fetchedSecond)
+	if assert.Equal(t, 1, len(fetchedSecond.Levels)) {
+		assert.Equal(t, second.Levels[2].ID, fetchedSecond.Levels[0].ID)
+	}
+
+	deleteWords := dto.WordBulkRequest{
+		Operation: dto.DeleteWords,
+		WordIDs:   []uuid.UUID{insertedFirst.ID, insertedSecond.ID},
+	}
+	report = dto.WordBulkReport{}
+	httpResCode = post("/api/v1/tech/words/bulk", ToJson(&deleteWords), &report)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 2, report.Changed)
+	assert.Equal(t, http.StatusNotFound, get("/api/v1/tech/words/"+insertedFirst.ID.String(), &models.Word{}))
+	assert.Equal(t, http.StatusNotFound, get("/api/v1/tech/words/"+insertedSecond.ID.String(), &models.Word{}))
+}
+
+func Test_should_report_word_whose_primary_reading_changes_with_its_yomi_type(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	word.YomiType = models.Kunyomi
+	word.Readings = []*models.WordReading{{Reading: word.Yomi, Type: models.Kunyomi, IsPrimary: true}}
+	var insertedWord models.Word
+	post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	// The primary reading no longer mirrors the reading type of the word, as for words saved before it did
+	db.Exec("UPDATE word_readings SET type = ? WHERE word_id = ? AND is_primary", models.Onyomi, insertedWord.ID)
+
+	setYomiType := dto.WordBulkRequest{Operation: dto.SetYomiType, WordIDs: []uuid.UUID{insertedWord.ID}, YomiType: models.Kunyomi}
+	var report dto.WordBulkReport
+	httpResCode := post("/api/v1/tech/words/bulk", ToJson(&setYomiType), &report)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 1, report.Changed)
+	if assert.Equal(t, 1, len(report.Results)) {
+		assert.Equal(t, dto.BulkUpdated, report.Results[0].Status)
+	}
+	var fetchedWord models.Word
+	get("/api/v1/tech/words/"+insertedWord.ID.String(), &fetchedWord)
+	if assert.Equal(t, 1, len(fetchedWord.Readings)) {
+		assert.Equal(t, models.Kunyomi, fetchedWord.Readings[0].Type)
+	}
+	assert.Equal(t, `"2"`, getETag("/api/v1/tech/words/"+insertedWord.ID.String()))
+}
+
+func Test_should_refuse_invalid_bulk_operations(t *testing.T) {
+	t.Parallel()
+
+	word := GenerateWord()
+	var insertedWord models.Word
+	post("/api/v1/tech/words", ToJson(&word), &insertedWord)
+	wordIDs := []uuid.UUID{insertedWord.ID}
+
+	requests := []dto.WordBulkRequest{
+		{Operation: "RENAME", WordIDs: wordIDs},
+		{Operation: dto.DeleteWords},
+		{Operation: dto.DeleteWords, WordIDs: wordIDs, Filter: &dto.WordFilter{Reading: "yomi"}},
+		{Operation: dto.DeleteWords, Filter: &dto.WordFilter{}},
+		{Operation: dto.AddTags, WordIDs: wordIDs},
+		{Operation: dto.AddTags, WordIDs: wordIDs, TagIDs: []uuid.UUID{uuid.New()}},
+		{Operation: dto.AddTags, WordIDs: wordIDs, TagIDs: []uuid.UUID{insertedWord.Translation.ID}},
+		{Operation: dto.AddLevels, WordIDs: wordIDs, LevelIDs: []uuid.UUID{uuid.New()}},
+		{Operation: dto.SetYomiType, WordIDs: wordIDs, YomiType: "NANORI"},
+	}
+	for _, request := range requests {
+		httpResCode := post("/api/v1/tech/words/bulk", ToJson(&request), &dto.WordBulkReport{})
+		assert.Equal(t, http.StatusBadRequest, httpResCode, request)
+	}
+
+	// Nothing has been changed
+	var fetchedWord models.Word
+	httpResCode := get("/api/v1/tech/words/"+insertedWord.ID.String(), &fetchedWord)
+	assert.Equal(t, http.StatusOK, httpResCode)
+	assert.Equal(t, 2, len(fetchedWord.Tags))
+}
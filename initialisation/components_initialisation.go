@@ -6,8 +6,10 @@ import (
 	"github.com/xanagit/kotoquiz-api/config"
 	"github.com/xanagit/kotoquiz-api/controllers"
 	"github.com/xanagit/kotoquiz-api/middlewares"
+	"github.com/xanagit/kotoquiz-api/models"
 	"github.com/xanagit/kotoquiz-api/repositories"
 	"github.com/xanagit/kotoquiz-api/services"
+	"github.com/xanagit/kotoquiz-api/storage"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -20,6 +22,15 @@ type AppComponents struct {
 	LabelRepository               repositories.LabelRepository
 	LevelRepository               repositories.LevelRepository
 	WordLearningHistoryRepository repositories.WordLearningHistoryRepository
+	KanjiRepository               repositories.KanjiRepository
+	ExampleSentenceRepository     repositories.ExampleSentenceRepository
+	ConjugationRepository         repositories.ConjugationLearningHistoryRepository
+	WordRelationRepository        repositories.WordRelationRepository
+	DeckRepository                repositories.DeckRepository
+	LearningPathRepository        repositories.LearningPathRepository
+
+	// Storage
+	MediaStorage storage.MediaStorage
 
 	// Services
 	HealthService              services.ApiHealthService
@@ -29,15 +40,38 @@ type AppComponents struct {
 	WordLearningHistoryService services.WordLearningHistoryService
 	WordDtoService             services.WordDtoService
 	RegistrationService        services.RegistrationService
+	KanjiService               services.KanjiService
+	ImportService              services.ImportService
+	ExampleSentenceService     services.ExampleSentenceService
+	ClozeService               services.ClozeService
+	MediaService               services.MediaService
+	ConjugationService         services.ConjugationService
+	WordRelationService        services.WordRelationService
+	DeckService                services.DeckService
+	LearningPathService        services.LearningPathService
+	TrashService               services.TrashService
 
 	// Controllers
 	HealthController              controllers.HealthController
 	WordController                controllers.WordController
 	LevelController               controllers.LevelController
 	TagController                 controllers.TagController
+	CategoryController            controllers.LabelController
+	LevelNameController           controllers.LabelController
+	LabelMergeController          controllers.LabelMergeController
 	WordLearningHistoryController controllers.WordLearningHistoryController
 	WordDtoController             controllers.WordDtoController
 	RegistrationController        controllers.RegistrationController
+	KanjiController               controllers.KanjiController
+	ImportController              controllers.ImportController
+	ExampleSentenceController     controllers.ExampleSentenceController
+	QuizController                controllers.QuizController
+	MediaController               controllers.MediaController
+	ConjugationController         controllers.ConjugationController
+	WordRelationController        controllers.WordRelationController
+	DeckController                controllers.DeckController
+	LearningPathController        controllers.LearningPathController
+	TrashController               controllers.TrashController
 }
 
 // MiddlewareComponents holds all middleware components used across the application
@@ -63,27 +97,117 @@ func InitializeAppComponents(db *gorm.DB, cfg *config.Config) *AppComponents {
 	labelRepo := &repositories.LabelRepositoryImpl{DB: db}
 	levelRepo := &repositories.LevelRepositoryImpl{DB: db}
 	wordLearningHistoryRepo := &repositories.WordLearningHistoryRepositoryImpl{DB: db}
+	kanjiRepo := &repositories.KanjiRepositoryImpl{DB: db}
+	exampleSentenceRepo := &repositories.ExampleSentenceRepositoryImpl{DB: db}
+	conjugationRepo := &repositories.ConjugationLearningHistoryRepositoryImpl{DB: db}
+	wordRelationRepo := &repositories.WordRelationRepositoryImpl{DB: db}
+	deckRepo := &repositories.DeckRepositoryImpl{DB: db}
+	learningPathRepo := &repositories.LearningPathRepositoryImpl{DB: db}
+
+	// Storage
+	mediaStorage := storage.NewMediaStorage(&cfg.Media)
 
 	// Services
 	healthService := &services.ApiHealthServiceImpl{DB: db}
-	wordService := &services.WordServiceImpl{Repo: wordRepo}
+	mediaService := &services.MediaServiceImpl{
+		Storage:  mediaStorage,
+		WordRepo: wordRepo,
+		Config:   &cfg.Media,
+	}
+	wordService := &services.WordServiceImpl{
+		Repo:         wordRepo,
+		KanjiRepo:    kanjiRepo,
+		RelationRepo: wordRelationRepo,
+		LabelRepo:    labelRepo,
+		LevelRepo:    levelRepo,
+	}
 	labelService := &services.LabelServiceImpl{Repo: labelRepo}
-	levelService := &services.LevelServiceImpl{Repo: levelRepo}
-	wordLearningHistoryService := &services.WordLearningHistoryServiceImpl{Repo: wordLearningHistoryRepo}
+	levelService := &services.LevelServiceImpl{
+		Repo:      levelRepo,
+		LabelRepo: labelRepo,
+	}
+	wordLearningHistoryService := &services.WordLearningHistoryServiceImpl{
+		Repo:            wordLearningHistoryRepo,
+		ConjugationRepo: conjugationRepo,
+		WordRepo:        wordRepo,
+		ExampleRepo:     exampleSentenceRepo,
+	}
 	wordDtoService := &services.WordDtoServiceImpl{
 		WordRepo:            wordRepo,
 		LearningHistoryRepo: wordLearningHistoryRepo,
+		ExampleRepo:         exampleSentenceRepo,
+		RelationRepo:        wordRelationRepo,
+		Media:               mediaStorage,
 	}
 	registrationService := &services.RegistrationServiceImpl{KeycloakConfig: &cfg.Auth.Keycloak}
+	kanjiService := &services.KanjiServiceImpl{
+		Repo:     kanjiRepo,
+		WordRepo: wordRepo,
+	}
+	importService := &services.ImportServiceImpl{
+		DataDir:     cfg.Import.DataDir,
+		WordRepo:    wordRepo,
+		KanjiRepo:   kanjiRepo,
+		ExampleRepo: exampleSentenceRepo,
+	}
+	exampleSentenceService := &services.ExampleSentenceServiceImpl{
+		Repo:      exampleSentenceRepo,
+		KanjiRepo: kanjiRepo,
+	}
+	clozeService := &services.ClozeServiceImpl{
+		WordRepo:    wordRepo,
+		ExampleRepo: exampleSentenceRepo,
+	}
+	conjugationService := &services.ConjugationServiceImpl{
+		WordRepo:        wordRepo,
+		ConjugationRepo: conjugationRepo,
+	}
+	wordRelationService := &services.WordRelationServiceImpl{
+		Repo:     wordRelationRepo,
+		WordRepo: wordRepo,
+	}
+	deckService := &services.DeckServiceImpl{
+		Repo:      deckRepo,
+		LevelRepo: levelRepo,
+	}
+	learningPathService := &services.LearningPathServiceImpl{
+		Repo:      learningPathRepo,
+		LevelRepo: levelRepo,
+		Config:    &cfg.Learning,
+	}
+	trashService := &services.TrashServiceImpl{
+		WordRepo:     wordRepo,
+		LabelRepo:    labelRepo,
+		LevelRepo:    levelRepo,
+		RelationRepo: wordRelationRepo,
+		MediaService: mediaService,
+		Config:       &cfg.Trash,
+	}
 
 	// Controllers
 	healthController := &controllers.HealthControllerImpl{Service: healthService}
 	wordController := &controllers.WordControllerImpl{Service: wordService}
 	levelController := &controllers.LevelControllerImpl{Service: levelService}
 	tagController := &controllers.TagControllerImpl{Service: labelService}
+	categoryController := &controllers.LabelControllerImpl{Service: labelService, Type: models.Category}
+	levelNameController := &controllers.LabelControllerImpl{Service: labelService, Type: models.LevelName}
+	labelMergeController := &controllers.LabelMergeControllerImpl{Service: labelService}
 	wordLearningHistoryController := &controllers.WordLearningHistoryControllerImpl{Service: wordLearningHistoryService}
 	wordDtoController := &controllers.WordDtoControllerImpl{WordDtoService: wordDtoService}
 	registrationController := &controllers.RegistrationControllerImpl{Service: registrationService}
+	kanjiController := &controllers.KanjiControllerImpl{Service: kanjiService}
+	importController := &controllers.ImportControllerImpl{Service: importService}
+	exampleSentenceController := &controllers.ExampleSentenceControllerImpl{Service: exampleSentenceService}
+	quizController := &controllers.QuizControllerImpl{
+		ClozeService:       clozeService,
+		ConjugationService: conjugationService,
+	}
+	mediaController := &controllers.MediaControllerImpl{Service: mediaService}
+	conjugationController := &controllers.ConjugationControllerImpl{Service: conjugationService}
+	wordRelationController := &controllers.WordRelationControllerImpl{Service: wordRelationService}
+	deckController := &controllers.DeckControllerImpl{Service: deckService}
+	learningPathController := &controllers.LearningPathControllerImpl{Service: learningPathService}
+	trashController := &controllers.TrashControllerImpl{Service: trashService}
 
 	// Return an instance of AppComponents with interfaces
 	return &AppComponents{
@@ -92,6 +216,15 @@ func InitializeAppComponents(db *gorm.DB, cfg *config.Config) *AppComponents {
 		LabelRepository:               labelRepo,
 		LevelRepository:               levelRepo,
 		WordLearningHistoryRepository: wordLearningHistoryRepo,
+		KanjiRepository:               kanjiRepo,
+		ExampleSentenceRepository:     exampleSentenceRepo,
+		ConjugationRepository:         conjugationRepo,
+		WordRelationRepository:        wordRelationRepo,
+		DeckRepository:                deckRepo,
+		LearningPathRepository:        learningPathRepo,
+
+		// Storage
+		MediaStorage: mediaStorage,
 
 		// Services
 		HealthService:              healthService,
@@ -101,15 +234,38 @@ func InitializeAppComponents(db *gorm.DB, cfg *config.Config) *AppComponents {
 		WordLearningHistoryService: wordLearningHistoryService,
 		WordDtoService:             wordDtoService,
 		RegistrationService:        registrationService,
+		KanjiService:               kanjiService,
+		ImportService:              importService,
+		ExampleSentenceService:     exampleSentenceService,
+		ClozeService:               clozeService,
+		MediaService:               mediaService,
+		ConjugationService:         conjugationService,
+		WordRelationService:        wordRelationService,
+		DeckService:                deckService,
+		LearningPathService:        learningPathService,
+		TrashService:               trashService,
 
 		// Controllers
 		HealthController:              healthController,
 		WordController:                wordController,
 		LevelController:               levelController,
 		TagController:                 tagController,
+		CategoryController:            categoryController,
+		LevelNameController:           levelNameController,
+		LabelMergeController:          labelMergeController,
 		WordLearningHistoryController: wordLearningHistoryController,
 		WordDtoController:             wordDtoController,
 		RegistrationController:        registrationController,
+		KanjiController:               kanjiController,
+		ImportController:              importController,
+		ExampleSentenceController:     exampleSentenceController,
+		QuizController:                quizController,
+		MediaController:               mediaController,
+		ConjugationController:         conjugationController,
+		WordRelationController:        wordRelationController,
+		DeckController:                deckController,
+		LearningPathController:        learningPathController,
+		TrashController:               trashController,
 	}
 }
 
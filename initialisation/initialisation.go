@@ -8,6 +8,7 @@ import (
 	"github.com/xanagit/kotoquiz-api/config"
 	"github.com/xanagit/kotoquiz-api/middlewares"
 	"github.com/xanagit/kotoquiz-api/models"
+	"github.com/xanagit/kotoquiz-api/storage"
 	"go.uber.org/zap"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -25,6 +26,11 @@ func ConfigureRoutes(r *gin.Engine, components *AppComponents, middlewareCompone
 	r.Use(middlewareComponents.CORSMiddleware.HandleCORS())
 	r.GET("/health", components.HealthController.HealthCheck)
 
+	// Media stored on the local filesystem are served by the API, the s3 backend provides signed URLs
+	if localStorage, ok := components.MediaStorage.(*storage.LocalMediaStorage); ok {
+		r.Static(storage.LocalMediaRoute, localStorage.Dir)
+	}
+
 	// Public routes - no authentication required
 	public := r.Group("/api/v1/public")
 	public.Use(middlewareComponents.CORSMiddleware.HandleCORS())
@@ -39,10 +45,37 @@ func ConfigureRoutes(r *gin.Engine, components *AppComponents, middlewareCompone
 	appUserGroup.Use(middlewareComponents.AuthMiddleware.RequireRoles(string(middlewares.UserRole)))
 	{
 		appUserGroup.GET("/words/q", components.WordDtoController.ListWordsIDs)
-		appUserGroup.GET("/words", components.WordDtoController.ListDtoWords)    // query param: ids, lang
-		appUserGroup.GET("/words/:id", components.WordDtoController.ReadDtoWord) // query param: lang
+		appUserGroup.GET("/words/catalogue", components.WordDtoController.ListCatalogue) // query param: filters, sort, limit, offset, lang, include
+		appUserGroup.GET("/words", components.WordDtoController.ListDtoWords)            // query param: ids, lang, include
+		appUserGroup.GET("/words/:id", components.WordDtoController.ReadDtoWord)         // query param: lang, include
+		appUserGroup.GET("/words/:id/conjugations", components.ConjugationController.ListWordConjugations)
 		appUserGroup.GET("/tags", components.TagController.ListTags)
+		appUserGroup.GET("/tags/tree", components.TagController.ListTagTree)
+		appUserGroup.GET("/tags/:id/tree", components.TagController.ReadTagTree)
+		appUserGroup.GET("/categories", components.CategoryController.ListLabels)
+		appUserGroup.GET("/level-names", components.LevelNameController.ListLabels)
 		appUserGroup.GET("/levels", components.LevelController.ListLevels)
+		appUserGroup.GET("/levels/:id", components.LevelController.ReadUserLevel)
+		appUserGroup.POST("/levels", components.LevelController.CreateUserLevel)
+		appUserGroup.PUT("/levels/:id", components.LevelController.UpdateUserLevel)
+		appUserGroup.DELETE("/levels/:id", components.LevelController.DeleteUserLevel)
+		appUserGroup.POST("/levels/:id/words", components.LevelController.AddLevelWords) // body: wordIds
+		appUserGroup.DELETE("/levels/:id/words/:wordId", components.LevelController.RemoveLevelWord)
+		appUserGroup.POST("/levels/:id/publish", components.DeckController.PublishLevel) // query param: visibility
+		appUserGroup.DELETE("/levels/:id/publish", components.DeckController.UnpublishLevel)
+		appUserGroup.POST("/levels/:id/push", components.DeckController.PushLevel)
+		appUserGroup.GET("/levels/:id/update", components.DeckController.ReadLevelUpdate)
+		appUserGroup.POST("/levels/:id/update", components.DeckController.AcceptLevelUpdate)
+		appUserGroup.GET("/decks", components.DeckController.ListDecks) // query param: sort, limit, offset, lang
+		appUserGroup.GET("/decks/:code", components.DeckController.ReadDeck)
+		appUserGroup.POST("/decks/:code/clone", components.DeckController.CloneDeck)
+		appUserGroup.PUT("/decks/:code/rating", components.DeckController.RateDeck)
+		appUserGroup.GET("/paths", components.LearningPathController.ListLearningPaths)
+		appUserGroup.GET("/paths/:id/progress", components.LearningPathController.ReadLearningPathProgress) // query param: lang
+		appUserGroup.GET("/kanji/lookup", components.KanjiController.LookupKanji)                           // query param: components, lang
+		appUserGroup.GET("/kanji/:id", components.KanjiController.ReadKanjiDto)                             // id or character, query param: lang
+		appUserGroup.GET("/quiz/cloze", components.QuizController.ListClozeQuestions)                       // query param: ids, lang
+		appUserGroup.GET("/quiz/conjugation", components.QuizController.ListConjugationQuestions)           // query param: ids, forms, lang
 		appUserGroup.POST("/quiz/results", components.WordLearningHistoryController.ProcessQuizResults)
 	}
 
@@ -55,20 +88,92 @@ func ConfigureRoutes(r *gin.Engine, components *AppComponents, middlewareCompone
 		// Word management endpoints
 		techGroup.GET("/words/:id", components.WordController.ReadWord)
 		techGroup.POST("/words", components.WordController.CreateWord)
+		techGroup.POST("/words/bulk", components.WordController.ApplyBulkOperation) // body: operation, wordIds or filter
 		techGroup.PUT("/words/:id", components.WordController.UpdateWord)
+		techGroup.PATCH("/words/:id", components.WordController.PatchWord) // header: If-Match
 		techGroup.DELETE("/words/:id", components.WordController.DeleteWord)
+		techGroup.PUT("/words/:id/furigana", components.WordController.OverrideFurigana)
+		techGroup.DELETE("/words/:id/furigana", components.WordController.ResetFurigana)
+		techGroup.POST("/words/:id/audio", components.MediaController.UploadWordAudio) // multipart form field: file
+		techGroup.DELETE("/words/:id/audio", components.MediaController.DeleteWordAudio)
+		techGroup.POST("/words/:id/image", components.MediaController.UploadWordImage) // multipart form field: file
+		techGroup.DELETE("/words/:id/image", components.MediaController.DeleteWordImage)
 
 		// Tag management endpoints
 		techGroup.GET("/tags/:id", components.TagController.ReadTag)
 		techGroup.POST("/tags", components.TagController.CreateTag)
 		techGroup.PUT("/tags/:id", components.TagController.UpdateTag)
-		techGroup.DELETE("/tags/:id", components.TagController.DeleteTag)
+		techGroup.PATCH("/tags/:id", components.TagController.PatchTag)   // header: If-Match
+		techGroup.DELETE("/tags/:id", components.TagController.DeleteTag) // query param: force
+
+		// Category management endpoints
+		techGroup.GET("/categories/:id", components.CategoryController.ReadLabel)
+		techGroup.POST("/categories", components.CategoryController.CreateLabel)
+		techGroup.PUT("/categories/:id", components.CategoryController.UpdateLabel)
+		techGroup.DELETE("/categories/:id", components.CategoryController.DeleteLabel) // query param: force
+
+		// Level name management endpoints
+		techGroup.GET("/level-names/:id", components.LevelNameController.ReadLabel)
+		techGroup.POST("/level-names", components.LevelNameController.CreateLabel)
+		techGroup.PUT("/level-names/:id", components.LevelNameController.UpdateLabel)
+		techGroup.DELETE("/level-names/:id", components.LevelNameController.DeleteLabel) // query param: force
+
+		// Duplicate label clean-up endpoints
+		techGroup.GET("/labels/duplicates", components.LabelMergeController.ListDuplicateLabels) // query param: type, maxDistance
+		techGroup.POST("/labels/merge", components.LabelMergeController.MergeLabels)
 
 		// Level management endpoints
 		techGroup.GET("/levels/:id", components.LevelController.ReadLevel)
 		techGroup.POST("/levels", components.LevelController.CreateLevel)
 		techGroup.PUT("/levels/:id", components.LevelController.UpdateLevel)
+		techGroup.PATCH("/levels/:id", components.LevelController.PatchLevel) // header: If-Match
 		techGroup.DELETE("/levels/:id", components.LevelController.DeleteLevel)
+
+		// Learning path management endpoints
+		techGroup.GET("/paths/:id", components.LearningPathController.ReadLearningPath)
+		techGroup.POST("/paths", components.LearningPathController.CreateLearningPath)
+		techGroup.PUT("/paths/:id", components.LearningPathController.UpdateLearningPath)
+		techGroup.DELETE("/paths/:id", components.LearningPathController.DeleteLearningPath)
+
+		// Kanji management endpoints
+		techGroup.GET("/kanji", components.KanjiController.ListKanji)
+		techGroup.GET("/kanji/:id", components.KanjiController.ReadKanji)
+		techGroup.POST("/kanji", components.KanjiController.CreateKanji)
+		techGroup.PUT("/kanji/:id", components.KanjiController.UpdateKanji)
+		techGroup.DELETE("/kanji/:id", components.KanjiController.DeleteKanji)
+
+		// Example sentence management endpoints
+		techGroup.GET("/examples", components.ExampleSentenceController.ListExampleSentences)
+		techGroup.GET("/examples/:id", components.ExampleSentenceController.ReadExampleSentence)
+		techGroup.POST("/examples", components.ExampleSentenceController.CreateExampleSentence)
+		techGroup.PUT("/examples/:id", components.ExampleSentenceController.UpdateExampleSentence)
+		techGroup.DELETE("/examples/:id", components.ExampleSentenceController.DeleteExampleSentence)
+		techGroup.PUT("/examples/:id/furigana", components.ExampleSentenceController.OverrideFurigana)
+		techGroup.DELETE("/examples/:id/furigana", components.ExampleSentenceController.ResetFurigana)
+
+		// Word relation management endpoints
+		techGroup.GET("/words/:id/relations", components.WordRelationController.ListWordRelations)
+		techGroup.GET("/relations/:id", components.WordRelationController.ReadWordRelation)
+		techGroup.POST("/relations", components.WordRelationController.CreateWordRelation)
+		techGroup.PUT("/relations/:id", components.WordRelationController.UpdateWordRelation)
+		techGroup.DELETE("/relations/:id", components.WordRelationController.DeleteWordRelation)
+		techGroup.POST("/relations/homophones", components.WordRelationController.DetectHomophones)
+
+		// Trash management endpoints
+		techGroup.GET("/trash", components.TrashController.ListTrash) // query param: kind
+		techGroup.POST("/trash/words/:id/restore", components.TrashController.RestoreWord)
+		techGroup.POST("/trash/labels/:id/restore", components.TrashController.RestoreLabel)
+		techGroup.POST("/trash/levels/:id/restore", components.TrashController.RestoreLevel)
+		techGroup.POST("/trash/purge", components.TrashController.PurgeTrash)
+
+		// Dictionary data import endpoints
+		techGroup.POST("/import/kanjidic", components.ImportController.ImportKanjidic)
+		techGroup.POST("/import/kanjivg", components.ImportController.ImportKanjiVG)
+		techGroup.POST("/import/kradfile", components.ImportController.ImportKradfile)
+		techGroup.POST("/import/tatoeba", components.ImportController.ImportTatoeba) // query param: lang
+		techGroup.POST("/import/accents", components.ImportController.ImportAccents)
+		techGroup.POST("/import/jlpt", components.ImportController.ImportJlptLevels)
+		techGroup.POST("/import/frequency", components.ImportController.ImportFrequencyRanks)
 	}
 
 	log.Info("Routes configured successfully")
@@ -119,10 +224,22 @@ func DatabaseConnection(dsn string, log *zap.Logger) (*gorm.DB, error) {
 	err = db.AutoMigrate(
 		&models.Label{},
 		&models.Word{},
+		&models.WordSense{},
+		&models.WordReading{},
+		&models.WordPitchAccent{},
+		&models.Kanji{},
+		&models.ExampleSentence{},
 		&models.Level{},
 		&models.WordTag{},
 		&models.WordLevel{},
-		&models.WordLearningHistory{})
+		&models.WordLearningHistory{},
+		&models.ConjugationLearningHistory{},
+		&models.WordRelation{},
+		&models.LevelRating{},
+		&models.LevelPublishedWord{},
+		&models.LevelSyncedWord{},
+		&models.LearningPath{},
+		&models.LearningPathStep{})
 	if err != nil {
 		log.Error("Failed to migrate database", zap.Error(err))
 		return nil, err
@@ -0,0 +1,42 @@
+package initialisation
+
+import (
+	"github.com/xanagit/kotoquiz-api/config"
+	"github.com/xanagit/kotoquiz-api/services"
+	"go.uber.org/zap"
+	"time"
+)
+
+// defaultPurgeInterval is used when no purge interval is configured
+const defaultPurgeInterval = 24 * time.Hour
+
+// StartTrashPurge purges the expired items of the trash in the background, once at startup then at each interval
+// A failed purge is logged and tried again at the next interval
+//
+// Parameters:
+//   - service: services.TrashService - The service purging the trash
+//   - cfg: *config.TrashConfig - The trash configuration giving the purge interval
+//   - log: *zap.Logger - Logger for the purge operations
+func StartTrashPurge(service services.TrashService, cfg *config.TrashConfig, log *zap.Logger) {
+	interval := defaultPurgeInterval
+	if cfg.PurgeInterval > 0 {
+		interval = time.Duration(cfg.PurgeInterval) * time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			report, err := service.PurgeTrash()
+			if err != nil {
+				log.Error("Failed to purge the trash", zap.Error(err))
+			} else {
+				log.Info("Trash purged",
+					zap.Int("words", report.Words),
+					zap.Int("labels", report.Labels),
+					zap.Int("levels", report.Levels))
+			}
+			<-ticker.C
+		}
+	}()
+}
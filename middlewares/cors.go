@@ -77,6 +77,8 @@ func (cm *CORSMiddlewareImpl) HandleCORS() gin.HandlerFunc {
 			c.Header("Access-Control-Allow-Methods", joinStrings(cm.CORSConfig.AllowMethods))
 			c.Header("Access-Control-Allow-Headers", joinStrings(cm.CORSConfig.AllowHeaders))
 			c.Header("Access-Control-Max-Age", strconv.Itoa(cm.CORSConfig.MaxAge))
+			// The ETag of a resource is needed to send a conditional update
+			c.Header("Access-Control-Expose-Headers", "ETag")
 
 			if cm.CORSConfig.Credentials {
 				c.Header("Access-Control-Allow-Credentials", "true")
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// ConjugationForm is an inflected form of a verb or an adjective
+// Verb forms are in their plain style, but for the polite form
+type ConjugationForm string
+
+const (
+	// PoliteForm is the ます form of verbs
+	PoliteForm   ConjugationForm = "POLITE"
+	TeForm       ConjugationForm = "TE"
+	PastForm     ConjugationForm = "PAST"
+	NegativeForm ConjugationForm = "NEGATIVE"
+	// PastNegativeForm is only drilled for adjectives
+	PastNegativeForm ConjugationForm = "PAST_NEGATIVE"
+	PotentialForm    ConjugationForm = "POTENTIAL"
+	PassiveForm      ConjugationForm = "PASSIVE"
+	CausativeForm    ConjugationForm = "CAUSATIVE"
+	VolitionalForm   ConjugationForm = "VOLITIONAL"
+	// ConditionalForm is the ば conditional, なら for na-adjectives
+	ConditionalForm ConjugationForm = "CONDITIONAL"
+)
+
+// VerbForms lists the forms drilled for verbs
+var VerbForms = []ConjugationForm{
+	PoliteForm, TeForm, PastForm, NegativeForm, PotentialForm,
+	PassiveForm, CausativeForm, VolitionalForm, ConditionalForm,
+}
+
+// AdjectiveForms lists the forms drilled for adjectives
+var AdjectiveForms = []ConjugationForm{TeForm, PastForm, NegativeForm, PastNegativeForm, ConditionalForm}
+
+// IsValid tells whether the form is a known one
+func (f ConjugationForm) IsValid() bool {
+	switch f {
+	case PoliteForm, TeForm, PastForm, NegativeForm, PastNegativeForm, PotentialForm,
+		PassiveForm, CausativeForm, VolitionalForm, ConditionalForm:
+		return true
+	}
+	return false
+}
+
+// ConjugationForms returns the forms drilled for a part of speech, none if the part of speech does not conjugate
+func ConjugationForms(partOfSpeech PartOfSpeech) []ConjugationForm {
+	switch {
+	case partOfSpeech.IsVerb():
+		return VerbForms
+	case partOfSpeech.IsAdjective():
+		return AdjectiveForms
+	default:
+		return nil
+	}
+}
+
+// ConjugationLearningHistory tracks the learning of a form of a word by a user
+type ConjugationLearningHistory struct {
+	UserID string          `gorm:"type:varchar(255);primaryKey;index:idx_user_word_form,priority:1" json:"userId"` // Keycloak user ID
+	WordID uuid.UUID       `gorm:"type:uuid;primaryKey;index:idx_user_word_form,priority:2" json:"wordId"`
+	Form   ConjugationForm `gorm:"type:varchar(20);primaryKey;index:idx_user_word_form,priority:3" json:"form"`
+
+	LearningStats `gorm:"embedded"`
+
+	// Relations
+	Word Word `gorm:"foreignKey:WordID" json:"-"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "github.com/google/uuid"
+
+// LevelRating is the score, from 1 to 5, given by a user to a published deck
+type LevelRating struct {
+	LevelID uuid.UUID `gorm:"type:uuid;primaryKey" json:"levelId"`
+	UserID  string    `gorm:"size:100;primaryKey" json:"-"`
+	Score   int       `gorm:"not null" json:"score"`
+
+	Level Level `gorm:"foreignKey:LevelID;constraint:OnDelete:CASCADE;" json:"-"`
+}
+
+// LevelPublishedWord is a word of a deck as of the last update pushed by its owner
+// Clones are built from the published words, the changes made to the deck in the meantime are not shared
+type LevelPublishedWord struct {
+	LevelID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	WordID  uuid.UUID `gorm:"type:uuid;primaryKey"`
+
+	Level Level `gorm:"foreignKey:LevelID;constraint:OnDelete:CASCADE;"`
+	Word  Word  `gorm:"foreignKey:WordID;constraint:OnDelete:CASCADE;"`
+}
+
+// LevelSyncedWord is a published word of the source deck of a clone, as of the last update accepted for the clone
+// Comparing them to the published words tells which words the owner of the deck added or removed since
+type LevelSyncedWord struct {
+	LevelID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	WordID  uuid.UUID `gorm:"type:uuid;primaryKey"`
+
+	Level Level `gorm:"foreignKey:LevelID;constraint:OnDelete:CASCADE;"`
+	Word  Word  `gorm:"foreignKey:WordID;constraint:OnDelete:CASCADE;"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "github.com/google/uuid"
+
+// ExampleSentence represents a Japanese sentence illustrating the use of words
+// Its translations are held by a label, like the translation of a word
+type ExampleSentence struct {
+	ID            uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Japanese      string    `gorm:"size:1000" json:"japanese"`
+	Reading       string    `gorm:"size:2000" json:"reading"`
+	Source        string    `gorm:"size:100;index:idx_example_source,priority:1" json:"source"`
+	SourceRef     string    `gorm:"size:100;index:idx_example_source,priority:2" json:"sourceRef"`
+	TranslationID uuid.UUID `gorm:"type:uuid" json:"-"`
+	// Furigana aligns Reading on Japanese, it is computed unless FuriganaOverride is set by an admin
+	Furigana         []FuriganaSegment `gorm:"type:jsonb;serializer:json" json:"furigana,omitempty"`
+	FuriganaOverride bool              `json:"furiganaOverride,omitempty"`
+
+	Translation Label   `gorm:"foreignKey:TranslationID" json:"translation"`
+	Words       []*Word `gorm:"many2many:word_example;joinForeignKey:ExampleSentenceID;joinReferences:WordID;constraint:OnDelete:CASCADE;" json:"-"`
+	// WordIDs lists the words illustrated by the sentence, it is used to read and write the links
+	WordIDs []uuid.UUID `gorm:"-" json:"wordIds"`
+}
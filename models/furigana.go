@@ -0,0 +1,8 @@
+package models
+
+// FuriganaSegment is a part of a Japanese text with the reading to display above it
+// Segments without kanji have no reading
+type FuriganaSegment struct {
+	Text    string `json:"text"`
+	Reading string `json:"reading,omitempty"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// JlptLevel is the level of the Japanese Language Proficiency Test at which a word is expected to be known
+// It goes from 5 for N5, the easiest level, down to 1 for N1, 0 meaning that the level is unknown
+type JlptLevel int
+
+const (
+	JlptN1 JlptLevel = 1
+	JlptN2 JlptLevel = 2
+	JlptN3 JlptLevel = 3
+	JlptN4 JlptLevel = 4
+	JlptN5 JlptLevel = 5
+)
+
+// IsValid tells whether the level is one of N5 to N1
+func (l JlptLevel) IsValid() bool {
+	return l >= JlptN1 && l <= JlptN5
+}
+
+// String returns the usual name of the level ("N5")
+func (l JlptLevel) String() string {
+	return "N" + strconv.Itoa(int(l))
+}
+
+// ParseJlptLevel reads a level written by its name ("N5", "n5") or by its number ("5")
+func ParseJlptLevel(s string) (JlptLevel, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) > 0 && (s[0] == 'N' || s[0] == 'n') {
+		s = s[1:]
+	}
+	number, err := strconv.Atoi(s)
+	if err != nil || !JlptLevel(number).IsValid() {
+		return 0, false
+	}
+	return JlptLevel(number), true
+}
@@ -0,0 +1,24 @@
+package models
+
+import "github.com/google/uuid"
+
+// Kanji represents a single kanji character with its dictionary data
+// It is linked to every word whose Kanji field contains the character
+type Kanji struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Character   string    `gorm:"size:10;uniqueIndex" json:"character"`
+	StrokeCount int       `json:"strokeCount"`
+	Radical     string    `gorm:"size:10" json:"radical"`
+	Components  []string  `gorm:"type:jsonb;serializer:json" json:"components"`
+	OnReadings  []string  `gorm:"type:jsonb;serializer:json" json:"onReadings"`
+	KunReadings []string  `gorm:"type:jsonb;serializer:json" json:"kunReadings"`
+	MeaningsEn  []string  `gorm:"type:jsonb;serializer:json" json:"meaningsEn"`
+	MeaningsFr  []string  `gorm:"type:jsonb;serializer:json" json:"meaningsFr"`
+	JLPT        int       `json:"jlpt"`
+	Grade       int       `json:"grade"`
+	Frequency   int       `json:"frequency"`
+	// StrokePaths holds the SVG path of every stroke, in stroke order
+	StrokePaths []string `gorm:"type:jsonb;serializer:json" json:"strokePaths"`
+
+	Words []*Word `gorm:"many2many:word_kanji;joinForeignKey:KanjiID;joinReferences:WordID;constraint:OnDelete:CASCADE;" json:"-"`
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type LabelType string
@@ -13,11 +14,24 @@ const (
 	Translation LabelType = "TRANSLATION"
 )
 
+// IsValid tells whether the label type is a known one
+func (t LabelType) IsValid() bool {
+	return t == Tag || t == Category || t == LevelName || t == Translation
+}
+
 type Label struct {
 	ID   uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
 	En   string    `gorm:"size:255" json:"en"`
 	Fr   string    `gorm:"size:255" json:"fr"`
 	Type LabelType `gorm:"size:100" json:"type"`
+	// Position orders the level names of a category, e.g. N5 to N1 or Lesson 1 to 30
+	Position int `gorm:"default:0" json:"position,omitempty"`
+	// ParentID nests a tag under a broader one, e.g. Citrus under Fruit under Food
+	ParentID *uuid.UUID `gorm:"type:uuid;index" json:"parentId,omitempty"`
+	// Version is incremented by each update of the label, it is exposed as the ETag of the label
+	Version int `gorm:"<-:create;not null;default:1" json:"-"`
+	// DeletedAt is set when the label is moved to the trash, GORM then leaves it out of the queries
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
 	Words []*Word `gorm:"many2many:word_tag;constraint:OnDelete:CASCADE;" json:"-"`
 }
@@ -0,0 +1,23 @@
+package models
+
+import "github.com/google/uuid"
+
+// LearningPath is an ordered sequence of levels, the users unlock each level by learning the previous one
+type LearningPath struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	En string    `gorm:"size:255" json:"en"`
+	Fr string    `gorm:"size:255" json:"fr"`
+	// UnlockThreshold overrides the configured share of words to learn to unlock the next level, 0 keeps the configured one
+	UnlockThreshold float64 `gorm:"default:0" json:"unlockThreshold,omitempty"`
+
+	Steps []*LearningPathStep `gorm:"foreignKey:PathID;constraint:OnDelete:CASCADE;" json:"steps"`
+}
+
+// LearningPathStep is a level of a learning path at its position in the path
+type LearningPathStep struct {
+	PathID   uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	LevelID  uuid.UUID `gorm:"type:uuid;primaryKey" json:"levelId"`
+	Position int       `gorm:"not null" json:"position"`
+
+	Level *Level `gorm:"foreignKey:LevelID;constraint:OnDelete:CASCADE;" json:"level,omitempty"`
+}
@@ -12,26 +12,65 @@ const (
 	CustomLevel LevelType = "CUSTOM_LEVEL"
 )
 
+// LevelVisibility tells who can see a custom level besides its owner
+type LevelVisibility string
+
+const (
+	// Private levels are only seen by their owner
+	Private LevelVisibility = "PRIVATE"
+	// Shared levels can be read by anyone knowing their ID
+	Shared LevelVisibility = "SHARED"
+	// Public levels can be read by anyone
+	Public LevelVisibility = "PUBLIC"
+)
+
+// IsValid tells whether the visibility is a known one
+func (v LevelVisibility) IsValid() bool {
+	return v == Private || v == Shared || v == Public
+}
+
 type Level struct {
-	ID   uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	Type LevelType `gorm:"size:100" json:"type"`
-	// TODO : ajouter une fk vers un user s'il s'agit de la liste d'un user
+	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Type       LevelType `gorm:"size:100" json:"type"`
 	CategoryID uuid.UUID `gorm:"type:uuid" json:"-"`
+	// OwnerID is the Keycloak subject of the user owning a custom level, empty for the built-in levels
+	OwnerID    string          `gorm:"size:100;index" json:"ownerId,omitempty"`
+	Visibility LevelVisibility `gorm:"size:20" json:"visibility,omitempty"`
 
 	Category   Label    `gorm:"foreignKey:CategoryID" json:"category"`
 	LevelNames []*Label `gorm:"many2many:level_values;constraint:OnDelete:CASCADE;" json:"levelNames"`
 	Words      []*Word  `gorm:"many2many:word_level" json:"-"`
+	// WordIDs lists the words of a custom level, they are only filled when reading a single level
+	WordIDs []uuid.UUID `gorm:"-" json:"wordIds,omitempty"`
+
+	// ShareCode is set once a custom level is published as a deck, Revision counts the updates pushed by its owner
+	ShareCode string `gorm:"size:16;uniqueIndex:idx_level_share_code,where:share_code <> ''" json:"shareCode,omitempty"`
+	Revision  int    `gorm:"default:0" json:"revision,omitempty"`
+	// SourceLevelID is the deck a level was cloned from, SourceRevision the revision of the deck it is in sync with
+	SourceLevelID  *uuid.UUID `gorm:"type:uuid;index" json:"sourceLevelId,omitempty"`
+	SourceRevision int        `gorm:"default:0" json:"sourceRevision,omitempty"`
+	// CloneCount and the ratings of a deck are kept up to date to sort the public decks
+	CloneCount    int     `gorm:"default:0" json:"cloneCount,omitempty"`
+	RatingCount   int     `gorm:"default:0" json:"ratingCount,omitempty"`
+	RatingAverage float64 `gorm:"default:0" json:"ratingAverage,omitempty"`
+	// Version is incremented by each update of the level, it is exposed as the ETag of the level
+	Version int `gorm:"<-:create;not null;default:1" json:"-"`
+	// DeletedAt is set when the level is moved to the trash, GORM then leaves it out of the queries
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// IsPublished tells whether the level is published as a deck
+func (l *Level) IsPublished() bool {
+	return l.ShareCode != ""
+}
+
+// IsCustom tells whether the level was created by a user
+func (l *Level) IsCustom() bool {
+	return l.Type == CustomLevel
 }
 
-// BeforeDelete est un hook GORM qui sera appelé automatiquement avant la suppression
-func (l *Level) BeforeDelete(tx *gorm.DB) error {
-	// Supprimer les associations many2many
-	if err := tx.Model(l).Association("LevelNames").Clear(); err != nil {
-		return err
-	}
-	if err := tx.Model(l).Association("Words").Clear(); err != nil {
-		return err
-	}
-
-	return nil
+// IsVisibleTo tells whether the level can be read by the given user
+// Built-in levels are seen by everyone
+func (l *Level) IsVisibleTo(userID string) bool {
+	return !l.IsCustom() || l.OwnerID == userID || l.Visibility == Shared || l.Visibility == Public
 }
@@ -0,0 +1,65 @@
+package models
+
+// PartOfSpeech is the grammatical class of a word
+// Verbs and adjectives are split by conjugation class
+type PartOfSpeech string
+
+const (
+	Noun        PartOfSpeech = "NOUN"
+	Pronoun     PartOfSpeech = "PRONOUN"
+	GodanVerb   PartOfSpeech = "GODAN_VERB"
+	IchidanVerb PartOfSpeech = "ICHIDAN_VERB"
+	// SuruVerb covers する and the nouns conjugated with it (勉強する)
+	SuruVerb PartOfSpeech = "SURU_VERB"
+	// KuruVerb is the irregular verb 来る
+	KuruVerb     PartOfSpeech = "KURU_VERB"
+	IAdjective   PartOfSpeech = "I_ADJECTIVE"
+	NaAdjective  PartOfSpeech = "NA_ADJECTIVE"
+	Adverb       PartOfSpeech = "ADVERB"
+	Counter      PartOfSpeech = "COUNTER"
+	Particle     PartOfSpeech = "PARTICLE"
+	Conjunction  PartOfSpeech = "CONJUNCTION"
+	Interjection PartOfSpeech = "INTERJECTION"
+	Prefix       PartOfSpeech = "PREFIX"
+	Suffix       PartOfSpeech = "SUFFIX"
+	Expression   PartOfSpeech = "EXPRESSION"
+)
+
+// PartsOfSpeech lists every known part of speech
+var PartsOfSpeech = []PartOfSpeech{
+	Noun, Pronoun, GodanVerb, IchidanVerb, SuruVerb, KuruVerb, IAdjective, NaAdjective,
+	Adverb, Counter, Particle, Conjunction, Interjection, Prefix, Suffix, Expression,
+}
+
+// IsValid tells whether the part of speech is a known one
+func (p PartOfSpeech) IsValid() bool {
+	for _, known := range PartsOfSpeech {
+		if p == known {
+			return true
+		}
+	}
+	return false
+}
+
+// IsVerb tells whether the part of speech is a verb conjugation class
+func (p PartOfSpeech) IsVerb() bool {
+	return p == GodanVerb || p == IchidanVerb || p == SuruVerb || p == KuruVerb
+}
+
+// IsAdjective tells whether the part of speech is an adjective conjugation class
+func (p PartOfSpeech) IsAdjective() bool {
+	return p == IAdjective || p == NaAdjective
+}
+
+// Transitivity tells whether a verb takes a direct object
+type Transitivity string
+
+const (
+	Transitive   Transitivity = "TRANSITIVE"
+	Intransitive Transitivity = "INTRANSITIVE"
+)
+
+// IsValid tells whether the transitivity is a known one
+func (t Transitivity) IsValid() bool {
+	return t == Transitive || t == Intransitive
+}
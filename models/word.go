@@ -17,29 +17,43 @@ const (
 // Word represents a vocabulary word in the database
 // It contains the core word data and references to translations, tags, and levels
 type Word struct {
-	ID            uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	Kanji         string    `gorm:"size:50" json:"kanji"`
-	Yomi          string    `gorm:"size:50" json:"yomi"`
-	YomiType      YomiType  `gorm:"size:50" json:"yomiType"`
-	ImageURL      string    `gorm:"size:255" json:"imageURL"`
+	ID       uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Kanji    string    `gorm:"size:50" json:"kanji"`
+	Yomi     string    `gorm:"size:50" json:"yomi"`
+	YomiType YomiType  `gorm:"size:50" json:"yomiType"`
+	ImageURL string    `gorm:"size:255" json:"imageURL"`
+	// AudioKey and ImageKey locate the media uploaded for the word in the media storage
+	// They are only changed by the media endpoints, ImageKey taking precedence over ImageURL
+	AudioKey      string    `gorm:"size:255" json:"audioKey,omitempty"`
+	ImageKey      string    `gorm:"size:255" json:"imageKey,omitempty"`
 	TranslationID uuid.UUID `gorm:"type:uuid" json:"-"`
 
+	// PartOfSpeech is the grammatical class of the word, Transitivity only applies to verbs
+	PartOfSpeech PartOfSpeech `gorm:"size:50;index" json:"partOfSpeech,omitempty"`
+	Transitivity Transitivity `gorm:"size:20" json:"transitivity,omitempty"`
+
+	// JlptLevel and FrequencyRank are canonical metadata used to introduce common words first, 0 when unknown
+	// FrequencyRank is the rank of the word in a corpus frequency list, 1 being the most common word
+	JlptLevel     JlptLevel `gorm:"index" json:"jlptLevel,omitempty"`
+	FrequencyRank int       `gorm:"index" json:"frequencyRank,omitempty"`
+
 	Translation Label    `gorm:"foreignKey:TranslationID" json:"translation"`
 	Tags        []*Label `gorm:"many2many:word_tag;joinForeignKey:WordID;joinReferences:LabelID" json:"tags"`
 	Levels      []*Level `gorm:"many2many:word_level;joinForeignKey:WordID;joinReferences:LevelID" json:"levels"`
-}
 
-// BeforeDelete is a GORM hook that runs before deleting a word
-// It handles cleaning up related records to prevent orphaned relationships
-func (w *Word) BeforeDelete(tx *gorm.DB) error {
-	// Remove all word-tag associations many2many
-	if err := tx.Model(w).Association("Tags").Clear(); err != nil {
-		return err
-	}
-	// Remove all word-level associations
-	if err := tx.Model(w).Association("Levels").Clear(); err != nil {
-		return err
-	}
-
-	return nil
+	// Senses lists the distinct meanings of the word, Translation remaining the primary one
+	Senses []*WordSense `gorm:"foreignKey:WordID;constraint:OnDelete:CASCADE;" json:"senses,omitempty"`
+	// Readings lists every valid reading of the word, Yomi being the primary one
+	Readings []*WordReading `gorm:"foreignKey:WordID;constraint:OnDelete:CASCADE;" json:"readings,omitempty"`
+	// PitchAccents lists the pitch accents of the primary reading, ordered by Position
+	PitchAccents []*WordPitchAccent `gorm:"foreignKey:WordID;constraint:OnDelete:CASCADE;" json:"pitchAccents,omitempty"`
+
+	// Furigana aligns Yomi on the characters of Kanji, it is computed unless FuriganaOverride is set by an admin
+	Furigana         []FuriganaSegment `gorm:"type:jsonb;serializer:json" json:"furigana,omitempty"`
+	FuriganaOverride bool              `json:"furiganaOverride,omitempty"`
+
+	// Version is incremented by each update of the word, it is exposed as the ETag of the word
+	Version int `gorm:"<-:create;not null;default:1" json:"-"`
+	// DeletedAt is set when the word is moved to the trash, GORM then leaves it out of the queries
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
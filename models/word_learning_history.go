@@ -14,10 +14,9 @@ const (
 	Mastered  WLStatus = "MASTERED"
 )
 
-type WordLearningHistory struct {
-	UserID string    `gorm:"type:varchar(255);primaryKey;index:idx_user_word,priority:1" json:"userId"` // Keycloak user ID
-	WordID uuid.UUID `gorm:"type:uuid;primaryKey;index:idx_user_word,priority:2" json:"wordId"`
-
+// LearningStats holds the review schedule and the answer statistics of a learned item
+// It is embedded in the learning histories, its columns are stored in their tables
+type LearningStats struct {
 	// Learning timing
 	LastViewedAt   time.Time `json:"lastViewedAt"`
 	NextReviewDate time.Time `json:"nextReviewDate"`
@@ -32,6 +31,13 @@ type WordLearningHistory struct {
 
 	// Learning Status
 	LearningStatus WLStatus `gorm:"default:'NEW'" json:"learningStatus"`
+}
+
+type WordLearningHistory struct {
+	UserID string    `gorm:"type:varchar(255);primaryKey;index:idx_user_word,priority:1" json:"userId"` // Keycloak user ID
+	WordID uuid.UUID `gorm:"type:uuid;primaryKey;index:idx_user_word,priority:2" json:"wordId"`
+
+	LearningStats `gorm:"embedded"`
 
 	// Relations
 	Word Word `gorm:"foreignKey:WordID" json:"-"`
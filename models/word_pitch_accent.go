@@ -0,0 +1,22 @@
+package models
+
+import "github.com/google/uuid"
+
+type PitchPattern string
+
+const (
+	Heiban    PitchPattern = "HEIBAN"
+	Atamadaka PitchPattern = "ATAMADAKA"
+	Nakadaka  PitchPattern = "NAKADAKA"
+	Odaka     PitchPattern = "ODAKA"
+)
+
+// WordPitchAccent represents one of the pitch accents of a word, the most common first
+// Downstep is the mora after which the pitch falls, 0 when it never falls
+type WordPitchAccent struct {
+	ID       uuid.UUID    `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	WordID   uuid.UUID    `gorm:"type:uuid;index:idx_word_pitch_accent,priority:1" json:"-"`
+	Position int          `gorm:"index:idx_word_pitch_accent,priority:2" json:"position"`
+	Downstep int          `json:"downstep"`
+	Pattern  PitchPattern `gorm:"size:50" json:"pattern"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "github.com/google/uuid"
+
+// WordReading represents one of the valid readings of a word
+// The primary reading is mirrored in Word.Yomi and Word.YomiType
+type WordReading struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	WordID    uuid.UUID `gorm:"type:uuid;index" json:"-"`
+	Reading   string    `gorm:"size:50;index" json:"reading"`
+	Type      YomiType  `gorm:"size:50" json:"type"`
+	IsPrimary bool      `json:"isPrimary"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "github.com/google/uuid"
+
+// WordRelationType is the kind of link between two words
+// Every relation goes both ways, the related word of a synonym is a synonym of the word
+type WordRelationType string
+
+const (
+	Synonym   WordRelationType = "SYNONYM"
+	Antonym   WordRelationType = "ANTONYM"
+	Homophone WordRelationType = "HOMOPHONE"
+	// SameKanji links words written with a common kanji
+	SameKanji WordRelationType = "SAME_KANJI"
+	// Confusable links words learners commonly mistake for each other
+	Confusable WordRelationType = "CONFUSABLE"
+)
+
+// IsValid tells whether the relation type is a known one
+func (t WordRelationType) IsValid() bool {
+	switch t {
+	case Synonym, Antonym, Homophone, SameKanji, Confusable:
+		return true
+	}
+	return false
+}
+
+// WordRelation links two words
+// A relation is stored once for both words, WordID being the lowest of the two IDs
+type WordRelation struct {
+	ID            uuid.UUID        `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	WordID        uuid.UUID        `gorm:"type:uuid;not null;uniqueIndex:idx_word_relation,priority:1" json:"wordId"`
+	RelatedWordID uuid.UUID        `gorm:"type:uuid;not null;uniqueIndex:idx_word_relation,priority:2;index" json:"relatedWordId"`
+	Type          WordRelationType `gorm:"size:20;not null;uniqueIndex:idx_word_relation,priority:3" json:"type"`
+	// Automatic is set on the homophones detected from identical readings, they follow the changes of the readings
+	Automatic bool `gorm:"default:false" json:"automatic"`
+
+	Word        Word `gorm:"foreignKey:WordID;constraint:OnDelete:CASCADE;" json:"-"`
+	RelatedWord Word `gorm:"foreignKey:RelatedWordID;constraint:OnDelete:CASCADE;" json:"-"`
+}
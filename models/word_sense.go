@@ -0,0 +1,15 @@
+package models
+
+import "github.com/google/uuid"
+
+// WordSense represents one distinct meaning of a word
+// Senses are ordered by Position and each one carries its own translations
+type WordSense struct {
+	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	WordID       uuid.UUID `gorm:"type:uuid;index:idx_word_sense,priority:1" json:"-"`
+	Position     int       `gorm:"index:idx_word_sense,priority:2" json:"position"`
+	PartOfSpeech string    `gorm:"size:100" json:"partOfSpeech"`
+	Notes        string    `gorm:"size:1000" json:"notes"`
+
+	Translations []*Label `gorm:"many2many:sense_translation;joinForeignKey:SenseID;joinReferences:LabelID;constraint:OnDelete:CASCADE;" json:"translations"`
+}
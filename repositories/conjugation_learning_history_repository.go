@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/models"
+	"gorm.io/gorm"
+)
+
+type ConjugationLearningHistoryRepository interface {
+	GetHistories(userID string, wordIDs []uuid.UUID) (map[uuid.UUID][]*models.ConjugationLearningHistory, error)
+	InsertHistories(histories []*models.ConjugationLearningHistory) error
+	UpdateHistories(histories []*models.ConjugationLearningHistory) error
+}
+
+type ConjugationLearningHistoryRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// Make sure that ConjugationLearningHistoryRepositoryImpl implements ConjugationLearningHistoryRepository
+var _ ConjugationLearningHistoryRepository = (*ConjugationLearningHistoryRepositoryImpl)(nil)
+
+// GetHistories returns the histories of every form of the words learned by the user, grouped by word
+func (r *ConjugationLearningHistoryRepositoryImpl) GetHistories(userID string, wordIDs []uuid.UUID) (map[uuid.UUID][]*models.ConjugationLearningHistory, error) {
+	var histories []*models.ConjugationLearningHistory
+
+	err := r.DB.Set("gorm:query_option", "FOR UPDATE").
+		Where("user_id = ? AND word_id IN ?", userID, wordIDs).
+		Find(&histories).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	existingHistories := make(map[uuid.UUID][]*models.ConjugationLearningHistory)
+	for _, h := range histories {
+		existingHistories[h.WordID] = append(existingHistories[h.WordID], h)
+	}
+
+	return existingHistories, nil
+}
+
+func (r *ConjugationLearningHistoryRepositoryImpl) InsertHistories(histories []*models.ConjugationLearningHistory) error {
+	if len(histories) == 0 {
+		return nil
+	}
+
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		for _, history := range histories {
+			// Check existence with lock
+			var existing models.ConjugationLearningHistory
+			err := tx.Set("gorm:query_option", "FOR UPDATE").
+				Where("user_id = ? AND word_id = ? AND form = ?", history.UserID, history.WordID, history.Form).
+				First(&existing).Error
+
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+
+			// If record not found, insert it
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				if err := tx.Create(history).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (r *ConjugationLearningHistoryRepositoryImpl) UpdateHistories(histories []*models.ConjugationLearningHistory) error {
+	if len(histories) == 0 {
+		return nil
+	}
+
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		for _, history := range histories {
+			// Get a lock on the record before updating
+			var existingHistory models.ConjugationLearningHistory
+			if err := tx.Set("gorm:query_option", "FOR UPDATE").
+				Where("user_id = ? AND word_id = ? AND form = ?", history.UserID, history.WordID, history.Form).
+				First(&existingHistory).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					continue // Skip if history not found
+				}
+				return err
+			}
+
+			// Make update with lock, every column being selected so that a streak reset to 0 is written too
+			if err := tx.Model(&models.ConjugationLearningHistory{}).
+				Where("user_id = ? AND word_id = ? AND form = ?", history.UserID, history.WordID, history.Form).
+				Select("*").
+				Updates(history).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,201 @@
+package repositories
+
+import (
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"gorm.io/gorm"
+)
+
+type DeckRepository interface {
+	ReadDeckByShareCode(code string) (*models.Level, error)
+	ListPublicDecks(sort dto.DeckSort, limit int, offset int) ([]*models.Level, int64, error)
+	CountPublishedWords(ids []uuid.UUID) (map[uuid.UUID]int64, error)
+	PublishDeck(id uuid.UUID, code string, visibility models.LevelVisibility) error
+	UnpublishDeck(id uuid.UUID) error
+	PushDeck(id uuid.UUID) error
+	CloneDeck(source *models.Level, clone *models.Level) error
+	ListDeckChanges(id uuid.UUID, sourceID uuid.UUID) (added []uuid.UUID, removed []uuid.UUID, err error)
+	ApplyDeckChanges(id uuid.UUID, source *models.Level, added []uuid.UUID, removed []uuid.UUID) error
+	RateDeck(id uuid.UUID, userID string, score int) error
+}
+
+type DeckRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// Make sure that DeckRepositoryImpl implements DeckRepository
+var _ DeckRepository = (*DeckRepositoryImpl)(nil)
+
+// deckSortOrders gives the ORDER BY clause of each order of the public decks
+var deckSortOrders = map[dto.DeckSort]string{
+	dto.SortByClones: "clone_count DESC, rating_average DESC, id",
+	dto.SortByRating: "rating_average DESC, rating_count DESC, clone_count DESC, id",
+}
+
+// ReadDeckByShareCode fetches a published level from its share code, a published level being shared or public
+func (r *DeckRepositoryImpl) ReadDeckByShareCode(code string) (*models.Level, error) {
+	var level models.Level
+	result := r.DB.Preload("LevelNames", levelNamesByPosition).Preload("Category").
+		First(&level, "share_code = ? AND share_code <> '' AND visibility IN ?", code, []models.LevelVisibility{models.Shared, models.Public})
+	return &level, result.Error
+}
+
+// ListPublicDecks returns a page of the published public levels, with the total number of public decks
+func (r *DeckRepositoryImpl) ListPublicDecks(sort dto.DeckSort, limit int, offset int) ([]*models.Level, int64, error) {
+	query := r.DB.Model(&models.Level{}).Where("share_code <> '' AND visibility = ?", models.Public)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var levels []*models.Level
+	err := query.Preload("LevelNames", levelNamesByPosition).Preload("Category").
+		Order(deckSortOrders[sort]).Limit(limit).Offset(offset).Find(&levels).Error
+	return levels, total, err
+}
+
+// CountPublishedWords counts the published words of each deck, except the words in the trash
+func (r *DeckRepositoryImpl) CountPublishedWords(ids []uuid.UUID) (map[uuid.UUID]int64, error) {
+	var counts []struct {
+		LevelID uuid.UUID
+		Count   int64
+	}
+	if err := r.DB.Model(&models.LevelPublishedWord{}).Select("level_id, COUNT(*) AS count").
+		Where("level_id IN ? AND word_id IN ("+liveWordIdsQuery+")", ids).Group("level_id").Find(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	countsByLevel := make(map[uuid.UUID]int64, len(counts))
+	for _, count := range counts {
+		countsByLevel[count.LevelID] = count.Count
+	}
+	return countsByLevel, nil
+}
+
+// PublishDeck gives a share code to a level, then publishes its current words
+func (r *DeckRepositoryImpl) PublishDeck(id uuid.UUID, code string, visibility models.LevelVisibility) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Level{ID: id}).Select("share_code", "visibility").
+			Updates(&models.Level{ShareCode: code, Visibility: visibility}).Error; err != nil {
+			return err
+		}
+		return pushDeck(tx, id)
+	})
+}
+
+// UnpublishDeck drops the share code of a level, which becomes private again
+// The clones of the deck keep their words
+func (r *DeckRepositoryImpl) UnpublishDeck(id uuid.UUID) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Level{ID: id}).Select("share_code", "visibility", "version").
+			Updates(map[string]interface{}{"share_code": "", "visibility": models.Private, "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return err
+		}
+		return tx.Where("level_id = ?", id).Delete(&models.LevelPublishedWord{}).Error
+	})
+}
+
+// PushDeck publishes the current words of a level as a new revision of the deck
+func (r *DeckRepositoryImpl) PushDeck(id uuid.UUID) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		return pushDeck(tx, id)
+	})
+}
+
+// CloneDeck creates a copy of a deck with its published words, except the words in the trash, the copy then evolves on its own
+func (r *DeckRepositoryImpl) CloneDeck(source *models.Level, clone *models.Level) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(clone).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("INSERT INTO word_level (word_id, level_id) "+
+			"SELECT word_id, ? FROM level_published_words WHERE level_id = ? AND word_id IN ("+liveWordIdsQuery+")",
+			clone.ID, source.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("INSERT INTO level_synced_words (level_id, word_id) "+
+			"SELECT ?, word_id FROM level_published_words WHERE level_id = ? AND word_id IN ("+liveWordIdsQuery+")",
+			clone.ID, source.ID).Error; err != nil {
+			return err
+		}
+		// A user cloning the same deck several times is counted once
+		return tx.Model(&models.Level{ID: source.ID}).
+			UpdateColumns(map[string]interface{}{
+				"clone_count": gorm.Expr("(SELECT COUNT(DISTINCT owner_id) FROM levels WHERE source_level_id = ?)", source.ID),
+				"version":     gorm.Expr("version + 1"),
+			}).Error
+	})
+}
+
+// ListDeckChanges compares the words published by the source deck to the ones the clone was last synced with
+// The words in the trash are left out of the changes
+func (r *DeckRepositoryImpl) ListDeckChanges(id uuid.UUID, sourceID uuid.UUID) (added []uuid.UUID, removed []uuid.UUID, err error) {
+	if err = r.DB.Raw("SELECT word_id FROM level_published_words WHERE level_id = ? AND word_id IN ("+liveWordIdsQuery+") "+
+		"EXCEPT SELECT word_id FROM level_synced_words WHERE level_id = ? ORDER BY word_id", sourceID, id).
+		Scan(&added).Error; err != nil {
+		return nil, nil, err
+	}
+	err = r.DB.Raw("SELECT word_id FROM level_synced_words WHERE level_id = ? AND word_id IN ("+liveWordIdsQuery+") "+
+		"EXCEPT SELECT word_id FROM level_published_words WHERE level_id = ? ORDER BY word_id", id, sourceID).
+		Scan(&removed).Error
+	return added, removed, err
+}
+
+// ApplyDeckChanges applies the changes of the source deck to the words of a clone
+// The words the owner of the clone added or removed on their own are left as they are
+func (r *DeckRepositoryImpl) ApplyDeckChanges(id uuid.UUID, source *models.Level, added []uuid.UUID, removed []uuid.UUID) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		for _, wordID := range added {
+			if err := tx.Exec("INSERT INTO word_level (word_id, level_id) VALUES (?, ?) ON CONFLICT DO NOTHING",
+				wordID, id).Error; err != nil {
+				return err
+			}
+		}
+		if len(removed) > 0 {
+			if err := tx.Exec("DELETE FROM word_level WHERE level_id = ? AND word_id IN ?", id, removed).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("level_id = ?", id).Delete(&models.LevelSyncedWord{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("INSERT INTO level_synced_words (level_id, word_id) "+
+			"SELECT ?, word_id FROM level_published_words WHERE level_id = ? AND word_id IN ("+liveWordIdsQuery+")",
+			id, source.ID).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Level{ID: id}).
+			UpdateColumns(map[string]interface{}{"source_revision": source.Revision, "version": gorm.Expr("version + 1")}).Error
+	})
+}
+
+// RateDeck records the score given by a user to a deck, replacing their previous score, and updates the ratings of the deck
+func (r *DeckRepositoryImpl) RateDeck(id uuid.UUID, userID string, score int) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("INSERT INTO level_ratings (level_id, user_id, score) VALUES (?, ?, ?) "+
+			"ON CONFLICT (level_id, user_id) DO UPDATE SET score = EXCLUDED.score", id, userID, score).Error; err != nil {
+			return err
+		}
+		return tx.Exec("UPDATE levels SET "+
+			"rating_count = (SELECT COUNT(*) FROM level_ratings WHERE level_id = ?), "+
+			"rating_average = (SELECT AVG(score) FROM level_ratings WHERE level_id = ?), "+
+			"version = version + 1 "+
+			"WHERE id = ?", id, id, id).Error
+	})
+}
+
+// pushDeck replaces the published words of a level by its current words, except the words in the trash,
+// and increments its revision and its version
+func pushDeck(tx *gorm.DB, id uuid.UUID) error {
+	if err := tx.Where("level_id = ?", id).Delete(&models.LevelPublishedWord{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("INSERT INTO level_published_words (level_id, word_id) "+
+		"SELECT level_id, word_id FROM word_level WHERE level_id = ? AND word_id IN ("+liveWordIdsQuery+")", id).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Level{ID: id}).
+		UpdateColumns(map[string]interface{}{"revision": gorm.Expr("revision + 1"), "version": gorm.Expr("version + 1")}).Error
+}
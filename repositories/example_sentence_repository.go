@@ -0,0 +1,202 @@
+package repositories
+
+import (
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/models"
+	"gorm.io/gorm"
+)
+
+type ExampleSentenceRepository interface {
+	ListExampleSentences() ([]*models.ExampleSentence, error)
+	ReadExampleSentence(id uuid.UUID) (*models.ExampleSentence, error)
+	CreateExampleSentence(sentence *models.ExampleSentence) error
+	UpdateExampleSentence(sentence *models.ExampleSentence) error
+	UpdateExampleSentenceFurigana(id uuid.UUID, furigana []models.FuriganaSegment, override bool) error
+	DeleteExampleSentence(id uuid.UUID) error
+	ListExampleSentencesByIds(ids []uuid.UUID) ([]*models.ExampleSentence, error)
+	ListExampleSentencesByWordIds(wordIDs []uuid.UUID) (map[uuid.UUID][]*models.ExampleSentence, error)
+	ListExampleSentencesBySourceRefs(source string, refs []string) (map[string]*models.ExampleSentence, error)
+	ImportExampleSentences(newSentences []*models.ExampleSentence, updatedTranslations []*models.Label) error
+}
+
+type ExampleSentenceRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// Make sure that ExampleSentenceRepositoryImpl implements ExampleSentenceRepository
+var _ ExampleSentenceRepository = (*ExampleSentenceRepositoryImpl)(nil)
+
+func (r *ExampleSentenceRepositoryImpl) ListExampleSentences() ([]*models.ExampleSentence, error) {
+	var sentences []*models.ExampleSentence
+	result := r.DB.Preload("Translation").Order("japanese").Find(&sentences)
+	return sentences, result.Error
+}
+
+// ReadExampleSentence reads a sentence with the IDs of its words, except the words in the trash
+func (r *ExampleSentenceRepositoryImpl) ReadExampleSentence(id uuid.UUID) (*models.ExampleSentence, error) {
+	var sentence models.ExampleSentence
+	if err := r.DB.Preload("Translation").First(&sentence, "id = ?", id).Error; err != nil {
+		return &sentence, err
+	}
+	err := r.DB.Table("word_example").Where("example_sentence_id = ? AND word_id IN ("+liveWordIdsQuery+")", id).
+		Pluck("word_id", &sentence.WordIDs).Error
+	return &sentence, err
+}
+
+func (r *ExampleSentenceRepositoryImpl) CreateExampleSentence(sentence *models.ExampleSentence) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(sentence).Error; err != nil {
+			return err
+		}
+		return replaceSentenceWords(tx, sentence)
+	})
+}
+
+func (r *ExampleSentenceRepositoryImpl) UpdateExampleSentence(sentence *models.ExampleSentence) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(sentence).Error; err != nil {
+			return err
+		}
+		return replaceSentenceWords(tx, sentence)
+	})
+}
+
+// UpdateExampleSentenceFurigana only updates the furigana of a sentence, telling whether they were set by hand
+func (r *ExampleSentenceRepositoryImpl) UpdateExampleSentenceFurigana(id uuid.UUID, furigana []models.FuriganaSegment, override bool) error {
+	return r.DB.Model(&models.ExampleSentence{ID: id}).Select("furigana", "furigana_override").
+		Updates(&models.ExampleSentence{Furigana: furigana, FuriganaOverride: override}).Error
+}
+
+func (r *ExampleSentenceRepositoryImpl) DeleteExampleSentence(id uuid.UUID) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var sentence models.ExampleSentence
+		if err := tx.First(&sentence, "id = ?", id).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec("DELETE FROM word_example WHERE example_sentence_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&sentence).Error; err != nil {
+			return err
+		}
+
+		// The translation label belongs to the sentence only, it does not go to the trash
+		if sentence.TranslationID != uuid.Nil {
+			return tx.Unscoped().Delete(&models.Label{}, "id = ?", sentence.TranslationID).Error
+		}
+		return nil
+	})
+}
+
+func (r *ExampleSentenceRepositoryImpl) ListExampleSentencesByIds(ids []uuid.UUID) ([]*models.ExampleSentence, error) {
+	var sentences []*models.ExampleSentence
+	result := r.DB.Preload("Translation").Where("id IN ?", ids).Find(&sentences)
+	return sentences, result.Error
+}
+
+func (r *ExampleSentenceRepositoryImpl) ListExampleSentencesByWordIds(wordIDs []uuid.UUID) (map[uuid.UUID][]*models.ExampleSentence, error) {
+	var links []struct {
+		ExampleSentenceID uuid.UUID
+		WordID            uuid.UUID
+	}
+	if err := r.DB.Table("word_example").Where("word_id IN ?", wordIDs).Find(&links).Error; err != nil {
+		return nil, err
+	}
+	if len(links) == 0 {
+		return map[uuid.UUID][]*models.ExampleSentence{}, nil
+	}
+
+	sentenceIDs := make([]uuid.UUID, len(links))
+	for i, link := range links {
+		sentenceIDs[i] = link.ExampleSentenceID
+	}
+	var sentences []*models.ExampleSentence
+	if err := r.DB.Preload("Translation").Where("id IN ?", sentenceIDs).Find(&sentences).Error; err != nil {
+		return nil, err
+	}
+	sentencesMap := make(map[uuid.UUID]*models.ExampleSentence)
+	for _, sentence := range sentences {
+		sentencesMap[sentence.ID] = sentence
+	}
+
+	sentencesByWord := make(map[uuid.UUID][]*models.ExampleSentence)
+	for _, link := range links {
+		if sentence, exists := sentencesMap[link.ExampleSentenceID]; exists {
+			sentencesByWord[link.WordID] = append(sentencesByWord[link.WordID], sentence)
+		}
+	}
+	return sentencesByWord, nil
+}
+
+func (r *ExampleSentenceRepositoryImpl) ListExampleSentencesBySourceRefs(source string, refs []string) (map[string]*models.ExampleSentence, error) {
+	sentencesMap := make(map[string]*models.ExampleSentence)
+	for start := 0; start < len(refs); start += importBatchSize {
+		end := min(start+importBatchSize, len(refs))
+
+		var sentences []*models.ExampleSentence
+		if err := r.DB.Preload("Translation").
+			Where("source = ? AND source_ref IN ?", source, refs[start:end]).
+			Find(&sentences).Error; err != nil {
+			return nil, err
+		}
+		for _, sentence := range sentences {
+			sentencesMap[sentence.SourceRef] = sentence
+		}
+	}
+	return sentencesMap, nil
+}
+
+// ImportExampleSentences stores imported sentences in a single transaction
+// New sentences are linked to the words whose kanji they contain, the version of the updated translations is incremented
+func (r *ExampleSentenceRepositoryImpl) ImportExampleSentences(newSentences []*models.ExampleSentence, updatedTranslations []*models.Label) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		translationIDs := make([]uuid.UUID, len(updatedTranslations))
+		for i, translation := range updatedTranslations {
+			if err := tx.Save(translation).Error; err != nil {
+				return err
+			}
+			translationIDs[i] = translation.ID
+		}
+		if err := bumpVersions(tx, "labels", translationIDs); err != nil {
+			return err
+		}
+		if len(newSentences) == 0 {
+			return nil
+		}
+
+		if err := tx.CreateInBatches(newSentences, importBatchSize).Error; err != nil {
+			return err
+		}
+
+		sentenceIDs := make([]uuid.UUID, len(newSentences))
+		for i, sentence := range newSentences {
+			sentenceIDs[i] = sentence.ID
+		}
+		for start := 0; start < len(sentenceIDs); start += importBatchSize {
+			end := min(start+importBatchSize, len(sentenceIDs))
+			if err := tx.Exec("INSERT INTO word_example (example_sentence_id, word_id) "+
+				"SELECT s.id, w.id FROM example_sentences s JOIN words w ON w.kanji <> '' AND strpos(s.japanese, w.kanji) > 0 "+
+				"WHERE s.id IN ? ON CONFLICT DO NOTHING", sentenceIDs[start:end]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// replaceSentenceWords replaces the links of a sentence with the words listed in WordIDs
+// The links to the words in the trash are kept, they are not listed when reading the sentence
+func replaceSentenceWords(tx *gorm.DB, sentence *models.ExampleSentence) error {
+	if err := tx.Exec("DELETE FROM word_example WHERE example_sentence_id = ? AND word_id IN ("+liveWordIdsQuery+")",
+		sentence.ID).Error; err != nil {
+		return err
+	}
+	for _, wordID := range sentence.WordIDs {
+		if err := tx.Exec("INSERT INTO word_example (example_sentence_id, word_id) VALUES (?, ?) ON CONFLICT DO NOTHING",
+			sentence.ID, wordID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Size of the batches used when importing dictionaries
+const importBatchSize = 500
+
+type KanjiRepository interface {
+	ListKanji() ([]*models.Kanji, error)
+	ReadKanji(id uuid.UUID) (*models.Kanji, error)
+	ReadKanjiByCharacter(character string) (*models.Kanji, error)
+	ListKanjiByCharacters(characters []string) ([]*models.Kanji, error)
+	ListKanjiWordIds(id uuid.UUID) ([]uuid.UUID, error)
+	CreateKanji(kanji *models.Kanji) error
+	UpdateKanji(kanji *models.Kanji) error
+	DeleteKanji(id uuid.UUID) error
+	LinkWords(kanji *models.Kanji) error
+	LinkWordKanji(word *models.Word) error
+	UpsertKanjiDictionary(kanji []*models.Kanji) error
+	UpdateStrokePaths(character string, strokePaths []string) (bool, error)
+	LinkAllWords() error
+	UpdateComponents(components map[string][]string) (int, error)
+	ListKanjiByComponents(components []string) ([]*models.Kanji, error)
+	ListWordIdsByKanji(kanjiIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error)
+}
+
+type KanjiRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// Make sure that KanjiRepositoryImpl implements KanjiRepository
+var _ KanjiRepository = (*KanjiRepositoryImpl)(nil)
+
+func (r *KanjiRepositoryImpl) ListKanji() ([]*models.Kanji, error) {
+	var kanji []*models.Kanji
+	result := r.DB.Order("stroke_count, character").Find(&kanji)
+	return kanji, result.Error
+}
+
+func (r *KanjiRepositoryImpl) ReadKanji(id uuid.UUID) (*models.Kanji, error) {
+	var kanji models.Kanji
+	result := r.DB.First(&kanji, "id = ?", id)
+	return &kanji, result.Error
+}
+
+func (r *KanjiRepositoryImpl) ReadKanjiByCharacter(character string) (*models.Kanji, error) {
+	var kanji models.Kanji
+	result := r.DB.First(&kanji, "character = ?", character)
+	return &kanji, result.Error
+}
+
+func (r *KanjiRepositoryImpl) ListKanjiByCharacters(characters []string) ([]*models.Kanji, error) {
+	var kanji []*models.Kanji
+	result := r.DB.Where("character IN ?", characters).Find(&kanji)
+	return kanji, result.Error
+}
+
+func (r *KanjiRepositoryImpl) ListKanjiWordIds(id uuid.UUID) ([]uuid.UUID, error) {
+	var wordIDs []uuid.UUID
+	result := r.DB.Table("word_kanji").Where("kanji_id = ?", id).Pluck("word_id", &wordIDs)
+	return wordIDs, result.Error
+}
+
+func (r *KanjiRepositoryImpl) CreateKanji(kanji *models.Kanji) error {
+	return r.DB.Create(kanji).Error
+}
+
+func (r *KanjiRepositoryImpl) UpdateKanji(kanji *models.Kanji) error {
+	return r.DB.Save(kanji).Error
+}
+
+func (r *KanjiRepositoryImpl) DeleteKanji(id uuid.UUID) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM word_kanji WHERE kanji_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Kanji{}, "id = ?", id).Error
+	})
+}
+
+// LinkWords links the kanji to every word whose Kanji field contains its character
+// Previous links of the kanji are replaced
+func (r *KanjiRepositoryImpl) LinkWords(kanji *models.Kanji) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM word_kanji WHERE kanji_id = ?", kanji.ID).Error; err != nil {
+			return err
+		}
+		return tx.Exec("INSERT INTO word_kanji (kanji_id, word_id) SELECT ?, w.id FROM words w WHERE strpos(w.kanji, ?) > 0",
+			kanji.ID, kanji.Character).Error
+	})
+}
+
+// LinkWordKanji links a word to every known kanji contained in its Kanji field
+// Previous links of the word are replaced
+func (r *KanjiRepositoryImpl) LinkWordKanji(word *models.Word) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM word_kanji WHERE word_id = ?", word.ID).Error; err != nil {
+			return err
+		}
+		return tx.Exec("INSERT INTO word_kanji (kanji_id, word_id) SELECT k.id, ? FROM kanjis k WHERE strpos(?, k.character) > 0",
+			word.ID, word.Kanji).Error
+	})
+}
+
+// UpsertKanjiDictionary creates the kanji or updates their dictionary data when the character already exists
+// Components and stroke paths, coming from other sources, are left untouched
+func (r *KanjiRepositoryImpl) UpsertKanjiDictionary(kanji []*models.Kanji) error {
+	return r.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "character"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"stroke_count", "radical", "on_readings", "kun_readings",
+			"meanings_en", "meanings_fr", "jlpt", "grade", "frequency",
+		}),
+	}).CreateInBatches(kanji, importBatchSize).Error
+}
+
+// UpdateStrokePaths sets the stroke paths of a kanji, returning false if the character is unknown
+func (r *KanjiRepositoryImpl) UpdateStrokePaths(character string, strokePaths []string) (bool, error) {
+	result := r.DB.Model(&models.Kanji{}).
+		Where("character = ?", character).
+		Updates(&models.Kanji{StrokePaths: strokePaths})
+	return result.RowsAffected > 0, result.Error
+}
+
+// LinkAllWords links every kanji to the words containing its character, keeping existing links
+func (r *KanjiRepositoryImpl) LinkAllWords() error {
+	return r.DB.Exec("INSERT INTO word_kanji (kanji_id, word_id) " +
+		"SELECT k.id, w.id FROM kanjis k JOIN words w ON strpos(w.kanji, k.character) > 0 " +
+		"ON CONFLICT DO NOTHING").Error
+}
+
+// UpdateComponents sets the components of the known kanji, returning the number of kanji updated
+func (r *KanjiRepositoryImpl) UpdateComponents(components map[string][]string) (int, error) {
+	updated := 0
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		for character, kanjiComponents := range components {
+			result := tx.Model(&models.Kanji{}).
+				Where("character = ?", character).
+				Updates(&models.Kanji{Components: kanjiComponents})
+			if result.Error != nil {
+				return result.Error
+			}
+			updated += int(result.RowsAffected)
+		}
+		return nil
+	})
+	return updated, err
+}
+
+// ListKanjiByComponents lists the kanji containing all the given components, sorted by stroke count
+func (r *KanjiRepositoryImpl) ListKanjiByComponents(components []string) ([]*models.Kanji, error) {
+	jsonComponents, err := json.Marshal(components)
+	if err != nil {
+		return nil, err
+	}
+
+	var kanji []*models.Kanji
+	result := r.DB.
+		Where("components @> ?::jsonb", string(jsonComponents)).
+		Order("stroke_count, character").
+		Find(&kanji)
+	return kanji, result.Error
+}
+
+// ListWordIdsByKanji returns the IDs of the words linked to each of the given kanji
+func (r *KanjiRepositoryImpl) ListWordIdsByKanji(kanjiIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	var links []struct {
+		KanjiID uuid.UUID
+		WordID  uuid.UUID
+	}
+	if err := r.DB.Table("word_kanji").Where("kanji_id IN ?", kanjiIDs).Find(&links).Error; err != nil {
+		return nil, err
+	}
+
+	wordIDs := make(map[uuid.UUID][]uuid.UUID)
+	for _, link := range links {
+		wordIDs[link.KanjiID] = append(wordIDs[link.KanjiID], link.WordID)
+	}
+	return wordIDs, nil
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/xanagit/kotoquiz-api/models"
 	"gorm.io/gorm"
+	"time"
 )
 
 type LabelRepository interface {
@@ -12,6 +13,12 @@ type LabelRepository interface {
 	CreateLabel(word *models.Label) error
 	UpdateLabel(word *models.Label) error
 	DeleteLabel(id uuid.UUID) error
+	CountLabelUsages(ids []uuid.UUID) (map[uuid.UUID]int64, error)
+	ListCustomLevelLabelIDs(ids []uuid.UUID) ([]uuid.UUID, error)
+	MergeLabels(targetID uuid.UUID, sourceIDs []uuid.UUID) (int64, error)
+	ListTrashedLabels() ([]*models.Label, error)
+	RestoreLabel(id uuid.UUID) error
+	PurgeLabels(before time.Time) (int64, error)
 }
 
 type LabelRepositoryImpl struct {
@@ -21,9 +28,10 @@ type LabelRepositoryImpl struct {
 // Make sure that LabelRepositoryImpl implements LabelRepository
 var _ LabelRepository = (*LabelRepositoryImpl)(nil)
 
+// ListLabelsByType lists the labels of a type, ordered by position
 func (r *LabelRepositoryImpl) ListLabelsByType(labelType models.LabelType) ([]*models.Label, error) {
 	var labels []*models.Label
-	result := r.DB.Where("type = ?", labelType).Find(&labels)
+	result := r.DB.Where("type = ?", labelType).Order("position").Find(&labels)
 	return labels, result.Error
 }
 
@@ -37,13 +45,188 @@ func (r *LabelRepositoryImpl) CreateLabel(label *models.Label) error {
 	return r.DB.Create(label).Error
 }
 
+// UpdateLabel saves a label and increments its version
+// When label.Version is not 0 the label must still be at this version
 func (r *LabelRepositoryImpl) UpdateLabel(label *models.Label) error {
-	return r.DB.Save(label).Error
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := bumpVersion(tx, "labels", label.ID, &label.Version); err != nil {
+			return err
+		}
+		return tx.Save(label).Error
+	})
 }
 
+// DeleteLabel moves a label to the trash and increments its version
+// It keeps the words and the levels using it and its sub-tags until it is purged, the reads leaving it out meanwhile
 func (r *LabelRepositoryImpl) DeleteLabel(id uuid.UUID) error {
-	if err := r.DB.Where("id = ?", id).Delete(&models.Label{}).Error; err != nil {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Label{}).Where("id = ?", id).UpdateColumn("version", gorm.Expr("version + 1"))
+		if result.Error == nil && result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		return tx.Delete(&models.Label{}, "id = ?", id).Error
+	})
+}
+
+// detachLabel removes the references of the words and the levels to a purged label, and moves its sub-tags up to its parent
+// The version of each detached word, level and sub-tag is incremented
+func detachLabel(tx *gorm.DB, id uuid.UUID) error {
+	if err := tx.Exec("UPDATE labels SET parent_id = (SELECT parent_id FROM labels WHERE id = ?), version = version + 1 "+
+		"WHERE parent_id = ?", id, id).Error; err != nil {
 		return err
 	}
-	return nil
+	if err := tx.Exec("UPDATE words SET version = version + 1 WHERE id IN (SELECT word_id FROM word_tag WHERE label_id = ?)",
+		id).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM word_tag WHERE label_id = ?", id).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("UPDATE levels SET version = version + 1 "+
+		"WHERE category_id = ? OR id IN (SELECT level_id FROM level_values WHERE label_id = ?)", id, id).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM level_values WHERE label_id = ?", id).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Model(&models.Level{}).Where("category_id = ?", id).Update("category_id", nil).Error
+}
+
+// ListTrashedLabels lists the labels in the trash, the last deleted first
+func (r *LabelRepositoryImpl) ListTrashedLabels() ([]*models.Label, error) {
+	var labels []*models.Label
+	err := r.DB.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC, id").Find(&labels).Error
+	return labels, err
+}
+
+// RestoreLabel takes a label out of the trash with its links and its sub-tags, and increments its version
+func (r *LabelRepositoryImpl) RestoreLabel(id uuid.UUID) error {
+	result := r.DB.Exec("UPDATE labels SET deleted_at = NULL, version = version + 1 "+
+		"WHERE id = ? AND deleted_at IS NOT NULL", id)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
+
+// PurgeLabels permanently deletes the labels moved to the trash before the given time and returns their number
+// They are detached from the words and the levels using them, and their sub-tags move up to their parent
+func (r *LabelRepositoryImpl) PurgeLabels(before time.Time) (int64, error) {
+	var expiredIDs []uuid.UUID
+	if err := r.DB.Unscoped().Model(&models.Label{}).Where("deleted_at < ?", before).Pluck("id", &expiredIDs).Error; err != nil {
+		return 0, err
+	}
+	var purged int64
+	for _, id := range expiredIDs {
+		if err := r.DB.Transaction(func(tx *gorm.DB) error {
+			if err := detachLabel(tx, id); err != nil {
+				return err
+			}
+			return tx.Unscoped().Where("id = ?", id).Delete(&models.Label{}).Error
+		}); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// CountLabelUsages counts for each label the words tagged with it, the levels using it as category or as level name,
+// and the words, senses and example sentences using it as translation, unused labels being left out of the counts
+func (r *LabelRepositoryImpl) CountLabelUsages(ids []uuid.UUID) (map[uuid.UUID]int64, error) {
+	counts := make(map[uuid.UUID]int64, len(ids))
+	if len(ids) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		LabelID uuid.UUID
+		Usages  int64
+	}
+	err := r.DB.Raw("SELECT label_id, COUNT(*) AS usages FROM ("+
+		"SELECT label_id FROM word_tag WHERE label_id IN ? "+
+		"UNION ALL SELECT category_id FROM levels WHERE category_id IN ? "+
+		"UNION ALL SELECT label_id FROM level_values WHERE label_id IN ? "+
+		"UNION ALL SELECT translation_id FROM words WHERE translation_id IN ? "+
+		"UNION ALL SELECT label_id FROM sense_translation WHERE label_id IN ? "+
+		"UNION ALL SELECT translation_id FROM example_sentences WHERE translation_id IN ?"+
+		") AS usages GROUP BY label_id", ids, ids, ids, ids, ids, ids).Scan(&rows).Error
+	for _, row := range rows {
+		counts[row.LabelID] = row.Usages
+	}
+	return counts, err
+}
+
+// ListCustomLevelLabelIDs lists the given labels used by a custom level as category or as level name
+// Those labels belong to the users owning the levels, even when the levels are in the trash
+func (r *LabelRepositoryImpl) ListCustomLevelLabelIDs(ids []uuid.UUID) ([]uuid.UUID, error) {
+	labelIDs := []uuid.UUID{}
+	if len(ids) == 0 {
+		return labelIDs, nil
+	}
+	err := r.DB.Raw("SELECT category_id FROM levels WHERE type = ? AND category_id IN ? "+
+		"UNION SELECT lv.label_id FROM level_values lv JOIN levels l ON l.id = lv.level_id "+
+		"WHERE l.type = ? AND lv.label_id IN ?",
+		models.CustomLevel, ids, models.CustomLevel, ids).Scan(&labelIDs).Error
+	return labelIDs, err
+}
+
+// MergeLabels repoints every reference to the source labels to the target label, then deletes the source labels
+// A word, sense or level linked to both a source and the target keeps a single link.
+// The versions of the target and of the repointed words, levels and sub-tags are incremented
+// It returns the number of repointed references
+func (r *LabelRepositoryImpl) MergeLabels(targetID uuid.UUID, sourceIDs []uuid.UUID) (int64, error) {
+	var repointed int64
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		// The words and the levels using a source change with the merge
+		if err := tx.Exec("UPDATE words SET version = version + 1 WHERE translation_id IN ? "+
+			"OR id IN (SELECT word_id FROM word_tag WHERE label_id IN ?) "+
+			"OR id IN (SELECT ws.word_id FROM word_senses ws JOIN sense_translation st ON st.sense_id = ws.id WHERE st.label_id IN ?)",
+			sourceIDs, sourceIDs, sourceIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("UPDATE levels SET version = version + 1 WHERE category_id IN ? "+
+			"OR id IN (SELECT level_id FROM level_values WHERE label_id IN ?)", sourceIDs, sourceIDs).Error; err != nil {
+			return err
+		}
+		if err := bumpVersions(tx, "labels", []uuid.UUID{targetID}); err != nil {
+			return err
+		}
+
+		// Join tables: the links of the sources are copied to the target, skipping the existing ones, then removed
+		joinTables := map[string]string{"word_tag": "word_id", "level_values": "level_id", "sense_translation": "sense_id"}
+		for table, ownerColumn := range joinTables {
+			result := tx.Exec("INSERT INTO "+table+" ("+ownerColumn+", label_id) SELECT "+ownerColumn+", ? FROM "+table+
+				" WHERE label_id IN ? ON CONFLICT DO NOTHING", targetID, sourceIDs)
+			if result.Error != nil {
+				return result.Error
+			}
+			repointed += result.RowsAffected
+			if err := tx.Exec("DELETE FROM "+table+" WHERE label_id IN ?", sourceIDs).Error; err != nil {
+				return err
+			}
+		}
+
+		references := map[string]string{"levels": "category_id", "words": "translation_id", "example_sentences": "translation_id"}
+		for table, column := range references {
+			result := tx.Exec("UPDATE "+table+" SET "+column+" = ? WHERE "+column+" IN ?", targetID, sourceIDs)
+			if result.Error != nil {
+				return result.Error
+			}
+			repointed += result.RowsAffected
+		}
+
+		// The sub-tags of the sources move under the target
+		result := tx.Exec("UPDATE labels SET parent_id = ?, version = version + 1 WHERE parent_id IN ?", targetID, sourceIDs)
+		if result.Error != nil {
+			return result.Error
+		}
+		repointed += result.RowsAffected
+
+		// The merged labels are not kept in the trash, their references now belong to the target
+		return tx.Unscoped().Where("id IN ?", sourceIDs).Delete(&models.Label{}).Error
+	})
+	return repointed, err
 }
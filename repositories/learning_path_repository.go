@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/models"
+	"gorm.io/gorm"
+)
+
+type LearningPathRepository interface {
+	ListLearningPaths() ([]*models.LearningPath, error)
+	ReadLearningPath(id uuid.UUID) (*models.LearningPath, error)
+	CreateLearningPath(path *models.LearningPath) error
+	UpdateLearningPath(path *models.LearningPath) error
+	DeleteLearningPath(id uuid.UUID) error
+	CountLevelWords(userID string, levelIDs []uuid.UUID) (words map[uuid.UUID]int64, learned map[uuid.UUID]int64, err error)
+}
+
+type LearningPathRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// Make sure that LearningPathRepositoryImpl implements LearningPathRepository
+var _ LearningPathRepository = (*LearningPathRepositoryImpl)(nil)
+
+// ListLearningPaths lists the learning paths with their levels in order
+func (r *LearningPathRepositoryImpl) ListLearningPaths() ([]*models.LearningPath, error) {
+	var paths []*models.LearningPath
+	result := preloadSteps(r.DB).Order("en").Find(&paths)
+	return paths, result.Error
+}
+
+func (r *LearningPathRepositoryImpl) ReadLearningPath(id uuid.UUID) (*models.LearningPath, error) {
+	var path models.LearningPath
+	result := preloadSteps(r.DB).First(&path, "id = ?", id)
+	return &path, result.Error
+}
+
+func (r *LearningPathRepositoryImpl) CreateLearningPath(path *models.LearningPath) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Steps").Create(path).Error; err != nil {
+			return err
+		}
+		return replaceSteps(tx, path)
+	})
+}
+
+// UpdateLearningPath updates a learning path and replaces its steps
+func (r *LearningPathRepositoryImpl) UpdateLearningPath(path *models.LearningPath) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Steps").Save(path).Error; err != nil {
+			return err
+		}
+		return replaceSteps(tx, path)
+	})
+}
+
+// DeleteLearningPath deletes a learning path, its steps being deleted in cascade
+func (r *LearningPathRepositoryImpl) DeleteLearningPath(id uuid.UUID) error {
+	return r.DB.Where("id = ?", id).Delete(&models.LearningPath{}).Error
+}
+
+// CountLevelWords counts the words of each level, and among them the words the user reviews or masters
+// Words in the trash are not counted
+func (r *LearningPathRepositoryImpl) CountLevelWords(userID string, levelIDs []uuid.UUID) (map[uuid.UUID]int64, map[uuid.UUID]int64, error) {
+	var counts []struct {
+		LevelID uuid.UUID
+		Words   int64
+		Learned int64
+	}
+	if err := r.DB.Table("word_level wl").
+		Select("wl.level_id, COUNT(*) AS words, COUNT(h.word_id) AS learned").
+		Joins("LEFT JOIN word_learning_histories h ON h.word_id = wl.word_id AND h.user_id = ? AND h.learning_status IN ?",
+			userID, []models.WLStatus{models.Reviewing, models.Mastered}).
+		Where("wl.level_id IN ? AND wl.word_id IN ("+liveWordIdsQuery+")", levelIDs).
+		Group("wl.level_id").Find(&counts).Error; err != nil {
+		return nil, nil, err
+	}
+
+	words := make(map[uuid.UUID]int64, len(counts))
+	learned := make(map[uuid.UUID]int64, len(counts))
+	for _, count := range counts {
+		words[count.LevelID] = count.Words
+		learned[count.LevelID] = count.Learned
+	}
+	return words, learned, nil
+}
+
+// preloadSteps preloads the steps of learning paths in order, with their levels
+// The steps of the levels in the trash are left out
+func preloadSteps(db *gorm.DB) *gorm.DB {
+	return db.Preload("Steps", func(db *gorm.DB) *gorm.DB {
+		return orderByPosition(db).Where("level_id IN (SELECT id FROM levels WHERE deleted_at IS NULL)")
+	}).
+		Preload("Steps.Level").
+		Preload("Steps.Level.Category").
+		Preload("Steps.Level.LevelNames", levelNamesByPosition)
+}
+
+// replaceSteps replaces the steps of a learning path with the given ones
+func replaceSteps(tx *gorm.DB, path *models.LearningPath) error {
+	if err := tx.Where("path_id = ?", path.ID).Delete(&models.LearningPathStep{}).Error; err != nil {
+		return err
+	}
+	if len(path.Steps) == 0 {
+		return nil
+	}
+	for _, step := range path.Steps {
+		step.PathID = path.ID
+	}
+	return tx.Omit("Level").Create(&path.Steps).Error
+}
@@ -5,14 +5,21 @@ import (
 	"github.com/google/uuid"
 	"github.com/xanagit/kotoquiz-api/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
 )
 
 type LevelRepository interface {
-	ListLevels() ([]*models.Level, error)
+	ListLevels(userID string) ([]*models.Level, error)
 	ReadLevel(id uuid.UUID) (*models.Level, error)
 	CreateLevel(word *models.Level) error
 	UpdateLevel(word *models.Level) error
 	DeleteLevel(id uuid.UUID) error
+	AddLevelWords(id uuid.UUID, wordIDs []uuid.UUID) error
+	RemoveLevelWord(id uuid.UUID, wordID uuid.UUID) error
+	ListTrashedLevels() ([]*models.Level, error)
+	RestoreLevel(id uuid.UUID) error
+	PurgeLevels(before time.Time) (int64, error)
 }
 
 type LevelRepositoryImpl struct {
@@ -22,7 +29,13 @@ type LevelRepositoryImpl struct {
 // Make sure that LevelRepositoryImpl implements LevelRepository
 var _ LevelRepository = (*LevelRepositoryImpl)(nil)
 
-func (r *LevelRepositoryImpl) ListLevels() ([]*models.Level, error) {
+// levelNamesByPosition preloads the level names of a level in their natural order
+func levelNamesByPosition(db *gorm.DB) *gorm.DB {
+	return db.Order("labels.position, labels.en")
+}
+
+// ListLevels lists the built-in levels and the custom levels owned by the user
+func (r *LevelRepositoryImpl) ListLevels(userID string) ([]*models.Level, error) {
 	var labels []*models.Level
 
 	err := r.DB.Transaction(func(tx *gorm.DB) error {
@@ -33,7 +46,9 @@ func (r *LevelRepositoryImpl) ListLevels() ([]*models.Level, error) {
 			return err
 		}
 
-		return tx.Preload("LevelNames").Preload("Category").Find(&labels).Error
+		return tx.Preload("LevelNames", levelNamesByPosition).Preload("Category").
+			Where("type IS DISTINCT FROM ? OR owner_id = ?", models.CustomLevel, userID).
+			Find(&labels).Error
 	}, &sql.TxOptions{
 		Isolation: sql.LevelRepeatableRead,
 		ReadOnly:  true, // Optimization because we only do reading
@@ -42,48 +57,232 @@ func (r *LevelRepositoryImpl) ListLevels() ([]*models.Level, error) {
 	return labels, err
 }
 
+// ReadLevel fetches a level, with the IDs of its words when it is a custom level
 func (r *LevelRepositoryImpl) ReadLevel(id uuid.UUID) (*models.Level, error) {
 	var label models.Level
-	result := r.DB.Preload("LevelNames").Preload("Category").First(&label, "id = ?", id)
-	return &label, result.Error
+	if err := r.DB.Preload("LevelNames", levelNamesByPosition).Preload("Category").First(&label, "id = ?", id).Error; err != nil {
+		return &label, err
+	}
+	if !label.IsCustom() {
+		return &label, nil
+	}
+	err := r.DB.Table("word_level").Where("level_id = ? AND word_id IN ("+liveWordIdsQuery+")", id).
+		Order("word_id").Pluck("word_id", &label.WordIDs).Error
+	return &label, err
 }
 
 func (r *LevelRepositoryImpl) CreateLevel(label *models.Level) error {
 	return r.DB.Create(label).Error
 }
 
-func (r *LevelRepositoryImpl) UpdateLevel(label *models.Level) error {
-	return r.DB.Save(label).Error
+// UpdateLevel saves a level and increments its version, the level names missing from the level are unlinked from it
+// except the ones in the trash, which are left out when reading the level
+// The category and the level names are linked by ID, see saveLevelLabels for their texts
+// When level.Version is not 0 the level must still be at this version
+func (r *LevelRepositoryImpl) UpdateLevel(level *models.Level) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := bumpVersion(tx, "levels", level.ID, &level.Version); err != nil {
+			return err
+		}
+		if err := saveLevelLabels(tx, level); err != nil {
+			return err
+		}
+		level.CategoryID = level.Category.ID
+		if err := tx.Omit("Category", "LevelNames").Save(level).Error; err != nil {
+			return err
+		}
+
+		levelNameIDs := make([]uuid.UUID, len(level.LevelNames))
+		for i, levelName := range level.LevelNames {
+			levelNameIDs[i] = levelName.ID
+			if err := tx.Exec("INSERT INTO level_values (level_id, label_id) VALUES (?, ?) ON CONFLICT DO NOTHING",
+				level.ID, levelName.ID).Error; err != nil {
+				return err
+			}
+		}
+		const unlinkLevelNames = "DELETE FROM level_values WHERE level_id = ? AND label_id IN (SELECT id FROM labels WHERE deleted_at IS NULL)"
+		if len(levelNameIDs) == 0 {
+			return tx.Exec(unlinkLevelNames, level.ID).Error
+		}
+		return tx.Exec(unlinkLevelNames+" AND label_id NOT IN ?", level.ID, levelNameIDs).Error
+	})
+}
+
+// saveLevelLabels creates the new labels of a level being updated
+// The texts of the existing labels are only updated for a custom level, which owns its labels,
+// the labels of a built-in level can be shared and are changed through the label endpoints
+func saveLevelLabels(tx *gorm.DB, level *models.Level) error {
+	onConflict := clause.OnConflict{DoNothing: true}
+	if level.IsCustom() {
+		onConflict = clause.OnConflict{
+			Columns: []clause.Column{{Name: "id"}},
+			DoUpdates: append(clause.AssignmentColumns([]string{"en", "fr", "position"}),
+				clause.Assignment{Column: clause.Column{Name: "version"}, Value: gorm.Expr("labels.version + 1")}),
+		}
+	}
+	for _, label := range append([]*models.Label{&level.Category}, level.LevelNames...) {
+		if err := tx.Clauses(onConflict).Create(label).Error; err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
+// DeleteLevel moves a level to the trash and increments its version,
+// it keeps its words, its level names and its category until it is purged
 func (r *LevelRepositoryImpl) DeleteLevel(id uuid.UUID) error {
 	return r.DB.Transaction(func(tx *gorm.DB) error {
-		var level models.Level
-		// Charger le level avec ses associations
-		if err := tx.First(&level, "id = ?", id).Error; err != nil {
+		result := tx.Model(&models.Level{}).Where("id = ?", id).UpdateColumn("version", gorm.Expr("version + 1"))
+		if result.Error == nil && result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		return tx.Delete(&models.Level{}, "id = ?", id).Error
+	})
+}
+
+// ListTrashedLevels lists the levels in the trash with their category, the last deleted first
+func (r *LevelRepositoryImpl) ListTrashedLevels() ([]*models.Level, error) {
+	var levels []*models.Level
+	err := r.DB.Unscoped().Preload("Category", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("deleted_at IS NOT NULL").Order("deleted_at DESC, id").Find(&levels).Error
+	return levels, err
+}
+
+// RestoreLevel takes a level out of the trash and increments its version
+func (r *LevelRepositoryImpl) RestoreLevel(id uuid.UUID) error {
+	result := r.DB.Unscoped().Model(&models.Level{}).Where("id = ? AND deleted_at IS NOT NULL", id).
+		UpdateColumns(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")})
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
+
+// PurgeLevels permanently deletes the levels moved to the trash before the given time and returns their number
+func (r *LevelRepositoryImpl) PurgeLevels(before time.Time) (int64, error) {
+	var expiredIDs []uuid.UUID
+	if err := r.DB.Unscoped().Model(&models.Level{}).Where("deleted_at < ?", before).Pluck("id", &expiredIDs).Error; err != nil {
+		return 0, err
+	}
+	var purged int64
+	for _, id := range expiredIDs {
+		if err := r.DB.Transaction(func(tx *gorm.DB) error {
+			return purgeLevel(tx, id)
+		}); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// purgeLevel permanently deletes a level with its links, and the category and level names of a custom level, within a transaction
+// The steps of the learning paths, the ratings and the published words of the level are deleted in cascade
+func purgeLevel(tx *gorm.DB, id uuid.UUID) error {
+	var level models.Level
+	// Charger le level, qui est dans la corbeille
+	if err := tx.Unscoped().First(&level, "id = ?", id).Error; err != nil {
+		return err
+	}
+
+	// Sauvegarder l'ID de la catégory
+	categoryID := level.CategoryID
+
+	// 1. Mettre à null la référence à la catégory
+	if err := tx.Unscoped().Model(&level).Update("category_id", nil).Error; err != nil {
+		return err
+	}
+
+	// Détacher les copies du deck, qui gardent leurs mots
+	if err := tx.Unscoped().Model(&models.Level{}).Where("source_level_id = ?", id).
+		UpdateColumns(map[string]interface{}{"source_level_id": nil, "version": gorm.Expr("version + 1")}).Error; err != nil {
+		return err
+	}
+
+	// Sauvegarder les IDs des noms d'un level personnalisé, qui lui appartiennent
+	var levelNameIDs []uuid.UUID
+	if level.IsCustom() {
+		if err := tx.Raw("SELECT label_id FROM level_values WHERE level_id = ?", id).Scan(&levelNameIDs).Error; err != nil {
 			return err
 		}
+	}
 
-		// Sauvegarder l'ID de la catégory
-		categoryID := level.CategoryID
+	// 2. Supprimer les associations aux noms de level et aux mots, puis le level
+	if err := tx.Exec("DELETE FROM level_values WHERE level_id = ?", id).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM word_level WHERE level_id = ?", id).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Delete(&level).Error; err != nil {
+		return err
+	}
 
-		// 1. Mettre à null la référence à la catégory
-		if err := tx.Model(&level).Update("category_id", nil).Error; err != nil {
+	// 3. Maintenant nous pouvons supprimer la catégorie et les noms d'un level personnalisé, qui lui appartiennent
+	// Les labels des levels intégrés sont gérés à part et peuvent être partagés
+	if categoryID != uuid.Nil && level.IsCustom() {
+		if err := tx.Unscoped().Delete(&models.Label{}, "id = ?", categoryID).Error; err != nil {
+			return err
+		}
+	}
+	if len(levelNameIDs) > 0 {
+		if err := tx.Unscoped().Delete(&models.Label{}, "id IN ?", levelNameIDs).Error; err != nil {
 			return err
 		}
+	}
 
-		// 2. Supprimer le level (cela déclenchera BeforeDelete)
-		if err := tx.Delete(&level).Error; err != nil {
+	return nil
+}
+
+// AddLevelWords adds existing words to a level and increments its version,
+// the words already in the level and the repeated IDs are ignored
+func (r *LevelRepositoryImpl) AddLevelWords(id uuid.UUID, wordIDs []uuid.UUID) error {
+	wordIDs = distinctIds(wordIDs)
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Word{}).Where("id IN ?", wordIDs).Count(&count).Error; err != nil {
 			return err
 		}
+		if count != int64(len(wordIDs)) {
+			return gorm.ErrRecordNotFound
+		}
 
-		// 3. Maintenant nous pouvons supprimer la catégory
-		if categoryID != uuid.Nil {
-			if err := tx.Delete(&models.Label{}, "id = ?", categoryID).Error; err != nil {
+		for _, wordID := range wordIDs {
+			if err := tx.Exec("INSERT INTO word_level (word_id, level_id) VALUES (?, ?) ON CONFLICT DO NOTHING",
+				wordID, id).Error; err != nil {
 				return err
 			}
 		}
+		return bumpVersions(tx, "levels", []uuid.UUID{id})
+	})
+}
 
-		return nil
+// RemoveLevelWord removes a word from a level and increments its version, the word itself is kept
+func (r *LevelRepositoryImpl) RemoveLevelWord(id uuid.UUID, wordID uuid.UUID) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec("DELETE FROM word_level WHERE level_id = ? AND word_id = ?", id, wordID)
+		if result.Error == nil && result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		return bumpVersions(tx, "levels", []uuid.UUID{id})
 	})
 }
+
+// distinctIds removes the repeated IDs of a list, keeping the first occurrence of each one
+func distinctIds(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	distinct := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			distinct = append(distinct, id)
+		}
+	}
+	return distinct
+}
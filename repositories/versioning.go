@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrVersionMismatch is returned when a record was updated since the version an update is based on
+var ErrVersionMismatch = errors.New("the resource was modified since the given version")
+
+// bumpVersion increments the version of a record of the table, then sets the new version
+// The records in the trash are not found. When version is not 0 the record must still be at this version
+func bumpVersion(tx *gorm.DB, table string, id uuid.UUID, version *int) error {
+	var versions []int
+	if err := tx.Raw("UPDATE "+table+" SET version = version + 1 WHERE id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?) RETURNING version",
+		id, *version, *version).Scan(&versions).Error; err != nil {
+		return err
+	}
+	if len(versions) > 0 {
+		*version = versions[0]
+		return nil
+	}
+
+	// Tell a missing record from an outdated version
+	var count int64
+	if err := tx.Table(table).Where("id = ? AND deleted_at IS NULL", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return ErrVersionMismatch
+}
+
+// bumpVersions increments the version of the given records of the table, for the writes which are not based on a version
+func bumpVersions(tx *gorm.DB, table string, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return tx.Exec("UPDATE "+table+" SET version = version + 1 WHERE id IN ?", ids).Error
+}
@@ -12,6 +12,7 @@ type WordLearningHistoryRepository interface {
 	InsertHistories(histories []*models.WordLearningHistory) error
 	UpdateHistories(histories []*models.WordLearningHistory) error
 	GetHistoriesByWordIDs(userID uuid.UUID, wordIDs []string) ([]*models.WordLearningHistory, error)
+	GetUserHistories(userID uuid.UUID) ([]*models.WordLearningHistory, error)
 }
 
 type WordLearningHistoryRepositoryImpl struct {
@@ -103,3 +104,11 @@ func (r *WordLearningHistoryRepositoryImpl) GetHistoriesByWordIDs(userID uuid.UU
 	err := r.DB.Where("user_id = ? AND word_id IN ?", userID, wordIDs).Find(&histories).Error
 	return histories, err
 }
+
+// GetUserHistories returns the histories of every word learned by the user
+// The histories of the words in the trash are kept but not returned
+func (r *WordLearningHistoryRepositoryImpl) GetUserHistories(userID uuid.UUID) ([]*models.WordLearningHistory, error) {
+	var histories []*models.WordLearningHistory
+	err := r.DB.Where("user_id = ? AND word_id IN ("+liveWordIdsQuery+")", userID).Find(&histories).Error
+	return histories, err
+}
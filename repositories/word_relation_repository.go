@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/models"
+	"gorm.io/gorm"
+)
+
+type WordRelationRepository interface {
+	ListWordRelations(wordID uuid.UUID) ([]*models.WordRelation, error)
+	ListWordRelationsByWordIds(wordIDs []uuid.UUID) (map[uuid.UUID][]*models.WordRelation, error)
+	ReadWordRelation(id uuid.UUID) (*models.WordRelation, error)
+	FindWordRelation(wordID uuid.UUID, relatedWordID uuid.UUID, relationType models.WordRelationType) (*models.WordRelation, error)
+	CreateWordRelation(relation *models.WordRelation) error
+	UpdateWordRelation(relation *models.WordRelation) error
+	DeleteWordRelation(id uuid.UUID) error
+	RefreshWordHomophones(wordID uuid.UUID) error
+	DetectHomophones() (created int64, removed int64, err error)
+}
+
+type WordRelationRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// Make sure that WordRelationRepositoryImpl implements WordRelationRepository
+var _ WordRelationRepository = (*WordRelationRepositoryImpl)(nil)
+
+// homophonesQuery selects the pairs of words sharing their reading but written differently, the lowest ID first
+const homophonesQuery = "SELECT a.id, b.id, '" + string(models.Homophone) + "', true FROM words a " +
+	"JOIN words b ON b.yomi = a.yomi AND b.id > a.id AND b.kanji <> a.kanji " +
+	"WHERE a.yomi <> '' AND a.kanji <> '' AND b.kanji <> '' AND a.deleted_at IS NULL AND b.deleted_at IS NULL"
+
+// ListWordRelations lists the relations of a word, whichever side of the relation the word is stored on
+func (r *WordRelationRepositoryImpl) ListWordRelations(wordID uuid.UUID) ([]*models.WordRelation, error) {
+	var relations []*models.WordRelation
+	result := r.DB.Where("word_id = ? OR related_word_id = ?", wordID, wordID).Order("type").Find(&relations)
+	return relations, result.Error
+}
+
+// ListWordRelationsByWordIds fetches the relations of several words at once, with both related words
+// Each relation is listed under every requested word it involves, the relations to words in the trash being left out
+func (r *WordRelationRepositoryImpl) ListWordRelationsByWordIds(wordIDs []uuid.UUID) (map[uuid.UUID][]*models.WordRelation, error) {
+	var relations []*models.WordRelation
+	if err := r.DB.Preload("Word.Translation").Preload("RelatedWord.Translation").
+		Where("word_id IN ? OR related_word_id IN ?", wordIDs, wordIDs).
+		Where("word_id IN (" + liveWordIdsQuery + ") AND related_word_id IN (" + liveWordIdsQuery + ")").
+		Order("type").Find(&relations).Error; err != nil {
+		return nil, err
+	}
+
+	requested := make(map[uuid.UUID]bool, len(wordIDs))
+	for _, wordID := range wordIDs {
+		requested[wordID] = true
+	}
+	relationsByWord := make(map[uuid.UUID][]*models.WordRelation)
+	for _, relation := range relations {
+		if requested[relation.WordID] {
+			relationsByWord[relation.WordID] = append(relationsByWord[relation.WordID], relation)
+		}
+		if requested[relation.RelatedWordID] {
+			relationsByWord[relation.RelatedWordID] = append(relationsByWord[relation.RelatedWordID], relation)
+		}
+	}
+	return relationsByWord, nil
+}
+
+func (r *WordRelationRepositoryImpl) ReadWordRelation(id uuid.UUID) (*models.WordRelation, error) {
+	var relation models.WordRelation
+	err := r.DB.First(&relation, "id = ?", id).Error
+	return &relation, err
+}
+
+// FindWordRelation looks for a relation of the given type between two words, stored in the given order
+func (r *WordRelationRepositoryImpl) FindWordRelation(wordID uuid.UUID, relatedWordID uuid.UUID, relationType models.WordRelationType) (*models.WordRelation, error) {
+	var relation models.WordRelation
+	err := r.DB.First(&relation, "word_id = ? AND related_word_id = ? AND type = ?", wordID, relatedWordID, relationType).Error
+	return &relation, err
+}
+
+func (r *WordRelationRepositoryImpl) CreateWordRelation(relation *models.WordRelation) error {
+	return r.DB.Omit("Word", "RelatedWord").Create(relation).Error
+}
+
+func (r *WordRelationRepositoryImpl) UpdateWordRelation(relation *models.WordRelation) error {
+	return r.DB.Omit("Word", "RelatedWord").Save(relation).Error
+}
+
+func (r *WordRelationRepositoryImpl) DeleteWordRelation(id uuid.UUID) error {
+	result := r.DB.Delete(&models.WordRelation{}, "id = ?", id)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
+
+// RefreshWordHomophones replaces the homophones detected for a word by the words currently sharing its reading
+// Homophones linked by hand are kept
+func (r *WordRelationRepositoryImpl) RefreshWordHomophones(wordID uuid.UUID) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM word_relations WHERE automatic AND type = ? AND (word_id = ? OR related_word_id = ?)",
+			models.Homophone, wordID, wordID).Error; err != nil {
+			return err
+		}
+		return tx.Exec("INSERT INTO word_relations (word_id, related_word_id, type, automatic) "+
+			homophonesQuery+" AND (a.id = ? OR b.id = ?) ON CONFLICT DO NOTHING", wordID, wordID).Error
+	})
+}
+
+// DetectHomophones links all the words sharing their reading, and removes the detected homophones
+// whose readings no longer match
+func (r *WordRelationRepositoryImpl) DetectHomophones() (created int64, removed int64, err error) {
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec("DELETE FROM word_relations r USING words a, words b "+
+			"WHERE r.automatic AND r.type = ? AND a.id = r.word_id AND b.id = r.related_word_id "+
+			"AND (a.yomi <> b.yomi OR a.yomi = '' OR a.kanji = b.kanji OR a.kanji = '' OR b.kanji = '')", models.Homophone)
+		if result.Error != nil {
+			return result.Error
+		}
+		removed = result.RowsAffected
+
+		result = tx.Exec("INSERT INTO word_relations (word_id, related_word_id, type, automatic) " +
+			homophonesQuery + " ON CONFLICT DO NOTHING")
+		if result.Error != nil {
+			return result.Error
+		}
+		created = result.RowsAffected
+		return nil
+	})
+	return created, removed, err
+}
@@ -5,20 +5,37 @@ package repositories
 
 import (
 	"database/sql"
+	"errors"
 	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/dto"
 	"github.com/xanagit/kotoquiz-api/models"
 	"gorm.io/gorm"
+	"time"
 )
 
 // WordRepository defines the interface for word-related database operations
 // It provides methods to perform CRUD operations on Word models
 type WordRepository interface {
-	ListWordsIds(tagIds []string, levelNameIds []string, nb int) ([]string, error)
+	ListWordsIds(filter *dto.WordFilter, nb int) ([]string, error)
+	ListWordsIdsPage(filter *dto.WordFilter, limit int, offset int) ([]string, int64, error)
 	ListWordsByIds(ids []uuid.UUID) ([]*models.Word, error)
 	ReadWord(id uuid.UUID) (*models.Word, error)
 	CreateWord(word *models.Word) error
 	UpdateWord(word *models.Word) error
+	UpdateWordFurigana(id uuid.UUID, furigana []models.FuriganaSegment, override bool) error
+	UpdateWordMedia(id uuid.UUID, audioKey string, imageKey string) error
+	CountWordsByImageKey(imageKey string) (int64, error)
+	ListWordsByWrittenForms(forms []string) ([]*models.Word, error)
+	ReplacePitchAccents(accentsByWord map[uuid.UUID][]*models.WordPitchAccent) error
+	UpdateJlptLevels(levels map[uuid.UUID]models.JlptLevel) error
+	UpdateFrequencyRanks(ranks map[uuid.UUID]int) error
 	DeleteWord(id uuid.UUID) error
+	ListExistingWordIds(ids []uuid.UUID) ([]uuid.UUID, error)
+	BulkUpdateWords(ids []uuid.UUID, request *dto.WordBulkRequest) ([]uuid.UUID, error)
+	DeleteWords(ids []uuid.UUID) error
+	ListTrashedWords() ([]*models.Word, error)
+	RestoreWord(id uuid.UUID) error
+	PurgeWords(before time.Time) ([]*models.Word, error)
 }
 
 type WordRepositoryImpl struct {
@@ -28,7 +45,25 @@ type WordRepositoryImpl struct {
 // Make sure that WordRepositoryImpl implements WordRepository
 var _ WordRepository = (*WordRepositoryImpl)(nil)
 
-func (r *WordRepositoryImpl) ListWordsIds(tagIds []string, levelNameIds []string, nb int) ([]string, error) {
+// wordSortOrders gives the ORDER BY clause of each sort of the words
+// Words without the sorting metadata (0) come last, the ID makes the order stable for pagination
+var wordSortOrders = map[dto.WordSort]string{
+	dto.SortByFrequency: "w.frequency_rank = 0, w.frequency_rank, w.jlpt_level = 0, w.jlpt_level DESC, w.id",
+	dto.SortByJlpt:      "w.jlpt_level = 0, w.jlpt_level DESC, w.frequency_rank = 0, w.frequency_rank, w.id",
+}
+
+// tagDescendantsQuery selects the given tags and their descendants, UNION stopping on cycles
+// The tags in the trash are walked through, so that their sub-tags stay under their ancestors
+const tagDescendantsQuery = "WITH RECURSIVE descendants AS (SELECT id FROM labels WHERE id IN ? " +
+	"UNION SELECT l.id FROM labels l JOIN descendants d ON l.parent_id = d.id) SELECT id FROM descendants"
+
+// liveWordIdsQuery selects the IDs of the words which are not in the trash, for the queries on the join tables
+const liveWordIdsQuery = "SELECT id FROM words WHERE deleted_at IS NULL"
+
+// defaultCatalogueOrder lists the words of the catalogue in the order of their reading
+const defaultCatalogueOrder = "w.yomi, w.kanji, w.id"
+
+func (r *WordRepositoryImpl) ListWordsIds(filter *dto.WordFilter, nb int) ([]string, error) {
 	var wordIDs []string
 
 	err := r.DB.Transaction(func(tx *gorm.DB) error {
@@ -39,20 +74,13 @@ func (r *WordRepositoryImpl) ListWordsIds(tagIds []string, levelNameIds []string
 			return err
 		}
 
-		query := tx.Table("words w").
-			Select("DISTINCT w.id")
-		if len(tagIds) > 0 {
-			query.
-				Joins("JOIN word_tag wt ON wt.word_id = w.id").
-				Joins("JOIN labels t ON t.id = wt.label_id").
-				Where("t.id IN ?", tagIds)
-		}
-		if len(levelNameIds) > 0 {
-			query.
-				Joins("JOIN word_level wl ON wl.word_id = w.id").
-				Joins("JOIN levels l ON l.id = wl.level_id").
-				Joins("JOIN level_values lv ON lv.level_id = l.id").
-				Where("lv.label_id IN ?", levelNameIds)
+		query := filterWords(tx, filter)
+		if order, exists := wordSortOrders[filter.Sort]; exists {
+			// DISTINCT prevents ordering on columns which are not selected, the filter becomes a subquery
+			query = tx.Table("words w").
+				Select("w.id").
+				Where("w.id IN (?)", query).
+				Order(order)
 		}
 		if nb > 0 {
 			query.Limit(nb)
@@ -66,59 +94,500 @@ func (r *WordRepositoryImpl) ListWordsIds(tagIds []string, levelNameIds []string
 	return wordIDs, err
 }
 
+// ListWordsIdsPage returns a page of the IDs of the words matching the filter, and the total number of matching words
+// Words are sorted as requested by the filter, by reading otherwise
+func (r *WordRepositoryImpl) ListWordsIdsPage(filter *dto.WordFilter, limit int, offset int) ([]string, int64, error) {
+	var wordIDs []string
+	var total int64
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		// Count and page on the same snapshot
+		err := tx.Exec("SET TRANSACTION ISOLATION LEVEL REPEATABLE READ").Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Table("(?) AS f", filterWords(tx, filter)).Count(&total).Error; err != nil {
+			return err
+		}
+
+		order, exists := wordSortOrders[filter.Sort]
+		if !exists {
+			order = defaultCatalogueOrder
+		}
+		return tx.Table("words w").
+			Select("w.id").
+			Where("w.id IN (?)", filterWords(tx, filter)).
+			Order(order).
+			Limit(limit).
+			Offset(offset).
+			Scan(&wordIDs).Error
+	}, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+	})
+
+	return wordIDs, total, err
+}
+
+// filterWords builds the query selecting the distinct IDs of the words matching the filter
+func filterWords(tx *gorm.DB, filter *dto.WordFilter) *gorm.DB {
+	query := tx.Table("words w").
+		Select("DISTINCT w.id").
+		Where("w.deleted_at IS NULL")
+	if len(filter.TagIds) > 0 {
+		query.
+			Joins("JOIN word_tag wt ON wt.word_id = w.id").
+			Joins("JOIN labels t ON t.id = wt.label_id AND t.deleted_at IS NULL")
+		if filter.IncludeChildren {
+			query.Where("t.id IN ("+tagDescendantsQuery+")", filter.TagIds)
+		} else {
+			query.Where("t.id IN ?", filter.TagIds)
+		}
+	}
+	if len(filter.LevelIds) > 0 {
+		levelQuery := tx.Table("word_level wli").
+			Select("1").
+			Joins("JOIN levels li ON li.id = wli.level_id").
+			Where("wli.word_id = w.id AND wli.level_id IN ? AND li.deleted_at IS NULL", filter.LevelIds)
+		if filter.UserID != "" {
+			levelQuery.Where("(li.type IS DISTINCT FROM ? OR li.owner_id = ? OR li.visibility IN ?)",
+				models.CustomLevel, filter.UserID, []models.LevelVisibility{models.Shared, models.Public})
+		}
+		query.Where("EXISTS (?)", levelQuery)
+	}
+	if len(filter.LevelNameIds) > 0 {
+		query.
+			Joins("JOIN word_level wl ON wl.word_id = w.id").
+			Joins("JOIN levels l ON l.id = wl.level_id AND l.deleted_at IS NULL").
+			Joins("JOIN level_values lv ON lv.level_id = l.id").
+			Joins("JOIN labels ln ON ln.id = lv.label_id AND ln.deleted_at IS NULL").
+			Where("lv.label_id IN ?", filter.LevelNameIds)
+	}
+	if len(filter.ReadingTypes) > 0 {
+		query.Where("(w.yomi_type IN ? OR EXISTS (SELECT 1 FROM word_readings wr WHERE wr.word_id = w.id AND wr.type IN ?))",
+			filter.ReadingTypes, filter.ReadingTypes)
+	}
+	if filter.Reading != "" {
+		query.Where("(w.yomi = ? OR EXISTS (SELECT 1 FROM word_readings wr WHERE wr.word_id = w.id AND wr.reading = ?))",
+			filter.Reading, filter.Reading)
+	}
+	if len(filter.PartsOfSpeech) > 0 {
+		query.Where("w.part_of_speech IN ?", filter.PartsOfSpeech)
+	}
+	if filter.Transitivity != "" {
+		query.Where("w.transitivity = ?", filter.Transitivity)
+	}
+	if len(filter.JlptLevels) > 0 {
+		query.Where("w.jlpt_level IN ?", filter.JlptLevels)
+	}
+	if filter.MaxFrequencyRank > 0 {
+		query.Where("w.frequency_rank BETWEEN 1 AND ?", filter.MaxFrequencyRank)
+	}
+	return query
+}
+
 func (r *WordRepositoryImpl) ListWordsByIds(ids []uuid.UUID) ([]*models.Word, error) {
 	var words []*models.Word
 	result := r.DB.
 		Preload("Tags").
+		Preload("Levels", builtInLevels).
 		Preload("Levels.Category").
-		Preload("Levels.LevelNames").
+		Preload("Levels.LevelNames", levelNamesByPosition).
 		Preload("Translation").
+		Preload("Senses", orderByPosition).
+		Preload("Senses.Translations").
+		Preload("Readings", primaryReadingFirst).
+		Preload("PitchAccents", orderByPosition).
 		Where("id IN ?", ids).Find(&words)
 	return words, result.Error
 }
 
 func (r *WordRepositoryImpl) ReadWord(id uuid.UUID) (*models.Word, error) {
 	var word models.Word
-	result := r.DB.Preload("Translation").Preload("Tags").Preload("Levels").Preload("Levels.Category").Preload("Levels.LevelNames").First(&word, "id = ?", id)
+	result := r.DB.Preload("Translation").Preload("Tags").Preload("Levels", builtInLevels).Preload("Levels.Category").Preload("Levels.LevelNames", levelNamesByPosition).
+		Preload("Senses", orderByPosition).Preload("Senses.Translations").Preload("Readings", primaryReadingFirst).
+		Preload("PitchAccents", orderByPosition).
+		First(&word, "id = ?", id)
 	return &word, result.Error
 }
 
+// CreateWord creates a word with its senses, readings and pitch accents, then links it to its tags and levels
+// The translation and the tags without ID are created, the other ones are only referenced
 func (r *WordRepositoryImpl) CreateWord(word *models.Word) error {
-	return r.DB.Create(word).Error
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Tags", "Levels").Create(word).Error; err != nil {
+			return err
+		}
+		return replaceWordLinks(tx, word)
+	})
 }
 
+// UpdateWord saves the word, except its media keys which are managed by UpdateWordMedia
+// Its senses, readings and pitch accents are replaced, as well as its links to tags and built-in levels.
+// The translations the word no longer uses are deleted, and the version of the word is incremented
+// When word.Version is not 0 the word must still be at this version
 func (r *WordRepositoryImpl) UpdateWord(word *models.Word) error {
-	return r.DB.Save(word).Error
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := bumpVersion(tx, "words", word.ID, &word.Version); err != nil {
+			return err
+		}
+
+		var previousTranslationIDs []uuid.UUID
+		if err := tx.Raw("SELECT translation_id FROM words WHERE id = ? AND translation_id IS NOT NULL "+
+			"UNION SELECT st.label_id FROM sense_translation st JOIN word_senses ws ON ws.id = st.sense_id WHERE ws.word_id = ?",
+			word.ID, word.ID).Scan(&previousTranslationIDs).Error; err != nil {
+			return err
+		}
+
+		for _, child := range []any{&models.WordSense{}, &models.WordReading{}, &models.WordPitchAccent{}} {
+			if err := tx.Where("word_id = ?", word.ID).Delete(child).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Omit("audio_key", "image_key", "Tags", "Levels").Save(word).Error; err != nil {
+			return err
+		}
+		if err := replaceWordLinks(tx, word); err != nil {
+			return err
+		}
+		return deleteUnusedTranslations(tx, previousTranslationIDs)
+	})
 }
 
-func (r *WordRepositoryImpl) DeleteWord(id uuid.UUID) error {
-	return r.DB.Transaction(func(tx *gorm.DB) error {
-		var word models.Word
-		// Charger le word avec ses associations
-		if err := tx.First(&word, "id = ?", id).Error; err != nil {
+// replaceWordLinks creates the tags of a word which have no ID yet, then replaces its links to tags and built-in levels
+// The links to custom levels belong to the owners of the levels and are kept, as well as the links to the tags in the trash
+func replaceWordLinks(tx *gorm.DB, word *models.Word) error {
+	for _, tag := range word.Tags {
+		if tag.ID != uuid.Nil {
+			continue
+		}
+		if err := tx.Create(tag).Error; err != nil {
 			return err
 		}
+	}
 
-		// Sauvegarder l'ID de la traduction
-		translationID := word.TranslationID
+	if err := tx.Exec("DELETE FROM word_tag WHERE word_id = ? AND label_id IN (SELECT id FROM labels WHERE deleted_at IS NULL)",
+		word.ID).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM word_level WHERE word_id = ? AND level_id IN (SELECT id FROM levels WHERE type IS DISTINCT FROM ?)",
+		word.ID, models.CustomLevel).Error; err != nil {
+		return err
+	}
+	for _, tag := range word.Tags {
+		if err := tx.Exec("INSERT INTO word_tag (word_id, label_id) VALUES (?, ?) ON CONFLICT DO NOTHING",
+			word.ID, tag.ID).Error; err != nil {
+			return err
+		}
+	}
+	for _, level := range word.Levels {
+		if err := tx.Exec("INSERT INTO word_level (word_id, level_id) VALUES (?, ?) ON CONFLICT DO NOTHING",
+			word.ID, level.ID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		// 1. Mettre à null la référence à la traduction
-		if err := tx.Model(&word).Update("translation_id", nil).Error; err != nil {
+// UpdateWordFurigana only updates the furigana of a word, telling whether they were set by hand,
+// and increments its version
+func (r *WordRepositoryImpl) UpdateWordFurigana(id uuid.UUID, furigana []models.FuriganaSegment, override bool) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := bumpVersions(tx, "words", []uuid.UUID{id}); err != nil {
 			return err
 		}
+		return tx.Model(&models.Word{ID: id}).Select("furigana", "furigana_override").
+			Updates(&models.Word{Furigana: furigana, FuriganaOverride: override}).Error
+	})
+}
 
-		// 2. Supprimer le word (cela déclenchera BeforeDelete)
-		if err := tx.Delete(&word).Error; err != nil {
+// UpdateWordMedia only updates the keys of the media uploaded for a word, and increments its version
+func (r *WordRepositoryImpl) UpdateWordMedia(id uuid.UUID, audioKey string, imageKey string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := bumpVersions(tx, "words", []uuid.UUID{id}); err != nil {
 			return err
 		}
+		return tx.Model(&models.Word{ID: id}).Select("audio_key", "image_key").
+			Updates(&models.Word{AudioKey: audioKey, ImageKey: imageKey}).Error
+	})
+}
 
-		// 3. Maintenant nous pouvons supprimer la traduction
-		if translationID != uuid.Nil {
-			if err := tx.Delete(&models.Label{}, "id = ?", translationID).Error; err != nil {
+// CountWordsByImageKey counts the words sharing an uploaded image
+func (r *WordRepositoryImpl) CountWordsByImageKey(imageKey string) (int64, error) {
+	var count int64
+	// Words in the trash keep their image until they are purged
+	err := r.DB.Unscoped().Model(&models.Word{}).Where("image_key = ?", imageKey).Count(&count).Error
+	return count, err
+}
+
+// ListWordsByWrittenForms fetches the words, without associations, written with one of the forms in kanji or in kana
+func (r *WordRepositoryImpl) ListWordsByWrittenForms(forms []string) ([]*models.Word, error) {
+	var words []*models.Word
+	for start := 0; start < len(forms); start += importBatchSize {
+		end := min(start+importBatchSize, len(forms))
+
+		var batch []*models.Word
+		if err := r.DB.Where("kanji IN ? OR yomi IN ?", forms[start:end], forms[start:end]).Find(&batch).Error; err != nil {
+			return nil, err
+		}
+		words = append(words, batch...)
+	}
+	return words, nil
+}
+
+// ReplacePitchAccents replaces the pitch accents of the given words in a single transaction, incrementing their version
+func (r *WordRepositoryImpl) ReplacePitchAccents(accentsByWord map[uuid.UUID][]*models.WordPitchAccent) error {
+	wordIDs := make([]uuid.UUID, 0, len(accentsByWord))
+	var accents []*models.WordPitchAccent
+	for wordID, wordAccents := range accentsByWord {
+		wordIDs = append(wordIDs, wordID)
+		for _, accent := range wordAccents {
+			accent.WordID = wordID
+			accents = append(accents, accent)
+		}
+	}
+	if len(wordIDs) == 0 {
+		return nil
+	}
+
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(wordIDs); start += importBatchSize {
+			end := min(start+importBatchSize, len(wordIDs))
+			if err := tx.Where("word_id IN ?", wordIDs[start:end]).Delete(&models.WordPitchAccent{}).Error; err != nil {
+				return err
+			}
+			if err := bumpVersions(tx, "words", wordIDs[start:end]); err != nil {
 				return err
 			}
 		}
+		return tx.CreateInBatches(accents, importBatchSize).Error
+	})
+}
+
+// UpdateJlptLevels sets the JLPT level of the given words in a single transaction
+func (r *WordRepositoryImpl) UpdateJlptLevels(levels map[uuid.UUID]models.JlptLevel) error {
+	values := make(map[uuid.UUID]int, len(levels))
+	for wordID, level := range levels {
+		values[wordID] = int(level)
+	}
+	return r.updateWordsColumn("jlpt_level", values)
+}
+
+// UpdateFrequencyRanks sets the frequency rank of the given words in a single transaction
+func (r *WordRepositoryImpl) UpdateFrequencyRanks(ranks map[uuid.UUID]int) error {
+	return r.updateWordsColumn("frequency_rank", ranks)
+}
+
+// updateWordsColumn sets an integer column of the given words and increments their version,
+// words sharing the same value being updated together
+func (r *WordRepositoryImpl) updateWordsColumn(column string, values map[uuid.UUID]int) error {
+	wordIDsByValue := make(map[int][]uuid.UUID)
+	for wordID, value := range values {
+		wordIDsByValue[value] = append(wordIDsByValue[value], wordID)
+	}
+
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		for value, wordIDs := range wordIDsByValue {
+			for start := 0; start < len(wordIDs); start += importBatchSize {
+				end := min(start+importBatchSize, len(wordIDs))
+				if err := tx.Model(&models.Word{}).Where("id IN ?", wordIDs[start:end]).
+					UpdateColumns(map[string]interface{}{column: value, "version": gorm.Expr("version + 1")}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteWord moves a word to the trash and increments its version, it keeps its links and its translations until it is purged
+func (r *WordRepositoryImpl) DeleteWord(id uuid.UUID) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Word{}).Where("id = ?", id).UpdateColumn("version", gorm.Expr("version + 1"))
+		if result.Error == nil && result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		return tx.Delete(&models.Word{}, "id = ?", id).Error
+	})
+}
+
+// ListTrashedWords lists the words in the trash, without associations, the last deleted first
+func (r *WordRepositoryImpl) ListTrashedWords() ([]*models.Word, error) {
+	var words []*models.Word
+	err := r.DB.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC, id").Find(&words).Error
+	return words, err
+}
+
+// RestoreWord takes a word out of the trash and increments its version
+func (r *WordRepositoryImpl) RestoreWord(id uuid.UUID) error {
+	result := r.DB.Unscoped().Model(&models.Word{}).Where("id = ? AND deleted_at IS NOT NULL", id).
+		UpdateColumns(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")})
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
+
+// PurgeWords permanently deletes the words moved to the trash before the given time
+// Each word is purged in its own transaction, the purged words are returned so that their media can be removed
+func (r *WordRepositoryImpl) PurgeWords(before time.Time) ([]*models.Word, error) {
+	var expired []*models.Word
+	if err := r.DB.Unscoped().Where("deleted_at < ?", before).Find(&expired).Error; err != nil {
+		return nil, err
+	}
+	purged := make([]*models.Word, 0, len(expired))
+	for _, word := range expired {
+		if err := r.DB.Transaction(func(tx *gorm.DB) error {
+			return purgeWord(tx, word.ID)
+		}); err != nil {
+			return purged, err
+		}
+		purged = append(purged, word)
+	}
+	return purged, nil
+}
+
+// purgeWord permanently deletes a word with its links, the learning histories of the users
+// and the translations no other record uses, within a transaction
+func purgeWord(tx *gorm.DB, id uuid.UUID) error {
+	var word models.Word
+	// Charger le word, qui est dans la corbeille
+	if err := tx.Unscoped().First(&word, "id = ?", id).Error; err != nil {
+		return err
+	}
+
+	// Sauvegarder les IDs de la traduction et des traductions des sens (les sens sont supprimés en cascade)
+	var translationIDs []uuid.UUID
+	if err := tx.Raw("SELECT translation_id FROM words WHERE id = ? AND translation_id IS NOT NULL "+
+		"UNION SELECT st.label_id FROM sense_translation st JOIN word_senses ws ON ws.id = st.sense_id WHERE ws.word_id = ?",
+		id, id).Scan(&translationIDs).Error; err != nil {
+		return err
+	}
+
+	// 1. Supprimer les liens vers les tags, les levels, les kanji et les phrases d'exemple
+	for _, table := range []string{"word_tag", "word_level", "word_kanji", "word_example"} {
+		if err := tx.Exec("DELETE FROM "+table+" WHERE word_id = ?", id).Error; err != nil {
+			return err
+		}
+	}
+
+	// 2. Supprimer les historiques d'apprentissage des utilisateurs
+	if err := tx.Where("word_id = ?", id).Delete(&models.WordLearningHistory{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("word_id = ?", id).Delete(&models.ConjugationLearningHistory{}).Error; err != nil {
+		return err
+	}
 
+	// 3. Supprimer définitivement le word
+	if err := tx.Unscoped().Delete(&word).Error; err != nil {
+		return err
+	}
+
+	// 4. Puis les traductions qui ne sont plus utilisées
+	return deleteUnusedTranslations(tx, translationIDs)
+}
+
+// deleteUnusedTranslations deletes the given translation labels which no word, sense or example sentence uses
+// Words in the trash still use their translations
+func deleteUnusedTranslations(tx *gorm.DB, ids []uuid.UUID) error {
+	if len(ids) == 0 {
 		return nil
+	}
+	return tx.Exec("DELETE FROM labels l WHERE l.id IN ? "+
+		"AND NOT EXISTS (SELECT 1 FROM words w WHERE w.translation_id = l.id) "+
+		"AND NOT EXISTS (SELECT 1 FROM sense_translation st WHERE st.label_id = l.id) "+
+		"AND NOT EXISTS (SELECT 1 FROM example_sentences e WHERE e.translation_id = l.id)", ids).Error
+}
+
+// ListExistingWordIds keeps the IDs of the given words which exist
+func (r *WordRepositoryImpl) ListExistingWordIds(ids []uuid.UUID) ([]uuid.UUID, error) {
+	var existing []uuid.UUID
+	err := r.DB.Model(&models.Word{}).Where("id IN ?", ids).Pluck("id", &existing).Error
+	return existing, err
+}
+
+// BulkUpdateWords applies a bulk operation other than the deletion to the given words within a single transaction
+// It returns the IDs of the words actually changed, whose version is incremented
+func (r *WordRepositoryImpl) BulkUpdateWords(ids []uuid.UUID, request *dto.WordBulkRequest) ([]uuid.UUID, error) {
+	var changed []uuid.UUID
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		changed = nil
+		var err error
+		switch request.Operation {
+		case dto.AddTags:
+			changed, err = returningWordIds(tx, "INSERT INTO word_tag (word_id, label_id) "+
+				"SELECT w.id, l.id FROM words w CROSS JOIN labels l WHERE w.id IN ? AND l.id IN ? "+
+				"ON CONFLICT DO NOTHING RETURNING word_id", ids, request.TagIDs)
+		case dto.RemoveTags:
+			changed, err = returningWordIds(tx, "DELETE FROM word_tag WHERE word_id IN ? AND label_id IN ? RETURNING word_id",
+				ids, request.TagIDs)
+		case dto.AddLevels:
+			changed, err = returningWordIds(tx, "INSERT INTO word_level (word_id, level_id) "+
+				"SELECT w.id, l.id FROM words w CROSS JOIN levels l WHERE w.id IN ? AND l.id IN ? "+
+				"ON CONFLICT DO NOTHING RETURNING word_id", ids, request.LevelIDs)
+		case dto.RemoveLevels:
+			changed, err = returningWordIds(tx, "DELETE FROM word_level WHERE word_id IN ? AND level_id IN ? RETURNING word_id",
+				ids, request.LevelIDs)
+		case dto.SetYomiType:
+			// The primary reading mirrors the reading type of the word, a word changes when either of them does
+			changed, err = returningWordIds(tx, "WITH changed_readings AS ("+
+				"UPDATE word_readings SET type = ? WHERE word_id IN ? AND is_primary AND type IS DISTINCT FROM ? RETURNING word_id), "+
+				"changed_words AS (UPDATE words SET yomi_type = ? WHERE id IN ? AND yomi_type IS DISTINCT FROM ? RETURNING id) "+
+				"SELECT word_id FROM changed_readings UNION SELECT id FROM changed_words",
+				request.YomiType, ids, request.YomiType, request.YomiType, ids, request.YomiType)
+		default:
+			return errors.New("unsupported bulk operation " + string(request.Operation))
+		}
+		if err != nil {
+			return err
+		}
+		return bumpVersions(tx, "words", changed)
 	})
+	return changed, err
+}
+
+// returningWordIds runs a statement returning word IDs, each changed word being returned once
+func returningWordIds(tx *gorm.DB, statement string, values ...any) ([]uuid.UUID, error) {
+	var rows []uuid.UUID
+	if err := tx.Raw(statement, values...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	seen := make(map[uuid.UUID]bool, len(rows))
+	ids := make([]uuid.UUID, 0, len(rows))
+	for _, id := range rows {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// DeleteWords moves the given words to the trash at once and increments their version
+func (r *WordRepositoryImpl) DeleteWords(ids []uuid.UUID) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := bumpVersions(tx, "words", ids); err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", ids).Delete(&models.Word{}).Error
+	})
+}
+
+// orderByPosition sorts preloaded ordered children of a word, like its senses or its pitch accents
+func orderByPosition(db *gorm.DB) *gorm.DB {
+	return db.Order("position")
+}
+
+// primaryReadingFirst sorts the preloaded readings of a word, the primary one first then in a stable order
+func primaryReadingFirst(db *gorm.DB) *gorm.DB {
+	return db.Order("is_primary DESC, id")
+}
+
+// builtInLevels keeps the built-in levels among the preloaded levels of a word, the custom levels belonging to their owner
+func builtInLevels(db *gorm.DB) *gorm.DB {
+	return db.Where("type IS DISTINCT FROM ?", models.CustomLevel)
 }
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"strings"
+)
+
+// gradeResults computes the status of every result carrying a typed answer
+// Results are updated in place, results without answer keep the status sent by the client
+func (s *WordLearningHistoryServiceImpl) gradeResults(results []dto.WordQuizResult) error {
+	var wordIDs []uuid.UUID
+	for _, result := range results {
+		if result.Answer != "" {
+			wordIDs = append(wordIDs, result.WordID)
+		}
+	}
+	if len(wordIDs) == 0 {
+		return nil
+	}
+
+	words, err := s.WordRepo.ListWordsByIds(wordIDs)
+	if err != nil {
+		return err
+	}
+	wordsMap := make(map[uuid.UUID]*models.Word)
+	for _, w := range words {
+		wordsMap[w.ID] = w
+	}
+	sentencesMap, err := s.listResultSentences(results)
+	if err != nil {
+		return err
+	}
+
+	for i := range results {
+		if results[i].Answer == "" {
+			continue
+		}
+		var sentence *models.ExampleSentence
+		if results[i].SentenceID != nil {
+			sentence = sentencesMap[*results[i].SentenceID]
+		}
+		word, exists := wordsMap[results[i].WordID]
+		if exists && gradeAnswer(word, sentence, &results[i]) {
+			results[i].Status = dto.Success
+		} else {
+			results[i].Status = dto.Error
+		}
+	}
+	return nil
+}
+
+// listResultSentences fetches the sentences of the answered cloze questions
+func (s *WordLearningHistoryServiceImpl) listResultSentences(results []dto.WordQuizResult) (map[uuid.UUID]*models.ExampleSentence, error) {
+	var sentenceIDs []uuid.UUID
+	for _, result := range results {
+		if result.Answer != "" && result.Mode == dto.ClozeMode && result.SentenceID != nil {
+			sentenceIDs = append(sentenceIDs, *result.SentenceID)
+		}
+	}
+	sentencesMap := make(map[uuid.UUID]*models.ExampleSentence)
+	if len(sentenceIDs) == 0 {
+		return sentencesMap, nil
+	}
+
+	sentences, err := s.ExampleRepo.ListExampleSentencesByIds(sentenceIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, sentence := range sentences {
+		sentencesMap[sentence.ID] = sentence
+	}
+	return sentencesMap, nil
+}
+
+// gradeAnswer checks a typed answer against the word according to the quiz mode
+// The sentence is only used in cloze mode and may be nil
+func gradeAnswer(word *models.Word, sentence *models.ExampleSentence, result *dto.WordQuizResult) bool {
+	switch result.Mode {
+	case dto.MeaningMode, "":
+		return matchesMeaning(word, result.Answer)
+	case dto.ReadingMode:
+		return matchesReading(word, result.Answer)
+	case dto.ClozeMode:
+		return matchesCloze(word, sentence, result.Answer)
+	case dto.ConjugationMode:
+		return matchesConjugation(word, result.Form, result.Answer)
+	default:
+		return false
+	}
+}
+
+// matchesMeaning accepts the primary translation and the translations of every sense, in any language
+func matchesMeaning(word *models.Word, answer string) bool {
+	normalizedAnswer := normalizeMeaning(answer)
+	if normalizedAnswer == "" {
+		return false
+	}
+
+	translations := []*models.Label{&word.Translation}
+	for _, sense := range word.Senses {
+		translations = append(translations, sense.Translations...)
+	}
+
+	for _, translation := range translations {
+		for _, text := range []string{translation.En, translation.Fr} {
+			for _, meaning := range splitMeanings(text) {
+				if normalizeMeaning(meaning) == normalizedAnswer {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// matchesReading accepts the primary reading and every alternative reading, in hiragana or katakana
+func matchesReading(word *models.Word, answer string) bool {
+	normalizedAnswer := normalizeReading(answer)
+	if normalizedAnswer == "" {
+		return false
+	}
+
+	if normalizeReading(word.Yomi) == normalizedAnswer {
+		return true
+	}
+	for _, reading := range word.Readings {
+		if normalizeReading(reading.Reading) == normalizedAnswer {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesCloze accepts the word as blanked in the sentence, conjugated or not, in kanji or in kana
+func matchesCloze(word *models.Word, sentence *models.ExampleSentence, answer string) bool {
+	normalizedAnswer := normalizeReading(answer)
+	if normalizedAnswer == "" {
+		return false
+	}
+
+	if normalizeReading(word.Kanji) == normalizedAnswer || matchesReading(word, answer) {
+		return true
+	}
+	if sentence != nil {
+		if _, blanked, found := maskWord(word, sentence.Japanese); found {
+			return normalizeReading(blanked) == normalizedAnswer
+		}
+	}
+	return false
+}
+
+// splitMeanings splits a translation holding several alternatives ("eat; consume")
+func splitMeanings(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ';' || r == '/'
+	})
+}
+
+// normalizeMeaning lowers case, collapses spaces and drops the infinitive marker of verbs
+func normalizeMeaning(meaning string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(meaning)), " ")
+	return strings.TrimPrefix(normalized, "to ")
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"github.com/xanagit/kotoquiz-api/models"
+	"strings"
+	"unicode/utf8"
+)
+
+// clozeBlank replaces the target word in a cloze sentence
+const clozeBlank = "＿＿"
+
+// inflectionTails are the endings that can follow the stem of a verb or an adjective,
+// possibly after a connecting kana (書[き]ます, 読[ん]で)
+var inflectionTails = []string{
+	"る", "た", "て", "だ", "で", "ば", "ろ", "よ", "い", "く", "さ",
+	"ない", "なかった", "なくて", "なければ",
+	"ます", "ました", "ません", "ませんでした", "ましょう",
+	"たい", "たくない", "たかった",
+	"ている", "ていた", "ています", "ていました", "てる", "てた",
+	"でいる", "でいた", "でいます", "でいました",
+	"れる", "られる", "せる", "させる", "られた", "させた",
+	"よう", "れば", "たら", "だら",
+	"くて", "かった", "くない", "くなかった", "ければ", "そう",
+}
+
+// hiraganaConnectors can link a godan or suru verb stem to its ending
+// They are never used after an adjective stem (高[い]で would swallow the particle)
+const hiraganaConnectors = "かきくけこがぎぐげごさしすせそただちつってとなにぬねのばびぶべぼまみむめもらりるれろわいうんっ"
+
+// locateWord finds the word in a sentence, in its dictionary form or conjugated,
+// and returns the byte range of the occurrence
+func locateWord(word *models.Word, sentence string) (int, int, bool) {
+	for _, form := range wordForms(word) {
+		if start := strings.Index(sentence, form); start >= 0 {
+			return start, start + len(form), true
+		}
+	}
+
+	// Conjugated forms: look for the stem, then for the longest known ending
+	for _, form := range wordForms(word) {
+		stem, isAdjective, ok := inflectionStem(form)
+		if !ok {
+			continue
+		}
+		start := strings.Index(sentence, stem)
+		if start < 0 {
+			continue
+		}
+		end := start + len(stem)
+		return start, end + inflectionLength(sentence[end:], isAdjective), true
+	}
+	return 0, 0, false
+}
+
+// maskWord blanks the word in a sentence and returns the masked sentence with the blanked text
+func maskWord(word *models.Word, sentence string) (string, string, bool) {
+	start, end, found := locateWord(word, sentence)
+	if !found {
+		return "", "", false
+	}
+	return sentence[:start] + clozeBlank + sentence[end:], sentence[start:end], true
+}
+
+// wordForms lists the written forms of a word, the kanji first, then the readings
+func wordForms(word *models.Word) []string {
+	var forms []string
+	for _, form := range []string{word.Kanji, word.Yomi} {
+		if form != "" {
+			forms = append(forms, form)
+		}
+	}
+	for _, reading := range word.Readings {
+		if reading.Reading != "" && reading.Reading != word.Yomi {
+			forms = append(forms, reading.Reading)
+		}
+	}
+	return forms
+}
+
+// inflectionStem removes the last kana of a dictionary form ending like a verb (う row) or an adjective (い)
+// and the whole "する" of suru verbs. Stems made of a single kana are too ambiguous to be searched
+func inflectionStem(form string) (string, bool, bool) {
+	if stem, isSuru := strings.CutSuffix(form, "する"); isSuru && stem != "" {
+		return stem, false, true
+	}
+	last, size := utf8.DecodeLastRuneInString(form)
+	stem := form[:len(form)-size]
+	if stem == "" || !strings.ContainsRune("うくぐすつぬぶむるい", last) {
+		return "", false, false
+	}
+	if first, _ := utf8.DecodeRuneInString(stem); utf8.RuneCountInString(stem) == 1 && isKana(first) {
+		return "", false, false
+	}
+	return stem, last == 'い', true
+}
+
+// inflectionLength returns the length in bytes of the conjugation ending at the beginning of text
+func inflectionLength(text string, isAdjective bool) int {
+	longest := longestTail(text)
+	if isAdjective {
+		return longest
+	}
+	if connector, size := utf8.DecodeRuneInString(text); size > 0 && strings.ContainsRune(hiraganaConnectors, connector) {
+		longest = max(longest, size+longestTail(text[size:]))
+	}
+	return longest
+}
+
+// longestTail returns the length in bytes of the longest inflection tail starting text
+func longestTail(text string) int {
+	longest := 0
+	for _, tail := range inflectionTails {
+		if len(tail) > longest && strings.HasPrefix(text, tail) {
+			longest = len(tail)
+		}
+	}
+	return longest
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"github.com/xanagit/kotoquiz-api/repositories"
+)
+
+// ClozeService builds fill-in-the-blank questions from the example sentences of the words
+type ClozeService interface {
+	ListClozeQuestions(ids []uuid.UUID, lang string) ([]*dto.ClozeQuestion, error)
+}
+
+type ClozeServiceImpl struct {
+	WordRepo    repositories.WordRepository
+	ExampleRepo repositories.ExampleSentenceRepository
+}
+
+// Make sure that ClozeServiceImpl implements ClozeService
+var _ ClozeService = (*ClozeServiceImpl)(nil)
+
+// ListClozeQuestions builds one question per word, from a random example sentence in which the word is found
+// Words without any usable sentence are left out
+func (s *ClozeServiceImpl) ListClozeQuestions(ids []uuid.UUID, lang string) ([]*dto.ClozeQuestion, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no IDs provided")
+	}
+
+	words, err := s.WordRepo.ListWordsByIds(ids)
+	if err != nil {
+		return nil, err
+	}
+	examplesByWord, err := s.ExampleRepo.ListExampleSentencesByWordIds(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	questions := []*dto.ClozeQuestion{}
+	for _, word := range words {
+		if question := buildClozeQuestion(word, examplesByWord[word.ID], lang); question != nil {
+			questions = append(questions, question)
+		}
+	}
+	return questions, nil
+}
+
+// buildClozeQuestion masks the word in one of the sentences, picked at random among those containing it
+func buildClozeQuestion(word *models.Word, sentences []*models.ExampleSentence, lang string) *dto.ClozeQuestion {
+	var candidates []*dto.ClozeQuestion
+	for _, sentence := range sentences {
+		masked, _, found := maskWord(word, sentence.Japanese)
+		if !found {
+			continue
+		}
+		candidates = append(candidates, &dto.ClozeQuestion{
+			WordID:      word.ID,
+			SentenceID:  sentence.ID,
+			Sentence:    masked,
+			Translation: extractLabel(&sentence.Translation, lang),
+			Hint:        mapWordToDTO(word, lang).Translation,
+		})
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	randMutex.Lock()
+	defer randMutex.Unlock()
+	return candidates[globalRand.Intn(len(candidates))]
+}
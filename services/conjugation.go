@@ -0,0 +1,285 @@
+package services
+
+import (
+	"github.com/xanagit/kotoquiz-api/models"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// godanRows gives, for the last kana of a godan verb, the kana of the same consonant in the あ, い, え and お rows
+// The あ row of う is わ (買う → 買わない)
+var godanRows = map[rune][4]string{
+	'う': {"わ", "い", "え", "お"},
+	'く': {"か", "き", "け", "こ"},
+	'ぐ': {"が", "ぎ", "げ", "ご"},
+	'す': {"さ", "し", "せ", "そ"},
+	'つ': {"た", "ち", "て", "と"},
+	'ぬ': {"な", "に", "ね", "の"},
+	'ぶ': {"ば", "び", "べ", "ぼ"},
+	'む': {"ま", "み", "め", "も"},
+	'る': {"ら", "り", "れ", "ろ"},
+}
+
+const (
+	aRow = iota
+	iRow
+	eRow
+	oRow
+)
+
+// godanTeEndings gives the euphonic ending of the te form of a godan verb, by its last kana
+// The past form uses the same ending with た in place of て
+var godanTeEndings = map[rune]string{
+	'う': "って",
+	'つ': "って",
+	'る': "って",
+	'ぬ': "んで",
+	'ぶ': "んで",
+	'む': "んで",
+	'く': "いて",
+	'ぐ': "いで",
+	'す': "して",
+}
+
+// honorificVerbs are godan verbs in る whose ます stem ends with い (いらっしゃいます)
+var honorificVerbs = []string{"いらっしゃる", "おっしゃる", "くださる", "下さる", "なさる", "ござる"}
+
+// suruEndings conjugates する, the potential form of する is できる
+var suruEndings = map[models.ConjugationForm]string{
+	models.PoliteForm:      "します",
+	models.TeForm:          "して",
+	models.PastForm:        "した",
+	models.NegativeForm:    "しない",
+	models.PotentialForm:   "できる",
+	models.PassiveForm:     "される",
+	models.CausativeForm:   "させる",
+	models.VolitionalForm:  "しよう",
+	models.ConditionalForm: "すれば",
+}
+
+// kuruEndings conjugates 来る: the reading of the kanji changes (き, こ, く) while the ending follows
+var kuruEndings = map[models.ConjugationForm]struct{ kana, ending string }{
+	models.PoliteForm:      {"き", "ます"},
+	models.TeForm:          {"き", "て"},
+	models.PastForm:        {"き", "た"},
+	models.NegativeForm:    {"こ", "ない"},
+	models.PotentialForm:   {"こ", "られる"},
+	models.PassiveForm:     {"こ", "られる"},
+	models.CausativeForm:   {"こ", "させる"},
+	models.VolitionalForm:  {"こ", "よう"},
+	models.ConditionalForm: {"く", "れば"},
+}
+
+// conjugate returns the given form of a word in its dictionary form, written in kanji or in kana
+// The first result is the standard form, the next ones are accepted variants (ら抜き potential, では negative...)
+// It reports false when the part of speech does not have the form or when the word does not end as its class requires
+func conjugate(dictionaryForm string, partOfSpeech models.PartOfSpeech, form models.ConjugationForm) ([]string, bool) {
+	if dictionaryForm == "" || !slices.Contains(models.ConjugationForms(partOfSpeech), form) {
+		return nil, false
+	}
+	switch partOfSpeech {
+	case models.GodanVerb:
+		return conjugateGodan(dictionaryForm, form)
+	case models.IchidanVerb:
+		return conjugateIchidan(dictionaryForm, form)
+	case models.SuruVerb:
+		// Nouns conjugated with する may be stored without it
+		stem := strings.TrimSuffix(dictionaryForm, "する")
+		return []string{stem + suruEndings[form]}, true
+	case models.KuruVerb:
+		return conjugateKuru(dictionaryForm, form)
+	case models.IAdjective:
+		return conjugateIAdjective(dictionaryForm, form)
+	case models.NaAdjective:
+		return conjugateNaAdjective(dictionaryForm, form)
+	default:
+		return nil, false
+	}
+}
+
+func conjugateGodan(verb string, form models.ConjugationForm) ([]string, bool) {
+	last, size := utf8.DecodeLastRuneInString(verb)
+	rows, exists := godanRows[last]
+	stem := verb[:len(verb)-size]
+	if !exists || stem == "" {
+		return nil, false
+	}
+
+	switch form {
+	case models.PoliteForm:
+		if slices.Contains(honorificVerbs, verb) {
+			return []string{stem + "います"}, true
+		}
+		return []string{stem + rows[iRow] + "ます"}, true
+	case models.TeForm, models.PastForm:
+		ending := godanTeEndings[last]
+		// 行く is the only verb in く with a te form in って
+		if isIku(verb) {
+			ending = "って"
+		}
+		if form == models.PastForm {
+			ending = strings.NewReplacer("て", "た", "で", "だ").Replace(ending)
+		}
+		return []string{stem + ending}, true
+	case models.NegativeForm:
+		// ある has no negative stem, its negative is ない
+		if verb == "ある" || verb == "有る" || verb == "在る" {
+			return []string{"ない"}, true
+		}
+		return []string{stem + rows[aRow] + "ない"}, true
+	case models.PotentialForm:
+		return []string{stem + rows[eRow] + "る"}, true
+	case models.PassiveForm:
+		return []string{stem + rows[aRow] + "れる"}, true
+	case models.CausativeForm:
+		return []string{stem + rows[aRow] + "せる"}, true
+	case models.VolitionalForm:
+		return []string{stem + rows[oRow] + "う"}, true
+	case models.ConditionalForm:
+		return []string{stem + rows[eRow] + "ば"}, true
+	default:
+		return nil, false
+	}
+}
+
+func conjugateIchidan(verb string, form models.ConjugationForm) ([]string, bool) {
+	stem, found := strings.CutSuffix(verb, "る")
+	if !found || stem == "" {
+		return nil, false
+	}
+
+	switch form {
+	case models.PoliteForm:
+		return []string{stem + "ます"}, true
+	case models.TeForm:
+		return []string{stem + "て"}, true
+	case models.PastForm:
+		return []string{stem + "た"}, true
+	case models.NegativeForm:
+		return []string{stem + "ない"}, true
+	case models.PotentialForm:
+		// The colloquial potential without ら (食べれる) is accepted
+		return []string{stem + "られる", stem + "れる"}, true
+	case models.PassiveForm:
+		return []string{stem + "られる"}, true
+	case models.CausativeForm:
+		return []string{stem + "させる"}, true
+	case models.VolitionalForm:
+		return []string{stem + "よう"}, true
+	case models.ConditionalForm:
+		return []string{stem + "れば"}, true
+	default:
+		return nil, false
+	}
+}
+
+func conjugateKuru(verb string, form models.ConjugationForm) ([]string, bool) {
+	endings := kuruEndings[form]
+	if prefix, found := strings.CutSuffix(verb, "来る"); found {
+		return kuruVariants(prefix+"来", endings.ending, form), true
+	}
+	if prefix, found := strings.CutSuffix(verb, "くる"); found {
+		return kuruVariants(prefix+endings.kana, endings.ending, form), true
+	}
+	return nil, false
+}
+
+// isIku tells whether a verb is 行く or one of its compounds
+// In kana, only いく itself and its compounds after a te form (持っていく, 飛んでいく) are matched,
+// so that other verbs merely ending in いく keep the regular te form in いて
+func isIku(verb string) bool {
+	if strings.HasSuffix(verb, "行く") || strings.HasSuffix(verb, "逝く") {
+		return true
+	}
+	return verb == "いく" || strings.HasSuffix(verb, "ていく") || strings.HasSuffix(verb, "でいく")
+}
+
+// kuruVariants adds the colloquial potential without ら (来れる) to the forms of 来る
+func kuruVariants(stem string, ending string, form models.ConjugationForm) []string {
+	if form == models.PotentialForm {
+		return []string{stem + ending, stem + "れる"}
+	}
+	return []string{stem + ending}
+}
+
+func conjugateIAdjective(adjective string, form models.ConjugationForm) ([]string, bool) {
+	stem, found := strings.CutSuffix(adjective, "い")
+	if !found || stem == "" {
+		return nil, false
+	}
+	// いい and its compounds (かっこいい) conjugate from the older form よい
+	if base, found := strings.CutSuffix(adjective, "いい"); found {
+		stem = base + "よ"
+	}
+
+	switch form {
+	case models.TeForm:
+		return []string{stem + "くて"}, true
+	case models.PastForm:
+		return []string{stem + "かった"}, true
+	case models.NegativeForm:
+		return []string{stem + "くない"}, true
+	case models.PastNegativeForm:
+		return []string{stem + "くなかった"}, true
+	case models.ConditionalForm:
+		return []string{stem + "ければ"}, true
+	default:
+		return nil, false
+	}
+}
+
+func conjugateNaAdjective(adjective string, form models.ConjugationForm) ([]string, bool) {
+	// Na-adjectives may be stored with their attributive な
+	stem := strings.TrimSuffix(adjective, "な")
+	if stem == "" {
+		return nil, false
+	}
+
+	switch form {
+	case models.TeForm:
+		return []string{stem + "で"}, true
+	case models.PastForm:
+		return []string{stem + "だった"}, true
+	case models.NegativeForm:
+		return []string{stem + "じゃない", stem + "ではない"}, true
+	case models.PastNegativeForm:
+		return []string{stem + "じゃなかった", stem + "ではなかった"}, true
+	case models.ConditionalForm:
+		return []string{stem + "なら", stem + "ならば"}, true
+	default:
+		return nil, false
+	}
+}
+
+// wordConjugations returns the given form of a word for each of its written forms (kanji, readings)
+// The standard form of the first written form comes first
+func wordConjugations(word *models.Word, form models.ConjugationForm) []string {
+	var conjugations []string
+	for _, written := range wordForms(word) {
+		variants, ok := conjugate(written, word.PartOfSpeech, form)
+		if !ok {
+			continue
+		}
+		for _, variant := range variants {
+			if !slices.Contains(conjugations, variant) {
+				conjugations = append(conjugations, variant)
+			}
+		}
+	}
+	return conjugations
+}
+
+// matchesConjugation accepts the form of the word written in kanji or in kana, standard or colloquial
+func matchesConjugation(word *models.Word, form models.ConjugationForm, answer string) bool {
+	normalizedAnswer := normalizeReading(answer)
+	if normalizedAnswer == "" {
+		return false
+	}
+	for _, conjugation := range wordConjugations(word, form) {
+		if normalizeReading(conjugation) == normalizedAnswer {
+			return true
+		}
+	}
+	return false
+}
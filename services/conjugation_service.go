@@ -0,0 +1,115 @@
+package services
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"github.com/xanagit/kotoquiz-api/repositories"
+	"slices"
+	"time"
+)
+
+// ConjugationService conjugates verbs and adjectives and builds the conjugation drill questions
+type ConjugationService interface {
+	ListWordConjugations(id uuid.UUID) ([]*dto.ConjugationDTO, error)
+	ListConjugationQuestions(userID string, ids []uuid.UUID, forms []models.ConjugationForm, lang string) ([]*dto.ConjugationQuestion, error)
+}
+
+type ConjugationServiceImpl struct {
+	WordRepo        repositories.WordRepository
+	ConjugationRepo repositories.ConjugationLearningHistoryRepository
+}
+
+// Make sure that ConjugationServiceImpl implements ConjugationService
+var _ ConjugationService = (*ConjugationServiceImpl)(nil)
+
+// ListWordConjugations returns every form of a word, empty if the word does not conjugate
+func (s *ConjugationServiceImpl) ListWordConjugations(id uuid.UUID) ([]*dto.ConjugationDTO, error) {
+	word, err := s.WordRepo.ReadWord(id)
+	if err != nil {
+		return nil, err
+	}
+
+	conjugations := []*dto.ConjugationDTO{}
+	for _, form := range models.ConjugationForms(word.PartOfSpeech) {
+		if answers := wordConjugations(word, form); len(answers) > 0 {
+			conjugations = append(conjugations, &dto.ConjugationDTO{Form: form, Answers: answers})
+		}
+	}
+	return conjugations, nil
+}
+
+// ListConjugationQuestions builds one question per word, asking for a form chosen according to the history of the user
+// The forms can be restricted to the given ones, words which do not conjugate in any of them are left out
+func (s *ConjugationServiceImpl) ListConjugationQuestions(userID string, ids []uuid.UUID, forms []models.ConjugationForm, lang string) ([]*dto.ConjugationQuestion, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no IDs provided")
+	}
+	for _, form := range forms {
+		if !form.IsValid() {
+			return nil, ErrInvalidConjugationForm
+		}
+	}
+
+	words, err := s.WordRepo.ListWordsByIds(ids)
+	if err != nil {
+		return nil, err
+	}
+	historiesMap, err := s.ConjugationRepo.GetHistories(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	questions := []*dto.ConjugationQuestion{}
+	for _, word := range words {
+		var candidates []models.ConjugationForm
+		for _, form := range models.ConjugationForms(word.PartOfSpeech) {
+			if (len(forms) == 0 || slices.Contains(forms, form)) && len(wordConjugations(word, form)) > 0 {
+				candidates = append(candidates, form)
+			}
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+		questions = append(questions, &dto.ConjugationQuestion{
+			WordID:       word.ID,
+			Kanji:        word.Kanji,
+			Yomi:         word.Yomi,
+			PartOfSpeech: word.PartOfSpeech,
+			Form:         pickConjugationForm(candidates, historiesMap[word.ID]),
+			Hint:         mapWordToDTO(word, lang).Translation,
+		})
+	}
+	return questions, nil
+}
+
+// pickConjugationForm chooses at random a form never asked or due for review
+// When every form has been reviewed recently, the form whose review is the closest is chosen
+func pickConjugationForm(candidates []models.ConjugationForm, histories []*models.ConjugationLearningHistory) models.ConjugationForm {
+	historiesByForm := make(map[models.ConjugationForm]*models.ConjugationLearningHistory)
+	for _, history := range histories {
+		historiesByForm[history.Form] = history
+	}
+
+	now := time.Now()
+	var due []models.ConjugationForm
+	next := candidates[0]
+	for _, form := range candidates {
+		history, exists := historiesByForm[form]
+		if !exists || !history.NextReviewDate.After(now) {
+			due = append(due, form)
+			continue
+		}
+		if nextHistory, exists := historiesByForm[next]; exists && history.NextReviewDate.Before(nextHistory.NextReviewDate) {
+			next = form
+		}
+	}
+	if len(due) == 0 {
+		return next
+	}
+
+	randMutex.Lock()
+	defer randMutex.Unlock()
+	return due[globalRand.Intn(len(due))]
+}
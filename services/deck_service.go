@@ -0,0 +1,282 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"github.com/xanagit/kotoquiz-api/repositories"
+)
+
+// ErrLevelNotPublished is returned when an action needs a published deck, or a clone of a deck still published
+var ErrLevelNotPublished = errors.New("level not published")
+
+// ErrInvalidRating is returned when a score is not between 1 and 5
+var ErrInvalidRating = errors.New("invalid rating")
+
+// shareCodeAlphabet leaves out the characters easily mistaken for one another
+const shareCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+const shareCodeLength = 8
+
+type DeckService interface {
+	PublishLevel(userID string, id uuid.UUID, visibility models.LevelVisibility) (*models.Level, error)
+	UnpublishLevel(userID string, id uuid.UUID) error
+	PushLevel(userID string, id uuid.UUID) (*models.Level, error)
+	ReadDeck(code string, lang string) (*dto.DeckDTO, error)
+	ListDecks(sort dto.DeckSort, limit int, offset int, lang string) (*dto.DeckPage, error)
+	CloneDeck(userID string, code string) (*models.Level, error)
+	RateDeck(userID string, code string, score int, lang string) (*dto.DeckDTO, error)
+	ReadLevelUpdate(userID string, id uuid.UUID) (*dto.DeckUpdate, error)
+	AcceptLevelUpdate(userID string, id uuid.UUID) (*models.Level, error)
+}
+
+type DeckServiceImpl struct {
+	Repo      repositories.DeckRepository
+	LevelRepo repositories.LevelRepository
+}
+
+// Make sure that DeckServiceImpl implements DeckService
+var _ DeckService = (*DeckServiceImpl)(nil)
+
+// PublishLevel publishes a custom level owned by the user as a shared or public deck
+// The share code of a level stays the same when it is published again, its current words are pushed
+func (s *DeckServiceImpl) PublishLevel(userID string, id uuid.UUID, visibility models.LevelVisibility) (*models.Level, error) {
+	if visibility == "" {
+		visibility = models.Shared
+	}
+	if visibility != models.Shared && visibility != models.Public {
+		return nil, ErrInvalidLevel
+	}
+	level, err := readOwnedLevel(s.LevelRepo, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	code := level.ShareCode
+	if code == "" {
+		if code, err = generateShareCode(); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.Repo.PublishDeck(id, code, visibility); err != nil {
+		return nil, err
+	}
+	return s.LevelRepo.ReadLevel(id)
+}
+
+// UnpublishLevel makes a deck private again, its clones keep their words but no longer receive updates
+func (s *DeckServiceImpl) UnpublishLevel(userID string, id uuid.UUID) error {
+	if _, err := readOwnedLevel(s.LevelRepo, userID, id); err != nil {
+		return err
+	}
+	return s.Repo.UnpublishDeck(id)
+}
+
+// PushLevel publishes the current words of a deck as a new revision, offered to its clones
+func (s *DeckServiceImpl) PushLevel(userID string, id uuid.UUID) (*models.Level, error) {
+	level, err := readOwnedLevel(s.LevelRepo, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !level.IsPublished() {
+		return nil, ErrLevelNotPublished
+	}
+	if err := s.Repo.PushDeck(id); err != nil {
+		return nil, err
+	}
+	return s.LevelRepo.ReadLevel(id)
+}
+
+// ReadDeck reads a published deck from its share code
+func (s *DeckServiceImpl) ReadDeck(code string, lang string) (*dto.DeckDTO, error) {
+	level, err := s.Repo.ReadDeckByShareCode(code)
+	if err != nil {
+		return nil, err
+	}
+	decks, err := s.mapDecks([]*models.Level{level}, lang)
+	if err != nil {
+		return nil, err
+	}
+	return decks[0], nil
+}
+
+// ListDecks returns a page of the public decks, the most cloned first unless sorted by rating
+func (s *DeckServiceImpl) ListDecks(sort dto.DeckSort, limit int, offset int, lang string) (*dto.DeckPage, error) {
+	if sort == "" {
+		sort = dto.SortByClones
+	}
+	levels, total, err := s.Repo.ListPublicDecks(sort, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	decks, err := s.mapDecks(levels, lang)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.DeckPage{Decks: decks, Total: total, Limit: limit, Offset: offset}, nil
+}
+
+// CloneDeck copies a published deck into a private level of the user
+// The clone gets its own labels and words, it follows the deck only through the updates the user accepts
+func (s *DeckServiceImpl) CloneDeck(userID string, code string) (*models.Level, error) {
+	source, err := s.Repo.ReadDeckByShareCode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	clone := &models.Level{
+		Type:           models.CustomLevel,
+		OwnerID:        userID,
+		Visibility:     models.Private,
+		Category:       copyLabel(&source.Category),
+		SourceLevelID:  &source.ID,
+		SourceRevision: source.Revision,
+	}
+	for _, levelName := range source.LevelNames {
+		copied := copyLabel(levelName)
+		clone.LevelNames = append(clone.LevelNames, &copied)
+	}
+	if err := s.Repo.CloneDeck(source, clone); err != nil {
+		return nil, err
+	}
+	return s.LevelRepo.ReadLevel(clone.ID)
+}
+
+// RateDeck records the score given by the user to a deck they do not own
+func (s *DeckServiceImpl) RateDeck(userID string, code string, score int, lang string) (*dto.DeckDTO, error) {
+	if score < 1 || score > 5 {
+		return nil, ErrInvalidRating
+	}
+	level, err := s.Repo.ReadDeckByShareCode(code)
+	if err != nil {
+		return nil, err
+	}
+	if level.OwnerID == userID {
+		return nil, ErrForbidden
+	}
+	if err := s.Repo.RateDeck(level.ID, userID, score); err != nil {
+		return nil, err
+	}
+	return s.ReadDeck(code, lang)
+}
+
+// ReadLevelUpdate lists the changes pushed to the source deck of a clone owned by the user since it was last synced
+func (s *DeckServiceImpl) ReadLevelUpdate(userID string, id uuid.UUID) (*dto.DeckUpdate, error) {
+	_, source, err := s.readClone(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	added, removed, err := s.Repo.ListDeckChanges(id, source.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	level, err := s.LevelRepo.ReadLevel(id)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.DeckUpdate{
+		SourceLevelID:  source.ID,
+		SourceRevision: level.SourceRevision,
+		Revision:       source.Revision,
+		AddedWordIDs:   nonNilIDs(added),
+		RemovedWordIDs: nonNilIDs(removed),
+	}, nil
+}
+
+// AcceptLevelUpdate applies the changes pushed to the source deck of a clone owned by the user
+func (s *DeckServiceImpl) AcceptLevelUpdate(userID string, id uuid.UUID) (*models.Level, error) {
+	_, source, err := s.readClone(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	added, removed, err := s.Repo.ListDeckChanges(id, source.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Repo.ApplyDeckChanges(id, source, added, removed); err != nil {
+		return nil, err
+	}
+	return s.LevelRepo.ReadLevel(id)
+}
+
+// readClone reads a clone owned by the user and its source deck, which must still be published
+func (s *DeckServiceImpl) readClone(userID string, id uuid.UUID) (*models.Level, *models.Level, error) {
+	clone, err := readOwnedLevel(s.LevelRepo, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if clone.SourceLevelID == nil {
+		return nil, nil, ErrLevelNotPublished
+	}
+	source, err := s.LevelRepo.ReadLevel(*clone.SourceLevelID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !source.IsPublished() {
+		return nil, nil, ErrLevelNotPublished
+	}
+	return clone, source, nil
+}
+
+// mapDecks maps published levels to decks, with the number of their published words
+func (s *DeckServiceImpl) mapDecks(levels []*models.Level, lang string) ([]*dto.DeckDTO, error) {
+	ids := make([]uuid.UUID, len(levels))
+	for i, level := range levels {
+		ids[i] = level.ID
+	}
+	wordCounts, err := s.Repo.CountPublishedWords(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	decks := make([]*dto.DeckDTO, len(levels))
+	for i, level := range levels {
+		decks[i] = &dto.DeckDTO{
+			ID:            level.ID,
+			ShareCode:     level.ShareCode,
+			Category:      extractLabel(&level.Category, lang),
+			LevelNames:    []string{},
+			Visibility:    level.Visibility,
+			Revision:      level.Revision,
+			WordCount:     wordCounts[level.ID],
+			CloneCount:    level.CloneCount,
+			RatingCount:   level.RatingCount,
+			RatingAverage: level.RatingAverage,
+		}
+		for _, levelName := range level.LevelNames {
+			decks[i].LevelNames = append(decks[i].LevelNames, extractLabel(levelName, lang))
+		}
+	}
+	return decks, nil
+}
+
+// copyLabel copies the texts of a label into a new label of the same type
+func copyLabel(label *models.Label) models.Label {
+	copied := *label
+	copied.ID = uuid.Nil
+	return copied
+}
+
+// nonNilIDs makes sure an empty list of IDs is serialized as an empty array
+func nonNilIDs(ids []uuid.UUID) []uuid.UUID {
+	if ids == nil {
+		return []uuid.UUID{}
+	}
+	return ids
+}
+
+// generateShareCode draws a random share code
+func generateShareCode() (string, error) {
+	random := make([]byte, shareCodeLength)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	code := make([]byte, shareCodeLength)
+	for i, b := range random {
+		code[i] = shareCodeAlphabet[int(b)%len(shareCodeAlphabet)]
+	}
+	return string(code), nil
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/models"
+	"github.com/xanagit/kotoquiz-api/repositories"
+)
+
+type ExampleSentenceService interface {
+	ListExampleSentences() ([]*models.ExampleSentence, error)
+	ReadExampleSentence(id uuid.UUID) (*models.ExampleSentence, error)
+	CreateExampleSentence(sentence *models.ExampleSentence) error
+	UpdateExampleSentence(sentence *models.ExampleSentence) error
+	DeleteExampleSentence(id uuid.UUID) error
+	OverrideFurigana(id uuid.UUID, furigana []models.FuriganaSegment) (*models.ExampleSentence, error)
+	ResetFurigana(id uuid.UUID) (*models.ExampleSentence, error)
+}
+
+type ExampleSentenceServiceImpl struct {
+	Repo      repositories.ExampleSentenceRepository
+	KanjiRepo repositories.KanjiRepository
+}
+
+// Make sure that ExampleSentenceServiceImpl implements ExampleSentenceService
+var _ ExampleSentenceService = (*ExampleSentenceServiceImpl)(nil)
+
+func (s *ExampleSentenceServiceImpl) ListExampleSentences() ([]*models.ExampleSentence, error) {
+	return s.Repo.ListExampleSentences()
+}
+
+func (s *ExampleSentenceServiceImpl) ReadExampleSentence(id uuid.UUID) (*models.ExampleSentence, error) {
+	return s.Repo.ReadExampleSentence(id)
+}
+
+func (s *ExampleSentenceServiceImpl) CreateExampleSentence(sentence *models.ExampleSentence) error {
+	sentence.ID = uuid.Nil
+	sentence.Translation.Type = models.Translation
+	if err := s.prepareFurigana(sentence); err != nil {
+		return err
+	}
+	return s.Repo.CreateExampleSentence(sentence)
+}
+
+func (s *ExampleSentenceServiceImpl) UpdateExampleSentence(sentence *models.ExampleSentence) error {
+	sentence.Translation.Type = models.Translation
+	if err := s.prepareFurigana(sentence); err != nil {
+		return err
+	}
+	return s.Repo.UpdateExampleSentence(sentence)
+}
+
+func (s *ExampleSentenceServiceImpl) DeleteExampleSentence(id uuid.UUID) error {
+	return s.Repo.DeleteExampleSentence(id)
+}
+
+// OverrideFurigana replaces the computed furigana of a sentence by the given segments
+// The segments must spell the Japanese text of the sentence
+func (s *ExampleSentenceServiceImpl) OverrideFurigana(id uuid.UUID, furigana []models.FuriganaSegment) (*models.ExampleSentence, error) {
+	sentence, err := s.Repo.ReadExampleSentence(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateFurigana(sentence.Japanese, furigana); err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.UpdateExampleSentenceFurigana(id, furigana, true); err != nil {
+		return nil, err
+	}
+	sentence.Furigana = furigana
+	sentence.FuriganaOverride = true
+	return sentence, nil
+}
+
+// ResetFurigana drops the furigana set by hand and computes them again
+func (s *ExampleSentenceServiceImpl) ResetFurigana(id uuid.UUID) (*models.ExampleSentence, error) {
+	sentence, err := s.Repo.ReadExampleSentence(id)
+	if err != nil {
+		return nil, err
+	}
+
+	sentence.FuriganaOverride = false
+	if err := s.prepareFurigana(sentence); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.UpdateExampleSentenceFurigana(id, sentence.Furigana, false); err != nil {
+		return nil, err
+	}
+	return sentence, nil
+}
+
+// prepareFurigana computes the furigana of the sentence, unless valid ones were set by hand
+func (s *ExampleSentenceServiceImpl) prepareFurigana(sentence *models.ExampleSentence) error {
+	if sentence.FuriganaOverride && validateFurigana(sentence.Japanese, sentence.Furigana) == nil {
+		return nil
+	}
+
+	furigana, err := computeFurigana(s.KanjiRepo, sentence.Japanese, sentence.Reading)
+	if err != nil {
+		return err
+	}
+	sentence.Furigana = furigana
+	sentence.FuriganaOverride = false
+	return nil
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"errors"
+	"github.com/xanagit/kotoquiz-api/models"
+	"github.com/xanagit/kotoquiz-api/repositories"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrInvalidFurigana is returned when overridden furigana segments do not spell the annotated text
+var ErrInvalidFurigana = errors.New("furigana segments do not match the text")
+
+type runKind int
+
+const (
+	kanjiRun runKind = iota
+	kanaRun
+	otherRun
+)
+
+// textRun is a sequence of characters of the same kind
+type textRun struct {
+	text string
+	kind runKind
+}
+
+// voicedKana gives the voiced variants a kana can take at the start of a compound (rendaku)
+var voicedKana = map[rune][]rune{
+	'か': {'が'}, 'き': {'ぎ'}, 'く': {'ぐ'}, 'け': {'げ'}, 'こ': {'ご'},
+	'さ': {'ざ'}, 'し': {'じ'}, 'す': {'ず'}, 'せ': {'ぜ'}, 'そ': {'ぞ'},
+	'た': {'だ'}, 'ち': {'ぢ', 'じ'}, 'つ': {'づ', 'ず'}, 'て': {'で'}, 'と': {'ど'},
+	'は': {'ば', 'ぱ'}, 'ひ': {'び', 'ぴ'}, 'ふ': {'ぶ', 'ぷ'}, 'へ': {'べ', 'ぺ'}, 'ほ': {'ぼ', 'ぽ'},
+}
+
+// computeFurigana aligns the reading on the text, with the readings of the kanji of the text known in the database
+func computeFurigana(kanjiRepo repositories.KanjiRepository, text string, reading string) ([]models.FuriganaSegment, error) {
+	var characters []string
+	for _, r := range text {
+		if isKanji(r) && r != '々' {
+			characters = append(characters, string(r))
+		}
+	}
+	if len(characters) == 0 {
+		return nil, nil
+	}
+
+	kanji, err := kanjiRepo.ListKanjiByCharacters(characters)
+	if err != nil {
+		return nil, err
+	}
+	kanjiReadings := make(map[string][]string)
+	for _, k := range kanji {
+		kanjiReadings[k.Character] = append(append([]string{}, k.OnReadings...), k.KunReadings...)
+	}
+	return alignFurigana(text, reading, kanjiReadings), nil
+}
+
+// alignFurigana splits a text into segments carrying the part of the reading written above their kanji
+// Kana of the text anchor the alignment, kanji readings, when known, split the kanji compounds per character
+// It returns nil when the text has no kanji or when the reading does not fit the text
+func alignFurigana(text string, reading string, kanjiReadings map[string][]string) []models.FuriganaSegment {
+	runs := splitRuns(text)
+	if !hasKanjiRun(runs) || reading == "" {
+		return nil
+	}
+
+	normalizedReading := []rune(normalizeReading(reading))
+	runReadings, ok := matchRuns(runs, 0, normalizedReading, 0, make(map[[2]int]bool))
+	if !ok {
+		return nil
+	}
+
+	var segments []models.FuriganaSegment
+	for i, run := range runs {
+		if run.kind != kanjiRun {
+			segments = append(segments, models.FuriganaSegment{Text: run.text})
+			continue
+		}
+		if perCharacter, ok := splitKanjiRun(run.text, runReadings[i], kanjiReadings); ok {
+			segments = append(segments, perCharacter...)
+		} else {
+			segments = append(segments, models.FuriganaSegment{Text: run.text, Reading: runReadings[i]})
+		}
+	}
+	return segments
+}
+
+// matchRuns assigns a part of the reading to each run, from the given run and reading position
+// Kana runs must be found as is in the reading, other characters may be missing from it
+// Failed positions are remembered to avoid exploring them again
+func matchRuns(runs []textRun, i int, reading []rune, pos int, failed map[[2]int]bool) ([]string, bool) {
+	if i == len(runs) {
+		return make([]string, len(runs)), pos == len(reading)
+	}
+	if failed[[2]int{i, pos}] {
+		return nil, false
+	}
+
+	run := runs[i]
+	switch run.kind {
+	case kanaRun:
+		kana := []rune(toHiragana(run.text))
+		if hasRunesAt(reading, pos, kana) {
+			if readings, ok := matchRuns(runs, i+1, reading, pos+len(kana), failed); ok {
+				return readings, true
+			}
+		}
+	case otherRun:
+		characters := []rune(strings.Join(strings.Fields(run.text), ""))
+		if len(characters) > 0 && hasRunesAt(reading, pos, characters) {
+			if readings, ok := matchRuns(runs, i+1, reading, pos+len(characters), failed); ok {
+				return readings, true
+			}
+		}
+		if readings, ok := matchRuns(runs, i+1, reading, pos, failed); ok {
+			return readings, true
+		}
+	case kanjiRun:
+		for end := pos + 1; end <= len(reading); end++ {
+			if readings, ok := matchRuns(runs, i+1, reading, end, failed); ok {
+				readings[i] = string(reading[pos:end])
+				return readings, true
+			}
+		}
+	}
+
+	failed[[2]int{i, pos}] = true
+	return nil, false
+}
+
+// splitKanjiRun splits the reading of a kanji compound between its characters using their known readings
+func splitKanjiRun(run string, reading string, kanjiReadings map[string][]string) ([]models.FuriganaSegment, bool) {
+	characters := []rune(run)
+	if len(characters) < 2 || len(kanjiReadings) == 0 {
+		return nil, false
+	}
+
+	candidates := make([][]string, len(characters))
+	for i, character := range characters {
+		known := kanjiReadings[string(character)]
+		if character == '々' && i > 0 {
+			known = kanjiReadings[string(characters[i-1])]
+		}
+		candidates[i] = readingVariants(known)
+	}
+
+	parts, ok := splitReading(candidates, 0, reading)
+	if !ok {
+		return nil, false
+	}
+	segments := make([]models.FuriganaSegment, len(characters))
+	for i, character := range characters {
+		segments[i] = models.FuriganaSegment{Text: string(character), Reading: parts[i]}
+	}
+	return segments, true
+}
+
+// splitReading finds a reading among the candidates of each character spelling the whole reading
+func splitReading(candidates [][]string, i int, reading string) ([]string, bool) {
+	if i == len(candidates) {
+		return make([]string, len(candidates)), reading == ""
+	}
+	for _, candidate := range candidates[i] {
+		rest, found := strings.CutPrefix(reading, candidate)
+		if !found {
+			continue
+		}
+		if parts, ok := splitReading(candidates, i+1, rest); ok {
+			parts[i] = candidate
+			return parts, true
+		}
+	}
+	return nil, false
+}
+
+// readingVariants lists the forms a kanji reading can take inside a compound
+// KANJIDIC readings are cleaned first ("つ.ぐ" gives "つ", "-がわ" gives "がわ")
+func readingVariants(readings []string) []string {
+	var variants []string
+	for _, reading := range readings {
+		reading, _, _ = strings.Cut(reading, ".")
+		reading = normalizeReading(strings.Trim(reading, "-"))
+		if reading == "" {
+			continue
+		}
+		variants = append(variants, reading)
+
+		first, size := utf8.DecodeRuneInString(reading)
+		for _, voiced := range voicedKana[first] {
+			variants = append(variants, string(voiced)+reading[size:])
+		}
+
+		// Gemination before another kanji (学校: がく gives がっ)
+		last, size := utf8.DecodeLastRuneInString(reading)
+		if utf8.RuneCountInString(reading) > 1 && strings.ContainsRune("つくちき", last) {
+			variants = append(variants, reading[:len(reading)-size]+"っ")
+		}
+	}
+	return variants
+}
+
+// splitRuns cuts a text into runs of kanji, kana and other characters
+func splitRuns(text string) []textRun {
+	var runs []textRun
+	for _, r := range text {
+		kind := otherRun
+		if isKanji(r) {
+			kind = kanjiRun
+		} else if isKana(r) || r == 'ー' {
+			kind = kanaRun
+		}
+
+		if len(runs) > 0 && runs[len(runs)-1].kind == kind {
+			runs[len(runs)-1].text += string(r)
+		} else {
+			runs = append(runs, textRun{text: string(r), kind: kind})
+		}
+	}
+	return runs
+}
+
+// hasKanjiRun tells whether one of the runs is made of kanji
+func hasKanjiRun(runs []textRun) bool {
+	for _, run := range runs {
+		if run.kind == kanjiRun {
+			return true
+		}
+	}
+	return false
+}
+
+// hasRunesAt tells whether the runes are found in the reading at the given position
+func hasRunesAt(reading []rune, pos int, runes []rune) bool {
+	if pos+len(runes) > len(reading) {
+		return false
+	}
+	for i, r := range runes {
+		if reading[pos+i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// isKanji tells whether a rune is a kanji, the repetition mark 々 included
+func isKanji(r rune) bool {
+	return unicode.Is(unicode.Han, r) || r == '々'
+}
+
+// validateFurigana checks that overridden segments spell the annotated text
+func validateFurigana(text string, segments []models.FuriganaSegment) error {
+	var spelled strings.Builder
+	for _, segment := range segments {
+		spelled.WriteString(segment.Text)
+	}
+	if len(segments) == 0 || spelled.String() != text {
+		return ErrInvalidFurigana
+	}
+	return nil
+}
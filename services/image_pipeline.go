@@ -0,0 +1,184 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/storage"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+)
+
+// Variants of an uploaded image, by the maximum length of their longest side
+// The large variant is the image served in place of the original
+const (
+	smallImageSide  = 160
+	mediumImageSide = 480
+	largeImageSide  = 1024
+)
+
+// imageSizes lists the variants of an image, the large one first
+var imageSizes = []struct {
+	name string
+	side int
+}{{"large", largeImageSide}, {"medium", mediumImageSide}, {"small", smallImageSide}}
+
+// maxImagePixels protects the decoding against images with huge dimensions in a small file
+const maxImagePixels = 50_000_000
+
+// imageKeyPrefix starts the keys of the images stored by the pipeline, named after their content hash
+const imageKeyPrefix = "images/"
+
+// jpegQuality is the quality of the re-encoded JPEG images
+const jpegQuality = 85
+
+// imageVariant is an encoded variant of an uploaded image
+type imageVariant struct {
+	size string
+	data []byte
+}
+
+// processedImage is an uploaded image cleaned from its metadata and resized in every variant
+type processedImage struct {
+	// key of the large variant, derived from the hash of its content
+	key         string
+	contentType string
+	variants    []imageVariant
+}
+
+// processImage decodes an image and encodes it again in each variant size
+// Re-encoding drops the metadata of the original (EXIF, text chunks...), GIF images become PNG images
+// The key depends only on the pixels, so the same picture uploaded twice gets the same key
+func processImage(data []byte, contentType string) (*processedImage, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedMediaType
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, ErrUnsupportedMediaType
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, ErrMediaTooLarge
+	}
+
+	var src image.Image
+	switch contentType {
+	case "image/jpeg":
+		src, err = jpeg.Decode(bytes.NewReader(data))
+	case "image/png":
+		src, err = png.Decode(bytes.NewReader(data))
+	case "image/gif":
+		src, err = gif.Decode(bytes.NewReader(data))
+	default:
+		return nil, ErrUnsupportedMediaType
+	}
+	if err != nil {
+		return nil, ErrUnsupportedMediaType
+	}
+
+	outputType := "image/png"
+	if contentType == "image/jpeg" {
+		outputType = "image/jpeg"
+	}
+
+	processed := &processedImage{contentType: outputType}
+	for _, size := range imageSizes {
+		encoded, err := encodeImage(resizeImage(src, size.side), outputType)
+		if err != nil {
+			return nil, err
+		}
+		processed.variants = append(processed.variants, imageVariant{size: size.name, data: encoded})
+	}
+
+	hash := sha256.Sum256(processed.variants[0].data)
+	processed.key = imageKeyPrefix + hex.EncodeToString(hash[:]) + mediaExtensions[outputType]
+	return processed, nil
+}
+
+// encodeImage encodes an image in the given format, without any metadata
+func encodeImage(img image.Image, contentType string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	return buf.Bytes(), err
+}
+
+// resizeImage scales an image down so that its longest side fits maxSide, keeping its aspect ratio
+// Each pixel of the result averages the pixels of the area it covers, smaller images are never scaled up
+func resizeImage(src image.Image, maxSide int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxSide && height <= maxSide {
+		return src
+	}
+
+	dstWidth, dstHeight := maxSide, max(1, height*maxSide/width)
+	if height > width {
+		dstWidth, dstHeight = max(1, width*maxSide/height), maxSide
+	}
+
+	// Work on premultiplied RGBA pixels so that transparent pixels do not darken the edges
+	rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		y0, y1 := y*height/dstHeight, max(y*height/dstHeight+1, (y+1)*height/dstHeight)
+		for x := 0; x < dstWidth; x++ {
+			x0, x1 := x*width/dstWidth, max(x*width/dstWidth+1, (x+1)*width/dstWidth)
+
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				offset := rgba.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += int(rgba.Pix[offset+c])
+					}
+					offset += 4
+				}
+			}
+			count := (y1 - y0) * (x1 - x0)
+			offset := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[offset+c] = uint8((sum[c] + count/2) / count)
+			}
+		}
+	}
+	return dst
+}
+
+// imageVariantKey returns the key of a variant of an image stored by the pipeline
+// The large variant is stored under the image key itself, the other ones are suffixed with their size
+// Images uploaded before the pipeline have a single file, used for every size
+func imageVariantKey(imageKey string, size string) string {
+	if size == "large" || !strings.HasPrefix(imageKey, imageKeyPrefix) {
+		return imageKey
+	}
+	dot := strings.LastIndex(imageKey, ".")
+	if dot < 0 {
+		return imageKey + "-" + size
+	}
+	return imageKey[:dot] + "-" + size + imageKey[dot:]
+}
+
+// imageURLs returns the URLs of every variant of an image
+func imageURLs(media storage.MediaStorage, imageKey string) (*dto.ImageURLs, error) {
+	urls := make(map[string]string, len(imageSizes))
+	for _, size := range imageSizes {
+		url, err := media.URL(imageVariantKey(imageKey, size.name))
+		if err != nil {
+			return nil, err
+		}
+		urls[size.name] = url
+	}
+	return &dto.ImageURLs{Small: urls["small"], Medium: urls["medium"], Large: urls["large"]}, nil
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"bufio"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// accentEntry is a line of an accent dictionary: a written form, its reading and its downsteps
+type accentEntry struct {
+	written   string
+	reading   string
+	downsteps []int
+}
+
+// parseAccentDictionary reads a tab-separated accent dictionary ("お茶\tおちゃ\t0", "今日\tきょう\t1,0")
+// and returns its entries with the number of skipped lines
+// Downsteps may be prefixed by a part of speech ("(名)0,(副)1"), kana words may leave the reading empty
+func parseAccentDictionary(path string) ([]*accentEntry, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var entries []*accentEntry
+	skipped := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 || strings.TrimSpace(fields[0]) == "" {
+			skipped++
+			continue
+		}
+		entry := &accentEntry{
+			written: strings.TrimSpace(fields[0]),
+			reading: strings.TrimSpace(fields[1]),
+		}
+		if entry.reading == "" {
+			entry.reading = entry.written
+		}
+
+		entry.downsteps, err = parseDownsteps(fields[2])
+		if err != nil || len(entry.downsteps) == 0 {
+			skipped++
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, skipped, scanner.Err()
+}
+
+// parseDownsteps reads a comma-separated list of downsteps, dropping duplicates and part of speech prefixes
+func parseDownsteps(field string) ([]int, error) {
+	var downsteps []int
+	for _, rawDownstep := range strings.Split(field, ",") {
+		if _, afterPrefix, found := strings.Cut(rawDownstep, ")"); found {
+			rawDownstep = afterPrefix
+		}
+		downstep, err := strconv.Atoi(strings.TrimSpace(rawDownstep))
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(downsteps, downstep) {
+			downsteps = append(downsteps, downstep)
+		}
+	}
+	return downsteps, nil
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"encoding/xml"
+	"github.com/xanagit/kotoquiz-api/models"
+	"io"
+	"os"
+)
+
+// kangxiRadicals lists the 214 classical radicals, indexed by radical number - 1
+var kangxiRadicals = []rune("一丨丶丿乙亅二亠人儿入八冂冖冫几凵刀力勹匕匚匸十卜卩厂厶又口囗土士夂夊夕大女子宀寸小尢尸屮山巛工己巾干幺广廴廾弋弓彐彡彳心戈戶手支攴文斗斤方无日曰月木欠止歹殳毋比毛氏气水火爪父爻爿片牙牛犬玄玉瓜瓦甘生用田疋疒癶白皮皿目矛矢石示禸禾穴立竹米糸缶网羊羽老而耒耳聿肉臣自至臼舌舛舟艮色艸虍虫血行衣襾見角言谷豆豕豸貝赤走足身車辛辰辵邑酉釆里金長門阜隶隹雨青非面革韋韭音頁風飛食首香馬骨高髟鬥鬯鬲鬼魚鳥鹵鹿麥麻黃黍黑黹黽鼎鼓鼠鼻齊齒龍龜龠")
+
+// kanjidicCharacter maps a <character> entry of KANJIDIC2
+type kanjidicCharacter struct {
+	Literal  string `xml:"literal"`
+	Radicals []struct {
+		Type  string `xml:"rad_type,attr"`
+		Value int    `xml:",chardata"`
+	} `xml:"radical>rad_value"`
+	Grade        int   `xml:"misc>grade"`
+	StrokeCounts []int `xml:"misc>stroke_count"`
+	Freq         int   `xml:"misc>freq"`
+	JLPT         int   `xml:"misc>jlpt"`
+	Readings     []struct {
+		Type  string `xml:"r_type,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"reading_meaning>rmgroup>reading"`
+	Meanings []struct {
+		Lang  string `xml:"m_lang,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"reading_meaning>rmgroup>meaning"`
+}
+
+// parseKanjidic streams a KANJIDIC2 file and returns its kanji with the number of skipped entries
+func parseKanjidic(path string) ([]*models.Kanji, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var kanji []*models.Kanji
+	skipped := 0
+	decoder := xml.NewDecoder(file)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "character" {
+			continue
+		}
+
+		var entry kanjidicCharacter
+		if err := decoder.DecodeElement(&entry, &start); err != nil {
+			return nil, 0, err
+		}
+		if entry.Literal == "" {
+			skipped++
+			continue
+		}
+		kanji = append(kanji, mapKanjidicCharacter(&entry))
+	}
+
+	return kanji, skipped, nil
+}
+
+func mapKanjidicCharacter(entry *kanjidicCharacter) *models.Kanji {
+	kanji := &models.Kanji{
+		Character:   entry.Literal,
+		Grade:       entry.Grade,
+		Frequency:   entry.Freq,
+		JLPT:        entry.JLPT,
+		OnReadings:  []string{},
+		KunReadings: []string{},
+		MeaningsEn:  []string{},
+		MeaningsFr:  []string{},
+	}
+
+	// The first stroke count is the accepted one, the others are common miscounts
+	if len(entry.StrokeCounts) > 0 {
+		kanji.StrokeCount = entry.StrokeCounts[0]
+	}
+
+	for _, radical := range entry.Radicals {
+		if radical.Type == "classical" && radical.Value >= 1 && radical.Value <= len(kangxiRadicals) {
+			kanji.Radical = string(kangxiRadicals[radical.Value-1])
+		}
+	}
+
+	for _, reading := range entry.Readings {
+		switch reading.Type {
+		case "ja_on":
+			kanji.OnReadings = append(kanji.OnReadings, reading.Value)
+		case "ja_kun":
+			kanji.KunReadings = append(kanji.KunReadings, reading.Value)
+		}
+	}
+
+	for _, meaning := range entry.Meanings {
+		switch meaning.Lang {
+		case "", "en":
+			kanji.MeaningsEn = append(kanji.MeaningsEn, meaning.Value)
+		case "fr":
+			kanji.MeaningsFr = append(kanji.MeaningsFr, meaning.Value)
+		}
+	}
+
+	return kanji
+}
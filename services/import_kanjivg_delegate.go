@@ -0,0 +1,95 @@
+package services
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// parseKanjiVGDir reads every KanjiVG SVG file of a directory and returns the stroke paths by character
+// Variant files ("065e5-Kaisho.svg") are skipped
+func parseKanjiVGDir(dir string) (map[string][]string, int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	strokes := make(map[string][]string)
+	skipped := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		hexCodePoint := strings.TrimSuffix(name, ".svg")
+		if entry.IsDir() || hexCodePoint == name || strings.Contains(hexCodePoint, "-") {
+			skipped++
+			continue
+		}
+
+		character, ok := kanjiVGCharacter(hexCodePoint)
+		if !ok {
+			skipped++
+			continue
+		}
+
+		paths, err := parseKanjiVGFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(paths) == 0 {
+			skipped++
+			continue
+		}
+		strokes[character] = paths
+	}
+
+	return strokes, skipped, nil
+}
+
+// parseKanjiVGFile returns the stroke paths of a KanjiVG SVG file, in stroke order
+// Strokes are the <path> elements, which appear in the document in the order they are drawn
+func parseKanjiVGFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var paths []string
+	decoder := xml.NewDecoder(file)
+	// KanjiVG files declare entities in their DTD that the decoder does not know
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "path" {
+			continue
+		}
+		for _, attr := range start.Attr {
+			if attr.Name.Local == "d" {
+				paths = append(paths, attr.Value)
+			}
+		}
+	}
+
+	return paths, nil
+}
+
+// kanjiVGCharacter decodes the hexadecimal code point used to name KanjiVG files ("065e5.svg")
+func kanjiVGCharacter(hexCodePoint string) (string, bool) {
+	codePoint, err := strconv.ParseInt(hexCodePoint, 16, 32)
+	if err != nil {
+		return "", false
+	}
+	return string(rune(codePoint)), true
+}
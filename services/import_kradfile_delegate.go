@@ -0,0 +1,74 @@
+package services
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// kradfileSubstitutes maps the kanji KRADFILE uses in place of the radicals missing from JIS X 0208
+// to the radicals learners actually type
+var kradfileSubstitutes = map[string]string{
+	"化": "亻",
+	"个": "𠆢",
+	"并": "丷",
+	"刈": "刂",
+	"乞": "𠂉",
+	"込": "辶",
+	"尚": "⺌",
+	"忙": "忄",
+	"扎": "扌",
+	"汁": "氵",
+	"犯": "犭",
+	"艾": "艹",
+	"邦": "阝",
+	"阡": "阝",
+	"老": "耂",
+	"杰": "灬",
+	"礼": "礻",
+	"疔": "疒",
+	"禹": "禸",
+	"初": "衤",
+	"買": "罒",
+	"滴": "啇",
+}
+
+// parseKradfile reads a UTF-8 KRADFILE ("亜 : ｜ 一 口") and returns the components by kanji
+// with the number of skipped lines
+func parseKradfile(path string) (map[string][]string, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	components := make(map[string][]string)
+	skipped := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		character, rawComponents, found := strings.Cut(line, ":")
+		character = strings.TrimSpace(character)
+		if !found || character == "" {
+			skipped++
+			continue
+		}
+
+		var kanjiComponents []string
+		for _, component := range strings.Fields(rawComponents) {
+			if substitute, exists := kradfileSubstitutes[component]; exists {
+				component = substitute
+			}
+			kanjiComponents = append(kanjiComponents, component)
+		}
+		components[character] = kanjiComponents
+	}
+
+	return components, skipped, scanner.Err()
+}
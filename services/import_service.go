@@ -0,0 +1,289 @@
+package services
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"github.com/xanagit/kotoquiz-api/repositories"
+	"path/filepath"
+	"strconv"
+)
+
+// ImportService imports dictionary data from local files into the database
+// Paths are always relative to the configured data directory
+type ImportService interface {
+	ImportKanjidic(path string) (*dto.ImportReport, error)
+	ImportKanjiVG(path string) (*dto.ImportReport, error)
+	ImportKradfile(path string) (*dto.ImportReport, error)
+	ImportTatoeba(path string, lang string) (*dto.ImportReport, error)
+	ImportAccents(path string) (*dto.ImportReport, error)
+	ImportJlptLevels(path string) (*dto.ImportReport, error)
+	ImportFrequencyRanks(path string) (*dto.ImportReport, error)
+}
+
+type ImportServiceImpl struct {
+	DataDir     string
+	WordRepo    repositories.WordRepository
+	KanjiRepo   repositories.KanjiRepository
+	ExampleRepo repositories.ExampleSentenceRepository
+}
+
+// Make sure that ImportServiceImpl implements ImportService
+var _ ImportService = (*ImportServiceImpl)(nil)
+
+// ImportKanjidic imports the kanji of a KANJIDIC2 XML file and links them to the existing words
+func (s *ImportServiceImpl) ImportKanjidic(path string) (*dto.ImportReport, error) {
+	kanji, skipped, err := parseKanjidic(s.resolvePath(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse KANJIDIC2 file: %v", err)
+	}
+
+	if err := s.KanjiRepo.UpsertKanjiDictionary(kanji); err != nil {
+		return nil, err
+	}
+	if err := s.KanjiRepo.LinkAllWords(); err != nil {
+		return nil, err
+	}
+
+	return &dto.ImportReport{Imported: len(kanji), Skipped: skipped}, nil
+}
+
+// ImportKanjiVG imports the stroke paths of a KanjiVG directory of SVG files
+// Only the kanji already known are updated, so KANJIDIC2 must be imported first
+func (s *ImportServiceImpl) ImportKanjiVG(path string) (*dto.ImportReport, error) {
+	strokes, skipped, err := parseKanjiVGDir(s.resolvePath(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse KanjiVG directory: %v", err)
+	}
+
+	report := &dto.ImportReport{Skipped: skipped}
+	for character, paths := range strokes {
+		updated, err := s.KanjiRepo.UpdateStrokePaths(character, paths)
+		if err != nil {
+			return nil, err
+		}
+		if updated {
+			report.Imported++
+		} else {
+			report.Skipped++
+		}
+	}
+	return report, nil
+}
+
+// ImportKradfile imports the components of a UTF-8 KRADFILE, used to look kanji up by their parts
+// Only the kanji already known are updated, so KANJIDIC2 must be imported first
+func (s *ImportServiceImpl) ImportKradfile(path string) (*dto.ImportReport, error) {
+	components, skipped, err := parseKradfile(s.resolvePath(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse KRADFILE: %v", err)
+	}
+
+	updated, err := s.KanjiRepo.UpdateComponents(components)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ImportReport{Imported: updated, Skipped: skipped + len(components) - updated}, nil
+}
+
+// ImportTatoeba imports the example sentences of a Tatoeba sentence pairs TSV file,
+// translated into the given language ("en" or "fr")
+// Importing the same sentences in another language completes their translation
+func (s *ImportServiceImpl) ImportTatoeba(path string, lang string) (*dto.ImportReport, error) {
+	refs, pairs, skipped, err := parseTatoebaPairs(s.resolvePath(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Tatoeba file: %v", err)
+	}
+
+	existing, err := s.ExampleRepo.ListExampleSentencesBySourceRefs(tatoebaSource, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	var newSentences []*models.ExampleSentence
+	var updatedTranslations []*models.Label
+	for _, ref := range refs {
+		pair := pairs[ref]
+		sentence, exists := existing[ref]
+		if !exists {
+			sentence = &models.ExampleSentence{
+				Japanese:    pair.japanese,
+				Source:      tatoebaSource,
+				SourceRef:   ref,
+				Translation: models.Label{Type: models.Translation},
+			}
+			setLabelText(&sentence.Translation, lang, pair.translation)
+			newSentences = append(newSentences, sentence)
+			continue
+		}
+
+		// Translations edited by hand are kept
+		if extractLabel(&sentence.Translation, lang) != "" {
+			skipped++
+			continue
+		}
+		setLabelText(&sentence.Translation, lang, pair.translation)
+		updatedTranslations = append(updatedTranslations, &sentence.Translation)
+	}
+
+	if err := s.ExampleRepo.ImportExampleSentences(newSentences, updatedTranslations); err != nil {
+		return nil, err
+	}
+
+	return &dto.ImportReport{Imported: len(newSentences) + len(updatedTranslations), Skipped: skipped}, nil
+}
+
+// ImportAccents imports the pitch accents of a tab-separated accent dictionary
+// Accents are attached to the words with the same written form and reading, replacing their previous ones
+func (s *ImportServiceImpl) ImportAccents(path string) (*dto.ImportReport, error) {
+	entries, skipped, err := parseAccentDictionary(s.resolvePath(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse accent dictionary: %v", err)
+	}
+
+	forms := make([]string, len(entries))
+	for i, entry := range entries {
+		forms[i] = entry.written
+	}
+	wordsByKey, err := s.listWordsByEntryKey(forms)
+	if err != nil {
+		return nil, err
+	}
+
+	accentsByWord := make(map[uuid.UUID][]*models.WordPitchAccent)
+	for _, entry := range entries {
+		matchingWords := wordsByKey[wordEntryKey(entry.written, entry.reading)]
+		if len(matchingWords) == 0 {
+			skipped++
+			continue
+		}
+		for _, word := range matchingWords {
+			word.PitchAccents = nil
+			for _, downstep := range entry.downsteps {
+				word.PitchAccents = append(word.PitchAccents, &models.WordPitchAccent{Downstep: downstep})
+			}
+			preparePitchAccents(word)
+			accentsByWord[word.ID] = word.PitchAccents
+		}
+	}
+
+	if err := s.WordRepo.ReplacePitchAccents(accentsByWord); err != nil {
+		return nil, err
+	}
+
+	return &dto.ImportReport{Imported: len(accentsByWord), Skipped: skipped}, nil
+}
+
+// ImportJlptLevels imports the JLPT levels of a tab-separated word list ("食べる\tたべる\tN5")
+// Levels are set on the words with the same written form and reading, a word listed twice keeping its easiest level
+func (s *ImportServiceImpl) ImportJlptLevels(path string) (*dto.ImportReport, error) {
+	parseLevel := func(field string) (int, bool) {
+		level, ok := models.ParseJlptLevel(field)
+		return int(level), ok
+	}
+	easiest := func(current int, candidate int) bool {
+		return candidate > current
+	}
+	values, skipped, err := s.importWordMetadata(path, parseLevel, easiest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JLPT list: %v", err)
+	}
+
+	levels := make(map[uuid.UUID]models.JlptLevel, len(values))
+	for wordID, value := range values {
+		levels[wordID] = models.JlptLevel(value)
+	}
+	if err := s.WordRepo.UpdateJlptLevels(levels); err != nil {
+		return nil, err
+	}
+
+	return &dto.ImportReport{Imported: len(levels), Skipped: skipped}, nil
+}
+
+// ImportFrequencyRanks imports the ranks of a tab-separated corpus frequency list ("猫\tねこ\t1024")
+// Ranks are set on the words with the same written form and reading, a word listed twice keeping its best rank
+func (s *ImportServiceImpl) ImportFrequencyRanks(path string) (*dto.ImportReport, error) {
+	parseRank := func(field string) (int, bool) {
+		rank, err := strconv.Atoi(field)
+		return rank, err == nil && rank > 0
+	}
+	mostCommon := func(current int, candidate int) bool {
+		return candidate < current
+	}
+	ranks, skipped, err := s.importWordMetadata(path, parseRank, mostCommon)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse frequency list: %v", err)
+	}
+
+	if err := s.WordRepo.UpdateFrequencyRanks(ranks); err != nil {
+		return nil, err
+	}
+
+	return &dto.ImportReport{Imported: len(ranks), Skipped: skipped}, nil
+}
+
+// importWordMetadata reads a word metadata list and returns the value of each matching word
+// Entries without matching word are counted as skipped, preferred tells which value to keep for a word listed twice
+func (s *ImportServiceImpl) importWordMetadata(path string, parseValue func(string) (int, bool), preferred func(current int, candidate int) bool) (map[uuid.UUID]int, int, error) {
+	entries, skipped, err := parseWordMetadataList(s.resolvePath(path), parseValue)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	forms := make([]string, len(entries))
+	for i, entry := range entries {
+		forms[i] = entry.written
+	}
+	wordsByKey, err := s.listWordsByEntryKey(forms)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	values := make(map[uuid.UUID]int)
+	for _, entry := range entries {
+		matchingWords := wordsByKey[wordEntryKey(entry.written, entry.reading)]
+		if len(matchingWords) == 0 {
+			skipped++
+			continue
+		}
+		for _, word := range matchingWords {
+			if current, exists := values[word.ID]; !exists || preferred(current, entry.value) {
+				values[word.ID] = entry.value
+			}
+		}
+	}
+	return values, skipped, nil
+}
+
+// listWordsByEntryKey fetches the words having one of the written forms, indexed by written form and reading
+// Kana words are written with their reading
+func (s *ImportServiceImpl) listWordsByEntryKey(forms []string) (map[string][]*models.Word, error) {
+	words, err := s.WordRepo.ListWordsByWrittenForms(forms)
+	if err != nil {
+		return nil, err
+	}
+
+	wordsByKey := make(map[string][]*models.Word)
+	for _, word := range words {
+		written := word.Kanji
+		if written == "" {
+			written = word.Yomi
+		}
+		key := wordEntryKey(written, word.Yomi)
+		wordsByKey[key] = append(wordsByKey[key], word)
+	}
+	return wordsByKey, nil
+}
+
+// wordEntryKey identifies a word of a dictionary file by its written form and its reading
+func wordEntryKey(written string, reading string) string {
+	return written + "\t" + normalizeReading(reading)
+}
+
+// resolvePath returns the location of a path inside the data directory
+// The path is cleaned first so that it can not escape the data directory
+func (s *ImportServiceImpl) resolvePath(path string) string {
+	return filepath.Join(s.DataDir, filepath.Clean("/"+path))
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// tatoebaSource identifies the sentences imported from Tatoeba
+const tatoebaSource = "tatoeba"
+
+// maxLabelLength is the size of the translation columns of a label
+const maxLabelLength = 255
+
+// tatoebaPair is a Japanese sentence of a Tatoeba export with its first translation
+type tatoebaPair struct {
+	japanese    string
+	translation string
+}
+
+// parseTatoebaPairs reads a Tatoeba sentence pairs TSV file ("jpn_id\tjpn_text\ttrans_id\ttrans_text")
+// and returns the pairs by Japanese sentence ID, in file order, with the number of skipped lines
+// A sentence listed several times keeps its first translation only
+func parseTatoebaPairs(path string) ([]string, map[string]*tatoebaPair, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var refs []string
+	pairs := make(map[string]*tatoebaPair)
+	skipped := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 4 || fields[0] == "" || strings.TrimSpace(fields[1]) == "" || strings.TrimSpace(fields[3]) == "" {
+			skipped++
+			continue
+		}
+		// Translations are stored in labels, longer ones can not be kept
+		if _, exists := pairs[fields[0]]; exists || utf8.RuneCountInString(fields[3]) > maxLabelLength {
+			skipped++
+			continue
+		}
+
+		refs = append(refs, fields[0])
+		pairs[fields[0]] = &tatoebaPair{
+			japanese:    strings.TrimSpace(fields[1]),
+			translation: strings.TrimSpace(fields[3]),
+		}
+	}
+
+	return refs, pairs, skipped, scanner.Err()
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// wordMetadataEntry is a line of a word metadata list: a written form, its reading and a value (JLPT level, rank)
+type wordMetadataEntry struct {
+	written string
+	reading string
+	value   int
+}
+
+// parseWordMetadataList reads a tab-separated list of words with a value ("食べる\tたべる\tN5", "猫\tねこ\t1024")
+// and returns its entries with the number of skipped lines
+// Values are read by parseValue, kana words may leave the reading empty
+func parseWordMetadataList(path string, parseValue func(string) (int, bool)) ([]*wordMetadataEntry, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var entries []*wordMetadataEntry
+	skipped := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 || strings.TrimSpace(fields[0]) == "" {
+			skipped++
+			continue
+		}
+		value, ok := parseValue(strings.TrimSpace(fields[2]))
+		if !ok {
+			skipped++
+			continue
+		}
+		entry := &wordMetadataEntry{
+			written: strings.TrimSpace(fields[0]),
+			reading: strings.TrimSpace(fields[1]),
+			value:   value,
+		}
+		if entry.reading == "" {
+			entry.reading = entry.written
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, skipped, scanner.Err()
+}
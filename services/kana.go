@@ -0,0 +1,28 @@
+package services
+
+import "strings"
+
+const (
+	// Offset between a katakana and the matching hiragana in the unicode table
+	katakanaToHiraganaOffset = 'ァ' - 'ぁ'
+)
+
+// toHiragana converts the katakana of a string to hiragana, other characters are kept as is
+func toHiragana(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'ァ' && r <= 'ヶ' {
+			return r - katakanaToHiraganaOffset
+		}
+		return r
+	}, s)
+}
+
+// normalizeReading makes readings comparable whatever the kana used and the surrounding spaces
+func normalizeReading(reading string) string {
+	return toHiragana(strings.Join(strings.Fields(reading), ""))
+}
+
+// isKana tells whether a rune is a hiragana or a katakana
+func isKana(r rune) bool {
+	return (r >= 'ぁ' && r <= 'ゖ') || (r >= 'ァ' && r <= 'ヺ')
+}
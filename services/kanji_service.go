@@ -0,0 +1,156 @@
+package services
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"github.com/xanagit/kotoquiz-api/repositories"
+	"gorm.io/gorm"
+)
+
+// ErrKanjiExists is returned when another kanji already has the same character
+var ErrKanjiExists = errors.New("kanji already exists")
+
+type KanjiService interface {
+	ListKanji() ([]*models.Kanji, error)
+	ReadKanji(id uuid.UUID) (*models.Kanji, error)
+	CreateKanji(kanji *models.Kanji) error
+	UpdateKanji(kanji *models.Kanji) error
+	DeleteKanji(id uuid.UUID) error
+	ReadKanjiDto(idOrCharacter string, lang string) (*dto.KanjiDTO, error)
+	LookupKanji(components []string, lang string) ([]*dto.KanjiDTO, error)
+}
+
+type KanjiServiceImpl struct {
+	Repo     repositories.KanjiRepository
+	WordRepo repositories.WordRepository
+}
+
+// Make sure that KanjiServiceImpl implements KanjiService
+var _ KanjiService = (*KanjiServiceImpl)(nil)
+
+func (s *KanjiServiceImpl) ListKanji() ([]*models.Kanji, error) {
+	return s.Repo.ListKanji()
+}
+
+func (s *KanjiServiceImpl) ReadKanji(id uuid.UUID) (*models.Kanji, error) {
+	return s.Repo.ReadKanji(id)
+}
+
+func (s *KanjiServiceImpl) CreateKanji(kanji *models.Kanji) error {
+	kanji.ID = uuid.Nil
+	if err := s.checkCharacterAvailable(kanji); err != nil {
+		return err
+	}
+	if err := s.Repo.CreateKanji(kanji); err != nil {
+		return err
+	}
+	return s.Repo.LinkWords(kanji)
+}
+
+// UpdateKanji updates an existing kanji and links it again to the words containing its character
+func (s *KanjiServiceImpl) UpdateKanji(kanji *models.Kanji) error {
+	if _, err := s.Repo.ReadKanji(kanji.ID); err != nil {
+		return err
+	}
+	if err := s.checkCharacterAvailable(kanji); err != nil {
+		return err
+	}
+	if err := s.Repo.UpdateKanji(kanji); err != nil {
+		return err
+	}
+	return s.Repo.LinkWords(kanji)
+}
+
+func (s *KanjiServiceImpl) DeleteKanji(id uuid.UUID) error {
+	return s.Repo.DeleteKanji(id)
+}
+
+// ReadKanjiDto fetches a kanji, by ID or by character, with all the words using it
+func (s *KanjiServiceImpl) ReadKanjiDto(idOrCharacter string, lang string) (*dto.KanjiDTO, error) {
+	var kanji *models.Kanji
+	var err error
+	if id, parseErr := uuid.Parse(idOrCharacter); parseErr == nil {
+		kanji, err = s.Repo.ReadKanji(id)
+	} else {
+		kanji, err = s.Repo.ReadKanjiByCharacter(idOrCharacter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	words, err := s.listKanjiWords(kanji)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapKanjiToDTO(kanji, words, lang), nil
+}
+
+// LookupKanji finds the kanji containing all the given components, sorted by stroke count,
+// each one with the words of the vocabulary using it
+func (s *KanjiServiceImpl) LookupKanji(components []string, lang string) ([]*dto.KanjiDTO, error) {
+	kanji, err := s.Repo.ListKanjiByComponents(components)
+	if err != nil || len(kanji) == 0 {
+		return []*dto.KanjiDTO{}, err
+	}
+
+	kanjiIDs := make([]uuid.UUID, len(kanji))
+	for i, k := range kanji {
+		kanjiIDs[i] = k.ID
+	}
+	wordIDsByKanji, err := s.Repo.ListWordIdsByKanji(kanjiIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch all the words at once, then dispatch them to their kanji
+	var allWordIDs []uuid.UUID
+	for _, wordIDs := range wordIDsByKanji {
+		allWordIDs = append(allWordIDs, wordIDs...)
+	}
+	wordsMap := make(map[uuid.UUID]*models.Word)
+	if len(allWordIDs) > 0 {
+		words, err := s.WordRepo.ListWordsByIds(allWordIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range words {
+			wordsMap[w.ID] = w
+		}
+	}
+
+	kanjiDTOs := make([]*dto.KanjiDTO, len(kanji))
+	for i, k := range kanji {
+		var words []*models.Word
+		for _, wordID := range wordIDsByKanji[k.ID] {
+			if w, exists := wordsMap[wordID]; exists {
+				words = append(words, w)
+			}
+		}
+		kanjiDTOs[i] = mapKanjiToDTO(k, words, lang)
+	}
+	return kanjiDTOs, nil
+}
+
+// checkCharacterAvailable makes sure that no other kanji has the character of the given one
+func (s *KanjiServiceImpl) checkCharacterAvailable(kanji *models.Kanji) error {
+	existing, err := s.Repo.ReadKanjiByCharacter(kanji.Character)
+	if err == nil && existing.ID != kanji.ID {
+		return ErrKanjiExists
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+// listKanjiWords fetches the complete words linked to a kanji
+func (s *KanjiServiceImpl) listKanjiWords(kanji *models.Kanji) ([]*models.Word, error) {
+	wordIDs, err := s.Repo.ListKanjiWordIds(kanji.ID)
+	if err != nil || len(wordIDs) == 0 {
+		return []*models.Word{}, err
+	}
+	return s.WordRepo.ListWordsByIds(wordIDs)
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+)
+
+func mapKanjiToDTO(kanji *models.Kanji, words []*models.Word, lang string) *dto.KanjiDTO {
+	if kanji == nil {
+		return nil
+	}
+
+	var meanings []string
+	switch lang {
+	case "en":
+		meanings = kanji.MeaningsEn
+	case "fr":
+		meanings = kanji.MeaningsFr
+	}
+
+	wordDTOs := make([]*dto.WordDTO, len(words))
+	for i, word := range words {
+		wordDTOs[i] = mapWordToDTO(word, lang)
+	}
+
+	return &dto.KanjiDTO{
+		ID:          kanji.ID,
+		Character:   kanji.Character,
+		StrokeCount: kanji.StrokeCount,
+		Radical:     kanji.Radical,
+		Components:  kanji.Components,
+		OnReadings:  kanji.OnReadings,
+		KunReadings: kanji.KunReadings,
+		Meanings:    meanings,
+		JLPT:        kanji.JLPT,
+		Grade:       kanji.Grade,
+		Frequency:   kanji.Frequency,
+		StrokePaths: kanji.StrokePaths,
+		Words:       wordDTOs,
+	}
+}
@@ -1,17 +1,39 @@
 package services
 
 import (
+	"errors"
 	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/dto"
 	"github.com/xanagit/kotoquiz-api/models"
 	"github.com/xanagit/kotoquiz-api/repositories"
+	"gorm.io/gorm"
+	"sort"
 )
 
+// ErrLabelInUse is returned when deleting without force a label still used by words or levels
+var ErrLabelInUse = errors.New("label still in use")
+
+// ErrInvalidLabel is returned when a level reuses a label which is not a category or a level name
+var ErrInvalidLabel = errors.New("invalid label type")
+
+// ErrLabelCycle is returned when a tag would become a descendant of itself
+var ErrLabelCycle = errors.New("tag cannot be nested under itself or its sub-tags")
+
+// ErrInvalidLabelMerge is returned when the labels to merge are not distinct labels of the same type,
+// or when one of them is used by a custom level
+var ErrInvalidLabelMerge = errors.New("invalid label merge")
+
 type LabelService interface {
 	ListLabels(labelType models.LabelType) ([]*models.Label, error)
-	ReadLabel(id uuid.UUID) (*models.Label, error)
+	ReadLabel(id uuid.UUID, labelType models.LabelType) (*models.Label, error)
 	CreateLabel(label *models.Label, labelType models.LabelType) error
-	UpdateLabel(label *models.Label) error
-	DeleteLabel(id uuid.UUID) error
+	UpdateLabel(label *models.Label, labelType models.LabelType) error
+	PatchLabel(id uuid.UUID, labelType models.LabelType, patch []byte, version int) (*models.Label, error)
+	DeleteLabel(id uuid.UUID, labelType models.LabelType, force bool) error
+	ListTagTree() ([]*dto.TagNode, error)
+	ReadTagTree(id uuid.UUID) (*dto.TagNode, error)
+	ListDuplicateLabels(labelType models.LabelType, maxDistance int) (*dto.LabelDuplicateReport, error)
+	MergeLabels(request *dto.LabelMergeRequest) (*dto.LabelMergeReport, error)
 }
 
 type LabelServiceImpl struct {
@@ -22,23 +44,336 @@ type LabelServiceImpl struct {
 var _ LabelService = (*LabelServiceImpl)(nil)
 
 func (s *LabelServiceImpl) ListLabels(labelType models.LabelType) ([]*models.Label, error) {
+	if labelType == models.Tag {
+		return s.listTags()
+	}
 	return s.Repo.ListLabelsByType(labelType)
 }
 
-func (s *LabelServiceImpl) ReadLabel(id uuid.UUID) (*models.Label, error) {
-	return s.Repo.ReadLabel(id)
+// ReadLabel reads a label of the given type, labels of other types are reported as missing
+// A tag whose parent is in the trash is given its closest ancestor out of the trash as parent
+func (s *LabelServiceImpl) ReadLabel(id uuid.UUID, labelType models.LabelType) (*models.Label, error) {
+	label, err := s.Repo.ReadLabel(id)
+	if err != nil {
+		return nil, err
+	}
+	if label.Type != labelType {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if label.ParentID != nil {
+		parents, available, err := s.tagParents()
+		if err != nil {
+			return nil, err
+		}
+		label.ParentID = availableParent(label, parents, available)
+	}
+	return label, nil
 }
 
+// CreateLabel creates a label of the given type, only tags may have a parent
 func (s *LabelServiceImpl) CreateLabel(label *models.Label, labelType models.LabelType) error {
 	label.ID = uuid.Nil
 	label.Type = labelType
+	if err := s.checkParent(label, nil); err != nil {
+		return err
+	}
 	return s.Repo.CreateLabel(label)
 }
 
-func (s *LabelServiceImpl) UpdateLabel(label *models.Label) error {
+// UpdateLabel updates the texts of an existing label of the given type, and the parent of a tag
+func (s *LabelServiceImpl) UpdateLabel(label *models.Label, labelType models.LabelType) error {
+	existing, err := s.ReadLabel(label.ID, labelType)
+	if err != nil {
+		return err
+	}
+	label.Type = labelType
+	if err := s.checkParent(label, existing.ParentID); err != nil {
+		return err
+	}
 	return s.Repo.UpdateLabel(label)
 }
 
-func (s *LabelServiceImpl) DeleteLabel(id uuid.UUID) error {
+// PatchLabel applies a JSON merge patch to a label of the given type, the members missing from the patch being kept
+// When version is not 0 the label must still be at this version
+func (s *LabelServiceImpl) PatchLabel(id uuid.UUID, labelType models.LabelType, patch []byte, version int) (*models.Label, error) {
+	existing, err := s.ReadLabel(id, labelType)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(existing.Version, version); err != nil {
+		return nil, err
+	}
+	label := *existing
+	if err := applyMergePatch(&label, patch); err != nil {
+		return nil, err
+	}
+	label.ID = id
+	// The patch is based on the version read above
+	label.Version = existing.Version
+	if err := s.UpdateLabel(&label, labelType); err != nil {
+		return nil, err
+	}
+	return &label, nil
+}
+
+// DeleteLabel moves a label of the given type to the trash
+// A label still used by words or levels is only deleted when forced, it is detached from them once purged
+func (s *LabelServiceImpl) DeleteLabel(id uuid.UUID, labelType models.LabelType, force bool) error {
+	if _, err := s.ReadLabel(id, labelType); err != nil {
+		return err
+	}
+	if !force {
+		usages, err := s.Repo.CountLabelUsages([]uuid.UUID{id})
+		if err != nil {
+			return err
+		}
+		if usages[id] > 0 {
+			return ErrLabelInUse
+		}
+	}
 	return s.Repo.DeleteLabel(id)
 }
+
+// ListTagTree lists the root tags with their sub-tags, at any depth
+func (s *LabelServiceImpl) ListTagTree() ([]*dto.TagNode, error) {
+	tags, err := s.listTags()
+	if err != nil {
+		return nil, err
+	}
+	nodes, roots := buildTagTree(tags)
+	tree := make([]*dto.TagNode, 0, len(roots))
+	for _, root := range roots {
+		tree = append(tree, nodes[root])
+	}
+	return tree, nil
+}
+
+// ReadTagTree reads a tag with its sub-tags, at any depth
+func (s *LabelServiceImpl) ReadTagTree(id uuid.UUID) (*dto.TagNode, error) {
+	tags, err := s.listTags()
+	if err != nil {
+		return nil, err
+	}
+	nodes, _ := buildTagTree(tags)
+	node, exists := nodes[id]
+	if !exists {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return node, nil
+}
+
+// ListDuplicateLabels finds the labels of a type with the same texts, ignoring case and spaces,
+// or with texts within maxDistance edits in both languages
+// The labels of custom levels belong to their owners and are left out
+func (s *LabelServiceImpl) ListDuplicateLabels(labelType models.LabelType, maxDistance int) (*dto.LabelDuplicateReport, error) {
+	allLabels, err := s.Repo.ListLabelsByType(labelType)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, 0, len(allLabels))
+	for _, label := range allLabels {
+		ids = append(ids, label.ID)
+	}
+	customIDs, err := s.Repo.ListCustomLevelLabelIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	excluded := make(map[uuid.UUID]bool, len(customIDs))
+	for _, id := range customIDs {
+		excluded[id] = true
+	}
+	labels := make([]*models.Label, 0, len(allLabels))
+	for _, label := range allLabels {
+		if !excluded[label.ID] {
+			labels = append(labels, label)
+		}
+	}
+
+	groups := groupDuplicateLabels(labels, maxDistance)
+	var groupedIDs []uuid.UUID
+	for _, labels := range groups {
+		for _, label := range labels {
+			groupedIDs = append(groupedIDs, label.ID)
+		}
+	}
+	usages, err := s.Repo.CountLabelUsages(groupedIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &dto.LabelDuplicateReport{Type: labelType, MaxDistance: maxDistance, Groups: []*dto.LabelDuplicateGroup{}}
+	for _, labels := range groups {
+		group := &dto.LabelDuplicateGroup{Exact: isExactGroup(labels)}
+		for _, label := range labels {
+			group.Labels = append(group.Labels, &dto.LabelUsage{Label: *label, Usages: usages[label.ID]})
+		}
+		sort.SliceStable(group.Labels, func(i, j int) bool {
+			return group.Labels[i].Usages > group.Labels[j].Usages
+		})
+		report.Groups = append(report.Groups, group)
+	}
+	return report, nil
+}
+
+// MergeLabels merges labels of the same type into a surviving label, the merged labels being deleted
+// A tag cannot be merged into one of its sub-tags, and the labels of custom levels cannot be merged
+func (s *LabelServiceImpl) MergeLabels(request *dto.LabelMergeRequest) (*dto.LabelMergeReport, error) {
+	target, err := s.Repo.ReadLabel(request.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	if len(request.SourceIDs) == 0 {
+		return nil, ErrInvalidLabelMerge
+	}
+	sources := make(map[uuid.UUID]bool, len(request.SourceIDs))
+	for _, sourceID := range request.SourceIDs {
+		if sourceID == target.ID || sources[sourceID] {
+			return nil, ErrInvalidLabelMerge
+		}
+		sources[sourceID] = true
+
+		source, err := s.Repo.ReadLabel(sourceID)
+		if err != nil {
+			return nil, err
+		}
+		if source.Type != target.Type {
+			return nil, ErrInvalidLabelMerge
+		}
+	}
+	customIDs, err := s.Repo.ListCustomLevelLabelIDs(append([]uuid.UUID{target.ID}, request.SourceIDs...))
+	if err != nil {
+		return nil, err
+	}
+	if len(customIDs) > 0 {
+		return nil, ErrInvalidLabelMerge
+	}
+
+	if target.Type == models.Tag {
+		parents, _, err := s.tagParents()
+		if err != nil {
+			return nil, err
+		}
+		visited := make(map[uuid.UUID]bool)
+		for ancestor := target.ParentID; ancestor != nil && !visited[*ancestor]; ancestor = parents[*ancestor] {
+			if sources[*ancestor] {
+				return nil, ErrLabelCycle
+			}
+			visited[*ancestor] = true
+		}
+	}
+
+	repointed, err := s.Repo.MergeLabels(target.ID, request.SourceIDs)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.LabelMergeReport{Target: target, Merged: len(request.SourceIDs), Repointed: repointed}, nil
+}
+
+// checkParent checks that the parent of a tag is an existing tag which is not one of its descendants
+// A tag keeps its current parent even when it is in the trash. Labels of other types have no parent
+func (s *LabelServiceImpl) checkParent(label *models.Label, currentParentID *uuid.UUID) error {
+	if label.Type != models.Tag {
+		label.ParentID = nil
+	}
+	if label.ParentID == nil || (currentParentID != nil && *currentParentID == *label.ParentID) {
+		return nil
+	}
+
+	parent, err := s.Repo.ReadLabel(*label.ParentID)
+	if err != nil {
+		return err
+	}
+	if parent.Type != models.Tag {
+		return ErrInvalidLabel
+	}
+
+	parents, _, err := s.tagParents()
+	if err != nil {
+		return err
+	}
+	// Walking up from the new parent must not lead back to the tag, visited ancestors guard against stored cycles
+	visited := make(map[uuid.UUID]bool)
+	for ancestor := label.ParentID; ancestor != nil && !visited[*ancestor]; ancestor = parents[*ancestor] {
+		if *ancestor == label.ID {
+			return ErrLabelCycle
+		}
+		visited[*ancestor] = true
+	}
+	return nil
+}
+
+// tagParents gives the parent of each tag and tells which tags are out of the trash
+// The tags in the trash are included as they keep their sub-tags until they are purged
+func (s *LabelServiceImpl) tagParents() (map[uuid.UUID]*uuid.UUID, map[uuid.UUID]bool, error) {
+	tags, err := s.Repo.ListLabelsByType(models.Tag)
+	if err != nil {
+		return nil, nil, err
+	}
+	trashed, err := s.Repo.ListTrashedLabels()
+	if err != nil {
+		return nil, nil, err
+	}
+	parents := make(map[uuid.UUID]*uuid.UUID, len(tags)+len(trashed))
+	available := make(map[uuid.UUID]bool, len(tags))
+	for _, tag := range tags {
+		parents[tag.ID] = tag.ParentID
+		available[tag.ID] = true
+	}
+	for _, label := range trashed {
+		if label.Type == models.Tag {
+			parents[label.ID] = label.ParentID
+		}
+	}
+	return parents, available, nil
+}
+
+// listTags lists the tags out of the trash, each one under its closest ancestor out of the trash
+func (s *LabelServiceImpl) listTags() ([]*models.Label, error) {
+	tags, err := s.Repo.ListLabelsByType(models.Tag)
+	if err != nil {
+		return nil, err
+	}
+	parents, available, err := s.tagParents()
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		tag.ParentID = availableParent(tag, parents, available)
+	}
+	return tags, nil
+}
+
+// availableParent gives the closest ancestor of a tag which is out of the trash, nil when there is none
+func availableParent(tag *models.Label, parents map[uuid.UUID]*uuid.UUID, available map[uuid.UUID]bool) *uuid.UUID {
+	visited := make(map[uuid.UUID]bool)
+	parentID := tag.ParentID
+	for parentID != nil && !available[*parentID] && !visited[*parentID] {
+		visited[*parentID] = true
+		parentID = parents[*parentID]
+	}
+	if parentID == nil || !available[*parentID] || *parentID == tag.ID {
+		return nil
+	}
+	return parentID
+}
+
+// buildTagTree links the tags to their sub-tags, keeping their order
+// It returns the node of each tag and the IDs of the roots, tags whose parent is missing being roots
+func buildTagTree(tags []*models.Label) (map[uuid.UUID]*dto.TagNode, []uuid.UUID) {
+	nodes := make(map[uuid.UUID]*dto.TagNode, len(tags))
+	for _, tag := range tags {
+		nodes[tag.ID] = &dto.TagNode{ID: tag.ID, En: tag.En, Fr: tag.Fr, Children: []*dto.TagNode{}}
+	}
+
+	var roots []uuid.UUID
+	for _, tag := range tags {
+		if tag.ParentID != nil {
+			if parent, exists := nodes[*tag.ParentID]; exists {
+				parent.Children = append(parent.Children, nodes[tag.ID])
+				continue
+			}
+		}
+		roots = append(roots, tag.ID)
+	}
+	return nodes, roots
+}
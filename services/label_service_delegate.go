@@ -0,0 +1,119 @@
+package services
+
+import (
+	"github.com/xanagit/kotoquiz-api/models"
+	"strings"
+)
+
+// groupDuplicateLabels groups the labels whose texts, in both languages, are equal or within maxDistance edits
+// Labels without text are left out, groups keep the order of the labels
+func groupDuplicateLabels(labels []*models.Label, maxDistance int) [][]*models.Label {
+	var candidates []*models.Label
+	for _, label := range labels {
+		if normalizeLabelText(label.En) != "" || normalizeLabelText(label.Fr) != "" {
+			candidates = append(candidates, label)
+		}
+	}
+
+	// Union-find over the candidates, the root of a group being its first label
+	roots := make([]int, len(candidates))
+	for i := range roots {
+		roots[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if roots[i] != i {
+			roots[i] = find(roots[i])
+		}
+		return roots[i]
+	}
+	union := func(i, j int) {
+		ri, rj := find(i), find(j)
+		if ri < rj {
+			roots[rj] = ri
+		} else if rj < ri {
+			roots[ri] = rj
+		}
+	}
+
+	// Exact duplicates share their key, near duplicates are compared pair by pair
+	byKey := make(map[string]int, len(candidates))
+	for i, label := range candidates {
+		key := normalizeLabelText(label.En) + "\x00" + normalizeLabelText(label.Fr)
+		if first, exists := byKey[key]; exists {
+			union(first, i)
+		} else {
+			byKey[key] = i
+		}
+	}
+	if maxDistance > 0 {
+		for i := range candidates {
+			for j := i + 1; j < len(candidates); j++ {
+				if find(i) != find(j) && areNearDuplicates(candidates[i], candidates[j], maxDistance) {
+					union(i, j)
+				}
+			}
+		}
+	}
+
+	members := make(map[int][]*models.Label)
+	var order []int
+	for i, label := range candidates {
+		root := find(i)
+		if _, exists := members[root]; !exists {
+			order = append(order, root)
+		}
+		members[root] = append(members[root], label)
+	}
+	var groups [][]*models.Label
+	for _, root := range order {
+		if len(members[root]) > 1 {
+			groups = append(groups, members[root])
+		}
+	}
+	return groups
+}
+
+// areNearDuplicates tells whether the texts of two labels are within maxDistance edits in both languages
+func areNearDuplicates(a *models.Label, b *models.Label, maxDistance int) bool {
+	return levenshtein(normalizeLabelText(a.En), normalizeLabelText(b.En)) <= maxDistance &&
+		levenshtein(normalizeLabelText(a.Fr), normalizeLabelText(b.Fr)) <= maxDistance
+}
+
+// isExactGroup tells whether all the labels of a group have the same texts once normalized
+func isExactGroup(labels []*models.Label) bool {
+	for _, label := range labels[1:] {
+		if normalizeLabelText(label.En) != normalizeLabelText(labels[0].En) ||
+			normalizeLabelText(label.Fr) != normalizeLabelText(labels[0].Fr) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeLabelText lowers case and collapses spaces
+func normalizeLabelText(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
+
+// levenshtein counts the insertions, deletions and substitutions of characters turning a into b
+func levenshtein(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/config"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"github.com/xanagit/kotoquiz-api/repositories"
+	"sort"
+)
+
+// ErrInvalidLearningPath is returned when a learning path has an invalid threshold, a custom level or a level twice
+var ErrInvalidLearningPath = errors.New("invalid learning path")
+
+// defaultUnlockThreshold is used when no threshold is configured
+const defaultUnlockThreshold = 0.8
+
+type LearningPathService interface {
+	ListLearningPaths() ([]*models.LearningPath, error)
+	ReadLearningPath(id uuid.UUID) (*models.LearningPath, error)
+	CreateLearningPath(path *models.LearningPath) error
+	UpdateLearningPath(path *models.LearningPath) error
+	DeleteLearningPath(id uuid.UUID) error
+	ReadLearningPathProgress(userID string, id uuid.UUID, lang string) (*dto.LearningPathProgress, error)
+}
+
+type LearningPathServiceImpl struct {
+	Repo      repositories.LearningPathRepository
+	LevelRepo repositories.LevelRepository
+	Config    *config.LearningConfig
+}
+
+// Make sure that LearningPathServiceImpl implements LearningPathService
+var _ LearningPathService = (*LearningPathServiceImpl)(nil)
+
+func (s *LearningPathServiceImpl) ListLearningPaths() ([]*models.LearningPath, error) {
+	return s.Repo.ListLearningPaths()
+}
+
+func (s *LearningPathServiceImpl) ReadLearningPath(id uuid.UUID) (*models.LearningPath, error) {
+	return s.Repo.ReadLearningPath(id)
+}
+
+// CreateLearningPath creates a learning path from built-in levels
+func (s *LearningPathServiceImpl) CreateLearningPath(path *models.LearningPath) error {
+	path.ID = uuid.Nil
+	if err := s.prepareSteps(path); err != nil {
+		return err
+	}
+	if err := s.Repo.CreateLearningPath(path); err != nil {
+		return err
+	}
+	return s.reload(path)
+}
+
+// UpdateLearningPath updates a learning path, its steps being replaced by the given ones
+func (s *LearningPathServiceImpl) UpdateLearningPath(path *models.LearningPath) error {
+	if _, err := s.Repo.ReadLearningPath(path.ID); err != nil {
+		return err
+	}
+	if err := s.prepareSteps(path); err != nil {
+		return err
+	}
+	if err := s.Repo.UpdateLearningPath(path); err != nil {
+		return err
+	}
+	return s.reload(path)
+}
+
+func (s *LearningPathServiceImpl) DeleteLearningPath(id uuid.UUID) error {
+	if _, err := s.Repo.ReadLearningPath(id); err != nil {
+		return err
+	}
+	return s.Repo.DeleteLearningPath(id)
+}
+
+// ReadLearningPathProgress computes the progress of the user through a learning path
+// The first level is always unlocked, each next one once the share of words of the previous level
+// the user reviews or masters reaches the threshold. Levels without words do not block the path
+func (s *LearningPathServiceImpl) ReadLearningPathProgress(userID string, id uuid.UUID, lang string) (*dto.LearningPathProgress, error) {
+	path, err := s.Repo.ReadLearningPath(id)
+	if err != nil {
+		return nil, err
+	}
+
+	levelIDs := make([]uuid.UUID, len(path.Steps))
+	for i, step := range path.Steps {
+		levelIDs[i] = step.LevelID
+	}
+	words, learned, err := s.Repo.CountLevelWords(userID, levelIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &dto.LearningPathProgress{
+		ID:              path.ID,
+		Name:            extractLabel(&models.Label{En: path.En, Fr: path.Fr}, lang),
+		UnlockThreshold: s.unlockThreshold(path),
+		Completed:       true,
+		Steps:           make([]*dto.LearningPathStepProgress, len(path.Steps)),
+	}
+	unlocked := true
+	for i, step := range path.Steps {
+		stepProgress := &dto.LearningPathStepProgress{
+			LevelID:      step.LevelID,
+			Position:     step.Position,
+			LevelNames:   []string{},
+			WordCount:    words[step.LevelID],
+			LearnedCount: learned[step.LevelID],
+			Unlocked:     unlocked,
+		}
+		if step.Level != nil {
+			stepProgress.Category = extractLabel(&step.Level.Category, lang)
+			for _, levelName := range step.Level.LevelNames {
+				stepProgress.LevelNames = append(stepProgress.LevelNames, extractLabel(levelName, lang))
+			}
+		}
+		if stepProgress.WordCount > 0 {
+			stepProgress.Share = float64(stepProgress.LearnedCount) / float64(stepProgress.WordCount)
+		}
+		stepProgress.Completed = stepProgress.WordCount == 0 || stepProgress.Share >= progress.UnlockThreshold
+
+		if unlocked {
+			progress.CurrentLevelID = &path.Steps[i].LevelID
+		}
+		progress.Completed = progress.Completed && stepProgress.Completed
+		unlocked = unlocked && stepProgress.Completed
+		progress.Steps[i] = stepProgress
+	}
+	return progress, nil
+}
+
+// unlockThreshold gives the threshold of a path, the configured one unless the path overrides it
+func (s *LearningPathServiceImpl) unlockThreshold(path *models.LearningPath) float64 {
+	if path.UnlockThreshold > 0 {
+		return path.UnlockThreshold
+	}
+	if s.Config != nil && s.Config.UnlockThreshold > 0 {
+		return s.Config.UnlockThreshold
+	}
+	return defaultUnlockThreshold
+}
+
+// prepareSteps checks the threshold and the levels of a path, then numbers its steps from 1
+// Steps are ordered by the given positions, steps without position following them in their order in the list
+func (s *LearningPathServiceImpl) prepareSteps(path *models.LearningPath) error {
+	if path.UnlockThreshold < 0 || path.UnlockThreshold > 1 {
+		return ErrInvalidLearningPath
+	}
+
+	seen := make(map[uuid.UUID]bool, len(path.Steps))
+	for _, step := range path.Steps {
+		if seen[step.LevelID] {
+			return ErrInvalidLearningPath
+		}
+		seen[step.LevelID] = true
+
+		level, err := s.LevelRepo.ReadLevel(step.LevelID)
+		if err != nil {
+			return err
+		}
+		if level.IsCustom() {
+			return ErrInvalidLearningPath
+		}
+		step.Level = nil
+	}
+
+	sort.SliceStable(path.Steps, func(i, j int) bool {
+		pi, pj := path.Steps[i].Position, path.Steps[j].Position
+		if pi == 0 || pj == 0 {
+			return pi != 0 && pj == 0
+		}
+		return pi < pj
+	})
+	for i, step := range path.Steps {
+		step.Position = i + 1
+	}
+	return nil
+}
+
+// reload reads back a saved path to return its steps with their levels
+func (s *LearningPathServiceImpl) reload(path *models.LearningPath) error {
+	saved, err := s.Repo.ReadLearningPath(path.ID)
+	if err != nil {
+		return err
+	}
+	*path = *saved
+	return nil
+}
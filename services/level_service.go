@@ -1,47 +1,274 @@
 package services
 
 import (
+	"errors"
 	"github.com/google/uuid"
 	"github.com/xanagit/kotoquiz-api/models"
 	"github.com/xanagit/kotoquiz-api/repositories"
+	"gorm.io/gorm"
 )
 
+// ErrForbidden is returned when a user tries to change a level which is not one of their custom levels
+var ErrForbidden = errors.New("forbidden")
+
+// ErrInvalidLevel is returned when the visibility of a custom level is unknown or is not set by publishing the level
+var ErrInvalidLevel = errors.New("invalid level visibility")
+
 type LevelService interface {
-	ListLevels() ([]*models.Level, error)
+	ListLevels(userID string) ([]*models.Level, error)
 	ReadLevel(id uuid.UUID) (*models.Level, error)
 	CreateLevel(level *models.Level) error
 	UpdateLevel(level *models.Level) error
+	PatchLevel(id uuid.UUID, patch []byte, version int) (*models.Level, error)
 	DeleteLevel(id uuid.UUID) error
+	ReadUserLevel(userID string, id uuid.UUID) (*models.Level, error)
+	CreateUserLevel(userID string, level *models.Level) error
+	UpdateUserLevel(userID string, level *models.Level) error
+	DeleteUserLevel(userID string, id uuid.UUID) error
+	AddUserLevelWords(userID string, id uuid.UUID, wordIDs []uuid.UUID) (*models.Level, error)
+	RemoveUserLevelWord(userID string, id uuid.UUID, wordID uuid.UUID) error
 }
 
 type LevelServiceImpl struct {
-	Repo repositories.LevelRepository
+	Repo      repositories.LevelRepository
+	LabelRepo repositories.LabelRepository
 }
 
 // Make sure that LevelServiceImpl implements LevelService
 var _ LevelService = (*LevelServiceImpl)(nil)
 
-func (s *LevelServiceImpl) ListLevels() ([]*models.Level, error) {
-	return s.Repo.ListLevels()
+// ListLevels lists the built-in levels and the custom levels of the user
+func (s *LevelServiceImpl) ListLevels(userID string) ([]*models.Level, error) {
+	return s.Repo.ListLevels(userID)
 }
 
 func (s *LevelServiceImpl) ReadLevel(id uuid.UUID) (*models.Level, error) {
 	return s.Repo.ReadLevel(id)
 }
 
+// CreateLevel creates a built-in level
+// The category and the level names given with the ID of an existing label are reused as they are
 func (s *LevelServiceImpl) CreateLevel(level *models.Level) error {
 	level.ID = uuid.Nil
-	level.Category.Type = models.Category
-	for _, l := range level.LevelNames {
-		l.Type = models.LevelName
+	level.OwnerID = ""
+	if err := s.reuseLevelLabels(level, true); err != nil {
+		return err
 	}
+	prepareLevelLabels(level)
 	return s.Repo.CreateLevel(level)
 }
 
+// UpdateLevel updates any level, a custom level keeping its owner
 func (s *LevelServiceImpl) UpdateLevel(level *models.Level) error {
+	existing, err := s.Repo.ReadLevel(level.ID)
+	if err != nil {
+		return err
+	}
+	keepManagedFields(level, existing)
+	if existing.IsCustom() {
+		level.Type = existing.Type
+		keepOwnLabels(level, existing)
+	}
+	// The texts of the labels of a built-in level are only changed through the label endpoints
+	if err := s.reuseLevelLabels(level, !existing.IsCustom()); err != nil {
+		return err
+	}
+	prepareLevelLabels(level)
 	return s.Repo.UpdateLevel(level)
 }
 
+// PatchLevel applies a JSON merge patch to any level, the members missing from the patch being kept
+// When version is not 0 the level must still be at this version
+func (s *LevelServiceImpl) PatchLevel(id uuid.UUID, patch []byte, version int) (*models.Level, error) {
+	existing, err := s.Repo.ReadLevel(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(existing.Version, version); err != nil {
+		return nil, err
+	}
+	level := *existing
+	if err := applyMergePatch(&level, patch); err != nil {
+		return nil, err
+	}
+	level.ID = id
+	// The patch is based on the version read above
+	level.Version = existing.Version
+	if err := s.UpdateLevel(&level); err != nil {
+		return nil, err
+	}
+	return s.Repo.ReadLevel(id)
+}
+
 func (s *LevelServiceImpl) DeleteLevel(id uuid.UUID) error {
 	return s.Repo.DeleteLevel(id)
 }
+
+// ReadUserLevel reads a level the user can see: a built-in level, one of their levels or a level shared by another user
+func (s *LevelServiceImpl) ReadUserLevel(userID string, id uuid.UUID) (*models.Level, error) {
+	level, err := s.Repo.ReadLevel(id)
+	if err != nil {
+		return nil, err
+	}
+	if !level.IsVisibleTo(userID) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return level, nil
+}
+
+// CreateUserLevel creates a private custom level owned by the user, which can then be published to share it
+func (s *LevelServiceImpl) CreateUserLevel(userID string, level *models.Level) error {
+	level.ID = uuid.Nil
+	level.Type = models.CustomLevel
+	level.OwnerID = userID
+	if err := validateVisibility(level); err != nil {
+		return err
+	}
+
+	// The labels of a custom level belong to it, they are always created with the level
+	level.Category.ID = uuid.Nil
+	for _, l := range level.LevelNames {
+		l.ID = uuid.Nil
+	}
+	prepareLevelLabels(level)
+	return s.Repo.CreateLevel(level)
+}
+
+// UpdateUserLevel updates a custom level owned by the user
+func (s *LevelServiceImpl) UpdateUserLevel(userID string, level *models.Level) error {
+	existing, err := s.readOwnedLevel(userID, level.ID)
+	if err != nil {
+		return err
+	}
+	level.Type = existing.Type
+	keepManagedFields(level, existing)
+
+	keepOwnLabels(level, existing)
+	prepareLevelLabels(level)
+	return s.Repo.UpdateLevel(level)
+}
+
+// DeleteUserLevel deletes a custom level owned by the user, its words are kept
+func (s *LevelServiceImpl) DeleteUserLevel(userID string, id uuid.UUID) error {
+	if _, err := s.readOwnedLevel(userID, id); err != nil {
+		return err
+	}
+	return s.Repo.DeleteLevel(id)
+}
+
+// AddUserLevelWords adds words to a custom level owned by the user and returns the updated level
+func (s *LevelServiceImpl) AddUserLevelWords(userID string, id uuid.UUID, wordIDs []uuid.UUID) (*models.Level, error) {
+	if _, err := s.readOwnedLevel(userID, id); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.AddLevelWords(id, wordIDs); err != nil {
+		return nil, err
+	}
+	return s.Repo.ReadLevel(id)
+}
+
+// RemoveUserLevelWord removes a word from a custom level owned by the user
+func (s *LevelServiceImpl) RemoveUserLevelWord(userID string, id uuid.UUID, wordID uuid.UUID) error {
+	if _, err := s.readOwnedLevel(userID, id); err != nil {
+		return err
+	}
+	return s.Repo.RemoveLevelWord(id, wordID)
+}
+
+// readOwnedLevel reads a level the user is allowed to change
+func (s *LevelServiceImpl) readOwnedLevel(userID string, id uuid.UUID) (*models.Level, error) {
+	return readOwnedLevel(s.Repo, userID, id)
+}
+
+// readOwnedLevel reads a level the user is allowed to change
+// Levels the user cannot see are reported as missing, the others as forbidden unless the user owns them
+func readOwnedLevel(repo repositories.LevelRepository, userID string, id uuid.UUID) (*models.Level, error) {
+	level, err := repo.ReadLevel(id)
+	if err != nil {
+		return nil, err
+	}
+	if !level.IsVisibleTo(userID) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if !level.IsCustom() || level.OwnerID != userID {
+		return nil, ErrForbidden
+	}
+	return level, nil
+}
+
+// keepManagedFields keeps the owner of a level and its sharing state, which are not changed by an update
+// The visibility is only changed by publishing or unpublishing the level, together with its share code
+func keepManagedFields(level *models.Level, existing *models.Level) {
+	level.OwnerID = existing.OwnerID
+	level.Visibility = existing.Visibility
+	level.ShareCode = existing.ShareCode
+	level.Revision = existing.Revision
+	level.SourceLevelID = existing.SourceLevelID
+	level.SourceRevision = existing.SourceRevision
+	level.CloneCount = existing.CloneCount
+	level.RatingCount = existing.RatingCount
+	level.RatingAverage = existing.RatingAverage
+}
+
+// reuseLevelLabels checks that the existing labels given to a level are a category and level names
+// When reuse is set, the texts of the existing labels replace the given ones
+func (s *LevelServiceImpl) reuseLevelLabels(level *models.Level, reuse bool) error {
+	labels := append([]*models.Label{&level.Category}, level.LevelNames...)
+	for i, label := range labels {
+		if label.ID == uuid.Nil {
+			continue
+		}
+		existing, err := s.LabelRepo.ReadLabel(label.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		expectedType := models.LevelName
+		if i == 0 {
+			expectedType = models.Category
+		}
+		if existing.Type != expectedType {
+			return ErrInvalidLabel
+		}
+		if reuse {
+			*label = *existing
+		}
+	}
+	return nil
+}
+
+// validateVisibility checks that a new custom level is private, a level being shared by publishing it
+func validateVisibility(level *models.Level) error {
+	if level.Visibility == "" {
+		level.Visibility = models.Private
+	}
+	if level.Visibility != models.Private {
+		return ErrInvalidLevel
+	}
+	return nil
+}
+
+// keepOwnLabels makes sure that a custom level only updates its own labels, other labels are created
+func keepOwnLabels(level *models.Level, existing *models.Level) {
+	level.Category.ID = existing.Category.ID
+	existingNames := make(map[uuid.UUID]bool, len(existing.LevelNames))
+	for _, l := range existing.LevelNames {
+		existingNames[l.ID] = true
+	}
+	for _, l := range level.LevelNames {
+		if !existingNames[l.ID] {
+			l.ID = uuid.Nil
+		}
+	}
+}
+
+// prepareLevelLabels types the category and the names of the level
+func prepareLevelLabels(level *models.Level) {
+	level.Category.Type = models.Category
+	for _, l := range level.LevelNames {
+		l.Type = models.LevelName
+	}
+}
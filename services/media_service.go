@@ -0,0 +1,261 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/config"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"github.com/xanagit/kotoquiz-api/repositories"
+	"github.com/xanagit/kotoquiz-api/storage"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+)
+
+// ErrMediaTooLarge is returned when an uploaded media exceeds the configured size limit
+var ErrMediaTooLarge = errors.New("media exceeds the maximum size")
+
+// ErrUnsupportedMediaType is returned when an uploaded media is not of an accepted type
+// or when its content does not match its declared type
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// mediaExtensions gives the file extension of the stored media for each supported content type
+var mediaExtensions = map[string]string{
+	"audio/mpeg": ".mp3",
+	"audio/ogg":  ".ogg",
+	"audio/wav":  ".wav",
+	"audio/webm": ".webm",
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// sniffedTypeAliases maps the types detected by http.DetectContentType to the supported content types
+var sniffedTypeAliases = map[string]string{
+	"audio/wave":      "audio/wav",
+	"audio/x-wav":     "audio/wav",
+	"application/ogg": "audio/ogg",
+	"video/webm":      "audio/webm",
+	"audio/mp3":       "audio/mpeg",
+}
+
+type MediaService interface {
+	UploadWordAudio(wordID uuid.UUID, contentType string, content io.Reader) (*dto.MediaDTO, error)
+	DeleteWordAudio(wordID uuid.UUID) error
+	UploadWordImage(wordID uuid.UUID, contentType string, content io.Reader) (*dto.MediaDTO, error)
+	DeleteWordImage(wordID uuid.UUID) error
+	DeleteWordMedia(word *models.Word) error
+}
+
+type MediaServiceImpl struct {
+	Storage  storage.MediaStorage
+	WordRepo repositories.WordRepository
+	Config   *config.MediaConfig
+}
+
+// Make sure that MediaServiceImpl implements MediaService
+var _ MediaService = (*MediaServiceImpl)(nil)
+
+// UploadWordAudio stores an audio file and attaches it to the word in place of the previous one
+// Each upload gets a new key so that cached or signed URLs of the previous audio never serve the new one
+func (s *MediaServiceImpl) UploadWordAudio(wordID uuid.UUID, contentType string, content io.Reader) (*dto.MediaDTO, error) {
+	word, data, contentType, err := s.readUpload(wordID, contentType, content, s.Config.MaxAudioSize, s.Config.AudioTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("words/%s/audio-%s%s", wordID, uuid.New(), mediaExtensions[contentType])
+	if err := s.Storage.Save(key, contentType, bytes.NewReader(data), int64(len(data))); err != nil {
+		return nil, err
+	}
+	if err := s.WordRepo.UpdateWordMedia(wordID, key, word.ImageKey); err != nil {
+		_ = s.Storage.Delete(key)
+		return nil, err
+	}
+	// The word no longer references the previous audio, failing to delete it only leaves an orphan file
+	if word.AudioKey != "" {
+		_ = s.Storage.Delete(word.AudioKey)
+	}
+
+	url, err := s.Storage.URL(key)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.MediaDTO{Key: key, URL: url}, nil
+}
+
+// DeleteWordAudio detaches the audio from the word and removes it from the storage
+func (s *MediaServiceImpl) DeleteWordAudio(wordID uuid.UUID) error {
+	word, err := s.WordRepo.ReadWord(wordID)
+	if err != nil || word.AudioKey == "" {
+		return err
+	}
+
+	if err := s.WordRepo.UpdateWordMedia(wordID, "", word.ImageKey); err != nil {
+		return err
+	}
+	return s.Storage.Delete(word.AudioKey)
+}
+
+// UploadWordImage cleans and resizes an image, then attaches it to the word in place of the previous one
+// Images are stored under the hash of their content: an image already used by another word is shared, not stored again
+func (s *MediaServiceImpl) UploadWordImage(wordID uuid.UUID, contentType string, content io.Reader) (*dto.MediaDTO, error) {
+	word, data, contentType, err := s.readUpload(wordID, contentType, content, s.Config.MaxImageSize, s.Config.ImageTypes)
+	if err != nil {
+		return nil, err
+	}
+	processed, err := processImage(data, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	if processed.key != word.ImageKey {
+		stored, err := s.storeImage(processed)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.WordRepo.UpdateWordMedia(wordID, word.AudioKey, processed.key); err != nil {
+			if stored {
+				_ = s.deleteImage(processed.key)
+			}
+			return nil, err
+		}
+		// Failing to delete the previous image only leaves orphan files
+		_ = s.releaseImage(word.ImageKey)
+	}
+
+	urls, err := imageURLs(s.Storage, processed.key)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.MediaDTO{Key: processed.key, URL: urls.Large, ImageURLs: urls}, nil
+}
+
+// DeleteWordImage detaches the uploaded image from the word and removes it from the storage unless other words use it
+func (s *MediaServiceImpl) DeleteWordImage(wordID uuid.UUID) error {
+	word, err := s.WordRepo.ReadWord(wordID)
+	if err != nil || word.ImageKey == "" {
+		return err
+	}
+
+	if err := s.WordRepo.UpdateWordMedia(wordID, word.AudioKey, ""); err != nil {
+		return err
+	}
+	return s.releaseImage(word.ImageKey)
+}
+
+// DeleteWordMedia removes from the storage the media of a word which has been deleted
+func (s *MediaServiceImpl) DeleteWordMedia(word *models.Word) error {
+	if word.AudioKey != "" {
+		if err := s.Storage.Delete(word.AudioKey); err != nil {
+			return err
+		}
+	}
+	return s.releaseImage(word.ImageKey)
+}
+
+// readUpload reads an uploaded media of the word, checking its size and its type
+// It returns the word, the content and its normalized content type
+func (s *MediaServiceImpl) readUpload(wordID uuid.UUID, contentType string, content io.Reader, maxSize int64, acceptedTypes []string) (*models.Word, []byte, string, error) {
+	word, err := s.WordRepo.ReadWord(wordID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	// Read one byte more than the limit to detect oversized content without trusting the declared size
+	data, err := io.ReadAll(io.LimitReader(content, maxSize+1))
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, nil, "", ErrMediaTooLarge
+	}
+	contentType, err = validateMediaType(contentType, data, acceptedTypes)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return word, data, contentType, nil
+}
+
+// storeImage saves the variants of an image, unless another word already uses the same image
+// It tells whether the variants were saved
+func (s *MediaServiceImpl) storeImage(processed *processedImage) (bool, error) {
+	count, err := s.WordRepo.CountWordsByImageKey(processed.key)
+	if err != nil || count > 0 {
+		return false, err
+	}
+
+	for _, variant := range processed.variants {
+		key := imageVariantKey(processed.key, variant.size)
+		if err := s.Storage.Save(key, processed.contentType, bytes.NewReader(variant.data), int64(len(variant.data))); err != nil {
+			_ = s.deleteImage(processed.key)
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// releaseImage deletes the variants of an image no word uses anymore
+func (s *MediaServiceImpl) releaseImage(imageKey string) error {
+	if imageKey == "" {
+		return nil
+	}
+	count, err := s.WordRepo.CountWordsByImageKey(imageKey)
+	if err != nil || count > 0 {
+		return err
+	}
+	return s.deleteImage(imageKey)
+}
+
+// deleteImage deletes every variant of an image from the storage
+func (s *MediaServiceImpl) deleteImage(imageKey string) error {
+	var keys []string
+	for _, size := range imageSizes {
+		if key := imageVariantKey(imageKey, size.name); !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		if err := s.Storage.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateMediaType checks that the declared content type is accepted and matches the content
+// It returns the normalized content type, without parameters
+func validateMediaType(declaredType string, data []byte, acceptedTypes []string) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(declaredType)
+	if err != nil {
+		return "", ErrUnsupportedMediaType
+	}
+	if alias, exists := sniffedTypeAliases[mediaType]; exists {
+		mediaType = alias
+	}
+	if _, supported := mediaExtensions[mediaType]; !supported || !slices.Contains(acceptedTypes, mediaType) {
+		return "", ErrUnsupportedMediaType
+	}
+
+	if sniffMediaType(data) != mediaType {
+		return "", ErrUnsupportedMediaType
+	}
+	return mediaType, nil
+}
+
+// sniffMediaType detects the type of the content from its first bytes
+func sniffMediaType(data []byte) string {
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	if alias, exists := sniffedTypeAliases[sniffed]; exists {
+		return alias
+	}
+	// MP3 files without ID3 tag start directly with an MPEG audio frame header
+	if sniffed == "application/octet-stream" && len(data) > 1 && data[0] == 0xFF && data[1]&0xE0 == 0xE0 {
+		return "audio/mpeg"
+	}
+	return sniffed
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/xanagit/kotoquiz-api/repositories"
+)
+
+// ErrInvalidMergePatch is returned when a merge patch is not a JSON object, or does not fit the patched resource
+var ErrInvalidMergePatch = errors.New("invalid merge patch")
+
+// ErrVersionMismatch is returned when the version required by the client is not the current version of the resource
+var ErrVersionMismatch = repositories.ErrVersionMismatch
+
+// applyMergePatch applies a JSON merge patch (RFC 7396) to the JSON form of a document
+// Members set to null are removed, objects are merged recursively and any other value, arrays included, replaces the previous one
+func applyMergePatch[T any](document *T, patch []byte) error {
+	var patchValue any
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return ErrInvalidMergePatch
+	}
+	if _, isObject := patchValue.(map[string]any); !isObject {
+		return ErrInvalidMergePatch
+	}
+
+	raw, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	var target any
+	if err := json.Unmarshal(raw, &target); err != nil {
+		return err
+	}
+	merged, err := json.Marshal(mergePatch(target, patchValue))
+	if err != nil {
+		return err
+	}
+
+	var patched T
+	if err := json.Unmarshal(merged, &patched); err != nil {
+		return ErrInvalidMergePatch
+	}
+	*document = patched
+	return nil
+}
+
+// mergePatch merges a decoded patch into a decoded target as described by RFC 7396
+func mergePatch(target any, patch any) any {
+	patchObject, isObject := patch.(map[string]any)
+	if !isObject {
+		return patch
+	}
+	targetObject, isObject := target.(map[string]any)
+	if !isObject {
+		targetObject = make(map[string]any, len(patchObject))
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+		} else {
+			targetObject[key] = mergePatch(targetObject[key], value)
+		}
+	}
+	return targetObject
+}
+
+// checkVersion checks the version required by the client, 0 meaning any version
+func checkVersion(current int, required int) error {
+	if required != 0 && required != current {
+		return ErrVersionMismatch
+	}
+	return nil
+}
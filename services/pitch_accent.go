@@ -0,0 +1,63 @@
+package services
+
+import (
+	"github.com/xanagit/kotoquiz-api/models"
+	"strings"
+)
+
+// smallKana are the kana merged with the previous one into a single mora (きょ, ファ)
+const smallKana = "ゃゅょぁぃぅぇぉゎャュョァィゥェォヮ"
+
+// splitMorae cuts a kana reading into morae, ん, っ and ー counting as morae of their own
+func splitMorae(reading string) []string {
+	var morae []string
+	for _, r := range strings.Join(strings.Fields(reading), "") {
+		if len(morae) > 0 && strings.ContainsRune(smallKana, r) {
+			morae[len(morae)-1] += string(r)
+			continue
+		}
+		morae = append(morae, string(r))
+	}
+	return morae
+}
+
+// pitchPattern names the accent of a word of moraCount morae falling after the downstep mora
+func pitchPattern(downstep int, moraCount int) models.PitchPattern {
+	switch {
+	case downstep <= 0:
+		return models.Heiban
+	case downstep == 1:
+		return models.Atamadaka
+	case downstep >= moraCount:
+		return models.Odaka
+	default:
+		return models.Nakadaka
+	}
+}
+
+// pitchHeights tells for each mora whether it is pronounced high, then whether a following particle is
+// The first mora is low unless the accent falls right after it, and morae are low after the downstep
+func pitchHeights(downstep int, moraCount int) ([]bool, bool) {
+	heights := make([]bool, moraCount)
+	for i := range heights {
+		mora := i + 1
+		switch {
+		case downstep == 1:
+			heights[i] = mora == 1
+		case downstep <= 0:
+			heights[i] = mora > 1
+		default:
+			heights[i] = mora > 1 && mora <= downstep
+		}
+	}
+	return heights, downstep <= 0
+}
+
+// preparePitchAccents numbers the pitch accents of a word and names their pattern from its reading
+func preparePitchAccents(word *models.Word) {
+	moraCount := len(splitMorae(word.Yomi))
+	for i, accent := range word.PitchAccents {
+		accent.Position = i
+		accent.Pattern = pitchPattern(accent.Downstep, moraCount)
+	}
+}
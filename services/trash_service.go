@@ -0,0 +1,139 @@
+package services
+
+import (
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/config"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/repositories"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultRetentionDays is used when no retention period is configured
+const defaultRetentionDays = 30
+
+type TrashService interface {
+	ListTrash(kind dto.TrashKind) ([]*dto.TrashItem, error)
+	RestoreWord(id uuid.UUID) error
+	RestoreLabel(id uuid.UUID) error
+	RestoreLevel(id uuid.UUID) error
+	PurgeTrash() (*dto.TrashPurgeReport, error)
+}
+
+type TrashServiceImpl struct {
+	WordRepo     repositories.WordRepository
+	LabelRepo    repositories.LabelRepository
+	LevelRepo    repositories.LevelRepository
+	RelationRepo repositories.WordRelationRepository
+	MediaService MediaService
+	Config       *config.TrashConfig
+}
+
+// Make sure that TrashServiceImpl implements TrashService
+var _ TrashService = (*TrashServiceImpl)(nil)
+
+// ListTrash lists the deleted items of the given kind, or of every kind when it is empty, the last deleted first
+func (s *TrashServiceImpl) ListTrash(kind dto.TrashKind) ([]*dto.TrashItem, error) {
+	items := []*dto.TrashItem{}
+	if kind == "" || kind == dto.TrashWord {
+		words, err := s.WordRepo.ListTrashedWords()
+		if err != nil {
+			return nil, err
+		}
+		for _, word := range words {
+			name := strings.TrimSpace(word.Kanji + " " + word.Yomi)
+			items = append(items, s.newTrashItem(word.ID, dto.TrashWord, "", name, word.DeletedAt.Time))
+		}
+	}
+	if kind == "" || kind == dto.TrashLabel {
+		labels, err := s.LabelRepo.ListTrashedLabels()
+		if err != nil {
+			return nil, err
+		}
+		for _, label := range labels {
+			items = append(items, s.newTrashItem(label.ID, dto.TrashLabel, string(label.Type), label.En, label.DeletedAt.Time))
+		}
+	}
+	if kind == "" || kind == dto.TrashLevel {
+		levels, err := s.LevelRepo.ListTrashedLevels()
+		if err != nil {
+			return nil, err
+		}
+		for _, level := range levels {
+			items = append(items, s.newTrashItem(level.ID, dto.TrashLevel, string(level.Type), level.Category.En, level.DeletedAt.Time))
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+// RestoreWord takes a word out of the trash with its links, and detects its homophones again
+func (s *TrashServiceImpl) RestoreWord(id uuid.UUID) error {
+	if err := s.WordRepo.RestoreWord(id); err != nil {
+		return err
+	}
+	return s.RelationRepo.RefreshWordHomophones(id)
+}
+
+// RestoreLabel takes a label out of the trash with its links to the words and the levels, and its sub-tags
+func (s *TrashServiceImpl) RestoreLabel(id uuid.UUID) error {
+	return s.LabelRepo.RestoreLabel(id)
+}
+
+// RestoreLevel takes a level out of the trash with its words, its level names and its category
+func (s *TrashServiceImpl) RestoreLevel(id uuid.UUID) error {
+	return s.LevelRepo.RestoreLevel(id)
+}
+
+// PurgeTrash permanently deletes the items kept in the trash for longer than the retention period
+// Words are purged first, with their media and the learning histories of the users, then levels and labels
+func (s *TrashServiceImpl) PurgeTrash() (*dto.TrashPurgeReport, error) {
+	before := time.Now().Add(-s.retention())
+	report := &dto.TrashPurgeReport{}
+
+	words, err := s.WordRepo.PurgeWords(before)
+	report.Words = len(words)
+	// The media of the purged words are removed even when a word could not be purged
+	for _, word := range words {
+		if mediaErr := s.MediaService.DeleteWordMedia(word); mediaErr != nil && err == nil {
+			err = mediaErr
+		}
+	}
+	if err != nil {
+		return report, err
+	}
+
+	levels, err := s.LevelRepo.PurgeLevels(before)
+	report.Levels = int(levels)
+	if err != nil {
+		return report, err
+	}
+	labels, err := s.LabelRepo.PurgeLabels(before)
+	report.Labels = int(labels)
+	return report, err
+}
+
+// retention gives how long the deleted items are kept in the trash
+func (s *TrashServiceImpl) retention() time.Duration {
+	days := defaultRetentionDays
+	if s.Config != nil && s.Config.RetentionDays > 0 {
+		days = s.Config.RetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// newTrashItem describes a deleted item, with the time it will be purged at
+func (s *TrashServiceImpl) newTrashItem(id uuid.UUID, kind dto.TrashKind, itemType string, name string, deletedAt time.Time) *dto.TrashItem {
+	return &dto.TrashItem{
+		ID:        id,
+		Kind:      kind,
+		Type:      itemType,
+		Name:      name,
+		DeletedAt: deletedAt,
+		PurgeAt:   deletedAt.Add(s.retention()),
+	}
+}
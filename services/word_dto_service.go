@@ -4,26 +4,39 @@ import (
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
 	"github.com/xanagit/kotoquiz-api/repositories"
+	"github.com/xanagit/kotoquiz-api/storage"
+	"slices"
 )
 
 type WordDtoService interface {
-	ListWordsIDs(userID uuid.UUID, tagIds []string, levelNameIds []string, nb int) (*dto.WordIdsList, error)
-	ListWordsDtoByIDs(ids []uuid.UUID, lang string) ([]*dto.WordDTO, error)
-	ReadWord(id uuid.UUID, lang string) (*dto.WordDTO, error)
+	ListWordsIDs(userID uuid.UUID, filter *dto.WordFilter, nb int) (*dto.WordIdsList, error)
+	ListWordsDtoByIDs(ids []uuid.UUID, lang string, includes []string) ([]*dto.WordDTO, error)
+	ReadWord(id uuid.UUID, lang string, includes []string) (*dto.WordDTO, error)
+	ListCatalogue(filter *dto.WordFilter, limit int, offset int, lang string, includes []string) (*dto.WordPage, error)
 }
 
 type WordDtoServiceImpl struct {
 	WordRepo            repositories.WordRepository
 	LearningHistoryRepo repositories.WordLearningHistoryRepository
+	ExampleRepo         repositories.ExampleSentenceRepository
+	RelationRepo        repositories.WordRelationRepository
+	Media               storage.MediaStorage
 }
 
 // Make sure that WordDtoServiceImpl implements WordDtoService
 var _ WordDtoService = (*WordDtoServiceImpl)(nil)
 
-func (s *WordDtoServiceImpl) ListWordsIDs(userID uuid.UUID, tagIds []string, levelNameIds []string, nb int) (*dto.WordIdsList, error) {
+// ListWordsIDs selects words to learn among the words matching the filter
+// Words are picked at random, or from the most common ones when the filter is sorted, favoring the words due for review
+func (s *WordDtoServiceImpl) ListWordsIDs(userID uuid.UUID, filter *dto.WordFilter, nb int) (*dto.WordIdsList, error) {
+	if filter.Sort != "" {
+		return s.selectCommonWordsFirst(userID, filter, nb)
+	}
+
 	// Fetch and validate words
-	allWordIDs, err := s.fetchAndValidateWords(tagIds, levelNameIds, nb)
+	allWordIDs, err := s.fetchAndValidateWords(filter, nb)
 	if err != nil || len(allWordIDs.Ids) == 0 {
 		return allWordIDs, err
 	}
@@ -37,7 +50,7 @@ func (s *WordDtoServiceImpl) ListWordsIDs(userID uuid.UUID, tagIds []string, lev
 	return s.processWordsWithLearningHistory(userID, allWordIDs.Ids, nb)
 }
 
-func (s *WordDtoServiceImpl) ListWordsDtoByIDs(ids []uuid.UUID, lang string) ([]*dto.WordDTO, error) {
+func (s *WordDtoServiceImpl) ListWordsDtoByIDs(ids []uuid.UUID, lang string, includes []string) ([]*dto.WordDTO, error) {
 	if ids == nil || len(ids) == 0 {
 		return nil, fmt.Errorf("no IDs provided")
 	}
@@ -52,18 +65,136 @@ func (s *WordDtoServiceImpl) ListWordsDtoByIDs(ids []uuid.UUID, lang string) ([]
 	wordDTOs := make([]*dto.WordDTO, len(words))
 	for i, word := range words {
 		wordDTOs[i] = mapWordToDTO(word, lang)
+		if err := s.mapWordMedia(wordDTOs[i], word); err != nil {
+			return nil, err
+		}
+	}
+
+	// Add the optional sections
+	if err := s.includeSections(wordDTOs, lang, includes); err != nil {
+		return nil, err
 	}
 
 	return wordDTOs, nil
 }
 
-func (s *WordDtoServiceImpl) ReadWord(id uuid.UUID, lang string) (*dto.WordDTO, error) {
+// ListCatalogue returns a page of the words matching the filter, in the order requested by the filter
+func (s *WordDtoServiceImpl) ListCatalogue(filter *dto.WordFilter, limit int, offset int, lang string, includes []string) (*dto.WordPage, error) {
+	wordIDs, total, err := s.WordRepo.ListWordsIdsPage(filter, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &dto.WordPage{Words: []*dto.WordDTO{}, Total: total, Limit: limit, Offset: offset}
+	if len(wordIDs) == 0 {
+		return page, nil
+	}
+
+	ids := make([]uuid.UUID, len(wordIDs))
+	positions := make(map[uuid.UUID]int, len(wordIDs))
+	for i, wordID := range wordIDs {
+		if ids[i], err = uuid.Parse(wordID); err != nil {
+			return nil, err
+		}
+		positions[ids[i]] = i
+	}
+	words, err := s.ListWordsDtoByIDs(ids, lang, includes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Words are fetched in no particular order, restore the order of the page
+	slices.SortFunc(words, func(a, b *dto.WordDTO) int {
+		return positions[a.ID] - positions[b.ID]
+	})
+	page.Words = words
+	return page, nil
+}
+
+func (s *WordDtoServiceImpl) ReadWord(id uuid.UUID, lang string, includes []string) (*dto.WordDTO, error) {
 	word, err := s.WordRepo.ReadWord(id)
 	if err != nil {
 		return nil, err
 	}
 
 	wordDTO := mapWordToDTO(word, lang)
+	if err := s.mapWordMedia(wordDTO, word); err != nil {
+		return nil, err
+	}
+	if err := s.includeSections([]*dto.WordDTO{wordDTO}, lang, includes); err != nil {
+		return nil, err
+	}
 
 	return wordDTO, nil
 }
+
+// mapWordMedia resolves the URLs of the media uploaded for the word
+// An uploaded image replaces the external image URL of the word
+func (s *WordDtoServiceImpl) mapWordMedia(wordDTO *dto.WordDTO, word *models.Word) error {
+	var err error
+	if word.AudioKey != "" {
+		if wordDTO.AudioURL, err = s.Media.URL(word.AudioKey); err != nil {
+			return err
+		}
+	}
+	if word.ImageKey != "" {
+		if wordDTO.ImageURLs, err = imageURLs(s.Media, word.ImageKey); err != nil {
+			return err
+		}
+		wordDTO.ImageURL = wordDTO.ImageURLs.Large
+	}
+	return nil
+}
+
+// includeSections fills the optional sections of the word DTOs requested with the include query parameter
+func (s *WordDtoServiceImpl) includeSections(wordDTOs []*dto.WordDTO, lang string, includes []string) error {
+	if slices.Contains(includes, dto.IncludeExamples) {
+		if err := s.includeExamples(wordDTOs, lang); err != nil {
+			return err
+		}
+	}
+	if slices.Contains(includes, dto.IncludeRelated) {
+		return s.includeRelated(wordDTOs, lang)
+	}
+	return nil
+}
+
+// includeExamples fetches the example sentences of all the words at once
+func (s *WordDtoServiceImpl) includeExamples(wordDTOs []*dto.WordDTO, lang string) error {
+	wordIDs := make([]uuid.UUID, len(wordDTOs))
+	for i, wordDTO := range wordDTOs {
+		wordIDs[i] = wordDTO.ID
+	}
+	examplesByWord, err := s.ExampleRepo.ListExampleSentencesByWordIds(wordIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, wordDTO := range wordDTOs {
+		wordDTO.Examples = []*dto.ExampleSentenceDTO{}
+		for _, sentence := range examplesByWord[wordDTO.ID] {
+			wordDTO.Examples = append(wordDTO.Examples, mapExampleSentenceToDTO(sentence, lang))
+		}
+	}
+	return nil
+}
+
+// includeRelated fetches the relations of all the words at once, each related word being seen from the word it is listed under
+func (s *WordDtoServiceImpl) includeRelated(wordDTOs []*dto.WordDTO, lang string) error {
+	wordIDs := make([]uuid.UUID, len(wordDTOs))
+	for i, wordDTO := range wordDTOs {
+		wordIDs[i] = wordDTO.ID
+	}
+	relationsByWord, err := s.RelationRepo.ListWordRelationsByWordIds(wordIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, wordDTO := range wordDTOs {
+		wordDTO.Related = []*dto.RelatedWordDTO{}
+		for _, relation := range relationsByWord[wordDTO.ID] {
+			wordDTO.Related = append(wordDTO.Related, mapRelatedWordToDTO(wordDTO.ID, relation, lang))
+		}
+	}
+	return nil
+}
@@ -148,9 +148,9 @@ func shuffleAndLimit(ids []string, limit int) []string {
 	return shuffled
 }
 
-func (s *WordDtoServiceImpl) fetchAndValidateWords(tagIds []string, levelNameIds []string, nb int) (*dto.WordIdsList, error) {
-	// Fetch all IDs corresponding to tags and level names
-	allWordIDs, err := s.WordRepo.ListWordsIds(tagIds, levelNameIds, -1)
+func (s *WordDtoServiceImpl) fetchAndValidateWords(filter *dto.WordFilter, nb int) (*dto.WordIdsList, error) {
+	// Fetch all IDs corresponding to the filter
+	allWordIDs, err := s.WordRepo.ListWordsIds(filter, -1)
 	if err != nil {
 		return nil, err
 	}
@@ -164,6 +164,50 @@ func (s *WordDtoServiceImpl) fetchAndValidateWords(tagIds []string, levelNameIds
 	return &dto.WordIdsList{Ids: shuffleAndLimit(allWordIDs, nb)}, nil
 }
 
+// selectCommonWordsFirst selects the words following the order of the filter, so that common words are introduced first
+// Words due for review come first, then the words never seen in the order of the filter,
+// then the other words already seen
+func (s *WordDtoServiceImpl) selectCommonWordsFirst(userID uuid.UUID, filter *dto.WordFilter, nb int) (*dto.WordIdsList, error) {
+	if nb <= 0 {
+		return &dto.WordIdsList{Ids: []string{}}, nil
+	}
+	wordIDs, err := s.WordRepo.ListWordsIds(filter, -1)
+	if err != nil {
+		return nil, err
+	}
+	if userID == uuid.Nil {
+		return &dto.WordIdsList{Ids: wordIDs[:min(nb, len(wordIDs))]}, nil
+	}
+
+	// The histories of the user are fewer than the words of the dictionary, they are all fetched at once
+	histories, err := s.LearningHistoryRepo.GetUserHistories(userID)
+	if err != nil {
+		return nil, err
+	}
+	historyMap := makeHistoryMap(histories)
+
+	now := time.Now()
+	var due, unseen, seen []string
+	for _, wordID := range wordIDs {
+		history, exists := historyMap[wordID]
+		switch {
+		case !exists:
+			unseen = append(unseen, wordID)
+		case !history.NextReviewDate.After(now):
+			due = append(due, wordID)
+		default:
+			seen = append(seen, wordID)
+		}
+	}
+
+	result := s.prioritizeWords(due, histories, nb)
+	result = append(result, unseen[:min(nb-len(result), len(unseen))]...)
+	if len(result) < nb {
+		result = append(result, s.prioritizeWords(seen, histories, nb-len(result))...)
+	}
+	return &dto.WordIdsList{Ids: result}, nil
+}
+
 func (s *WordDtoServiceImpl) processWordsWithLearningHistory(userID uuid.UUID, wordIDs []string, nb int) (*dto.WordIdsList, error) {
 	// Fetch learning histories
 	histories, err := s.LearningHistoryRepo.GetHistoriesByWordIDs(userID, wordIDs)
@@ -1,30 +1,69 @@
 package services
 
 import (
+	"errors"
 	"github.com/google/uuid"
 	"github.com/xanagit/kotoquiz-api/dto"
 	"github.com/xanagit/kotoquiz-api/models"
 	"github.com/xanagit/kotoquiz-api/repositories"
+	"slices"
 )
 
+// ErrInvalidConjugationForm is returned when a conjugation result does not designate a known form
+var ErrInvalidConjugationForm = errors.New("invalid conjugation form")
+
 type WordLearningHistoryService interface {
 	ProcessQuizResults(userID string, results []dto.WordQuizResult) error
 }
 
 type WordLearningHistoryServiceImpl struct {
-	Repo repositories.WordLearningHistoryRepository
+	Repo            repositories.WordLearningHistoryRepository
+	ConjugationRepo repositories.ConjugationLearningHistoryRepository
+	WordRepo        repositories.WordRepository
+	ExampleRepo     repositories.ExampleSentenceRepository
 }
 
 // Make sure that WordLearningHistoryServiceImpl implements WordLearningHistoryService
 var _ WordLearningHistoryService = (*WordLearningHistoryServiceImpl)(nil)
 
+// ProcessQuizResults grades the answers and updates the learning histories of the user
+// Conjugation results update the history of the asked form instead of the history of the word
 func (s *WordLearningHistoryServiceImpl) ProcessQuizResults(userID string, results []dto.WordQuizResult) error {
-	// Build list of word IDs and map of results (word ID -> WordQuizResult)
-	wordIDs := make([]uuid.UUID, len(results))
-	resultsMap := make(map[uuid.UUID]*dto.WordQuizResult)
-	for i, result := range results {
-		wordIDs[i] = result.WordID
-		resultsMap[result.WordID] = &results[i]
+	for _, result := range results {
+		if result.Mode == dto.ConjugationMode && !result.Form.IsValid() {
+			return ErrInvalidConjugationForm
+		}
+	}
+
+	// Grade typed answers before computing statistics
+	if err := s.gradeResults(results); err != nil {
+		return err
+	}
+
+	var wordResults, conjugationResults []dto.WordQuizResult
+	for _, result := range results {
+		if result.Mode == dto.ConjugationMode {
+			conjugationResults = append(conjugationResults, result)
+		} else {
+			wordResults = append(wordResults, result)
+		}
+	}
+	if err := s.processConjugationResults(userID, conjugationResults); err != nil {
+		return err
+	}
+	if len(wordResults) == 0 {
+		return nil
+	}
+	results = wordResults
+
+	// Group the results by word, a word can be asked several times in the same quiz (e.g. meaning and reading)
+	var wordIDs []uuid.UUID
+	resultsByWord := make(map[uuid.UUID][]dto.WordQuizResult)
+	for _, result := range results {
+		if _, exists := resultsByWord[result.WordID]; !exists {
+			wordIDs = append(wordIDs, result.WordID)
+		}
+		resultsByWord[result.WordID] = append(resultsByWord[result.WordID], result)
 	}
 
 	historiesMap, err := s.Repo.GetHistories(userID, wordIDs)
@@ -34,21 +73,20 @@ func (s *WordLearningHistoryServiceImpl) ProcessQuizResults(userID string, resul
 
 	var historiesToUpdate []*models.WordLearningHistory
 	var historiesToCreate []*models.WordLearningHistory
-	for _, result := range results {
-		history, exists := historiesMap[result.WordID]
+	for _, wordID := range wordIDs {
+		history, exists := historiesMap[wordID]
 		if !exists {
 			history = &models.WordLearningHistory{
 				UserID: userID,
-				WordID: result.WordID,
+				WordID: wordID,
 			}
 			historiesToCreate = append(historiesToCreate, history)
 		} else {
 			historiesToUpdate = append(historiesToUpdate, history)
 		}
-		s.updateHistoryBasicInfo(history)
-		s.updateHistoryStats(history, resultsMap[history.WordID].Status)
-		s.updateLearningStatus(history)
-		s.calculateNextReviewDate(history)
+		for _, result := range resultsByWord[wordID] {
+			s.updateLearningStats(&history.LearningStats, result.Status)
+		}
 	}
 	err = s.Repo.UpdateHistories(historiesToUpdate)
 	if err != nil {
@@ -61,3 +99,47 @@ func (s *WordLearningHistoryServiceImpl) ProcessQuizResults(userID string, resul
 
 	return nil
 }
+
+// processConjugationResults updates the histories of the forms asked in conjugation mode
+func (s *WordLearningHistoryServiceImpl) processConjugationResults(userID string, results []dto.WordQuizResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	wordIDs := make([]uuid.UUID, len(results))
+	for i, result := range results {
+		wordIDs[i] = result.WordID
+	}
+	historiesMap, err := s.ConjugationRepo.GetHistories(userID, wordIDs)
+	if err != nil {
+		return err
+	}
+
+	var historiesToUpdate []*models.ConjugationLearningHistory
+	var historiesToCreate []*models.ConjugationLearningHistory
+	for _, result := range results {
+		var history *models.ConjugationLearningHistory
+		for _, h := range historiesMap[result.WordID] {
+			if h.Form == result.Form {
+				history = h
+			}
+		}
+		if history == nil {
+			history = &models.ConjugationLearningHistory{
+				UserID: userID,
+				WordID: result.WordID,
+				Form:   result.Form,
+			}
+			historiesMap[result.WordID] = append(historiesMap[result.WordID], history)
+			historiesToCreate = append(historiesToCreate, history)
+		} else if !slices.Contains(historiesToUpdate, history) && !slices.Contains(historiesToCreate, history) {
+			historiesToUpdate = append(historiesToUpdate, history)
+		}
+		s.updateLearningStats(&history.LearningStats, result.Status)
+	}
+
+	if err := s.ConjugationRepo.UpdateHistories(historiesToUpdate); err != nil {
+		return err
+	}
+	return s.ConjugationRepo.InsertHistories(historiesToCreate)
+}
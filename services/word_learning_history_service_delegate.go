@@ -6,12 +6,20 @@ import (
 	"time"
 )
 
-func (s *WordLearningHistoryServiceImpl) updateHistoryBasicInfo(history *models.WordLearningHistory) {
+// updateLearningStats records the result of an answer and schedules the next review
+func (s *WordLearningHistoryServiceImpl) updateLearningStats(stats *models.LearningStats, status dto.ResultStatus) {
+	s.updateHistoryBasicInfo(stats)
+	s.updateHistoryStats(stats, status)
+	s.updateLearningStatus(stats)
+	s.calculateNextReviewDate(stats)
+}
+
+func (s *WordLearningHistoryServiceImpl) updateHistoryBasicInfo(history *models.LearningStats) {
 	history.LastViewedAt = time.Now()
 	history.AnswerCount++
 }
 
-func (s *WordLearningHistoryServiceImpl) updateHistoryStats(history *models.WordLearningHistory, status dto.ResultStatus) {
+func (s *WordLearningHistoryServiceImpl) updateHistoryStats(history *models.LearningStats, status dto.ResultStatus) {
 	switch status {
 	case dto.Success:
 		s.handleSuccessfulAnswer(history)
@@ -22,7 +30,7 @@ func (s *WordLearningHistoryServiceImpl) updateHistoryStats(history *models.Word
 	}
 }
 
-func (s *WordLearningHistoryServiceImpl) handleSuccessfulAnswer(history *models.WordLearningHistory) {
+func (s *WordLearningHistoryServiceImpl) handleSuccessfulAnswer(history *models.LearningStats) {
 	history.NbSuccess++
 	history.CurrentStreak++
 	if history.CurrentStreak > history.BestStreak {
@@ -30,17 +38,17 @@ func (s *WordLearningHistoryServiceImpl) handleSuccessfulAnswer(history *models.
 	}
 }
 
-func (s *WordLearningHistoryServiceImpl) handleFailedAnswer(history *models.WordLearningHistory) {
+func (s *WordLearningHistoryServiceImpl) handleFailedAnswer(history *models.LearningStats) {
 	history.NbErrors++
 	history.CurrentStreak = 0
 }
 
-func (s *WordLearningHistoryServiceImpl) handleUnansweredQuestion(history *models.WordLearningHistory) {
+func (s *WordLearningHistoryServiceImpl) handleUnansweredQuestion(history *models.LearningStats) {
 	history.NbUnanswered++
 	history.CurrentStreak = 0
 }
 
-func (s *WordLearningHistoryServiceImpl) updateLearningStatus(history *models.WordLearningHistory) {
+func (s *WordLearningHistoryServiceImpl) updateLearningStatus(history *models.LearningStats) {
 	totalAnswers := history.NbSuccess + history.NbErrors + history.NbUnanswered
 	successRate := float64(history.NbSuccess) / float64(totalAnswers+history.NbUnanswered)
 
@@ -56,7 +64,7 @@ func (s *WordLearningHistoryServiceImpl) updateLearningStatus(history *models.Wo
 	}
 }
 
-func (s *WordLearningHistoryServiceImpl) calculateNextReviewDate(history *models.WordLearningHistory) {
+func (s *WordLearningHistoryServiceImpl) calculateNextReviewDate(history *models.LearningStats) {
 	// Base interval according to status
 	var baseInterval time.Duration
 	switch history.LearningStatus {
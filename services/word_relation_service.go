@@ -0,0 +1,117 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/dto"
+	"github.com/xanagit/kotoquiz-api/models"
+	"github.com/xanagit/kotoquiz-api/repositories"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidWordRelation is returned when the type of a relation is unknown or when a word is related to itself
+var ErrInvalidWordRelation = errors.New("invalid word relation")
+
+// ErrWordRelationExists is returned when two words are already linked by a relation of the same type
+var ErrWordRelationExists = errors.New("word relation already exists")
+
+type WordRelationService interface {
+	ListWordRelations(wordID uuid.UUID) ([]*models.WordRelation, error)
+	ReadWordRelation(id uuid.UUID) (*models.WordRelation, error)
+	CreateWordRelation(relation *models.WordRelation) error
+	UpdateWordRelation(relation *models.WordRelation) error
+	DeleteWordRelation(id uuid.UUID) error
+	DetectHomophones() (*dto.HomophoneDetectionReport, error)
+}
+
+type WordRelationServiceImpl struct {
+	Repo     repositories.WordRelationRepository
+	WordRepo repositories.WordRepository
+}
+
+// Make sure that WordRelationServiceImpl implements WordRelationService
+var _ WordRelationService = (*WordRelationServiceImpl)(nil)
+
+// ListWordRelations lists the relations of an existing word
+func (s *WordRelationServiceImpl) ListWordRelations(wordID uuid.UUID) ([]*models.WordRelation, error) {
+	if _, err := s.WordRepo.ReadWord(wordID); err != nil {
+		return nil, err
+	}
+	return s.Repo.ListWordRelations(wordID)
+}
+
+func (s *WordRelationServiceImpl) ReadWordRelation(id uuid.UUID) (*models.WordRelation, error) {
+	return s.Repo.ReadWordRelation(id)
+}
+
+// CreateWordRelation links two existing words, a relation created by hand is never removed by the homophone detection
+// Linking by hand two words detected as homophones keeps the detected relation, which is then considered as set by hand
+func (s *WordRelationServiceImpl) CreateWordRelation(relation *models.WordRelation) error {
+	relation.ID = uuid.Nil
+	if err := s.prepareWordRelation(relation); err != nil {
+		return err
+	}
+	existing, err := s.Repo.FindWordRelation(relation.WordID, relation.RelatedWordID, relation.Type)
+	if err == nil && existing.Automatic {
+		relation.ID = existing.ID
+		return s.Repo.UpdateWordRelation(relation)
+	}
+	if err == nil {
+		return ErrWordRelationExists
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return s.Repo.CreateWordRelation(relation)
+}
+
+// UpdateWordRelation changes the words or the type of a relation, which is then considered as set by hand
+func (s *WordRelationServiceImpl) UpdateWordRelation(relation *models.WordRelation) error {
+	if _, err := s.Repo.ReadWordRelation(relation.ID); err != nil {
+		return err
+	}
+	if err := s.prepareWordRelation(relation); err != nil {
+		return err
+	}
+	existing, err := s.Repo.FindWordRelation(relation.WordID, relation.RelatedWordID, relation.Type)
+	if err == nil && existing.ID != relation.ID {
+		return ErrWordRelationExists
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return s.Repo.UpdateWordRelation(relation)
+}
+
+func (s *WordRelationServiceImpl) DeleteWordRelation(id uuid.UUID) error {
+	return s.Repo.DeleteWordRelation(id)
+}
+
+// DetectHomophones links all the words sharing their reading
+func (s *WordRelationServiceImpl) DetectHomophones() (*dto.HomophoneDetectionReport, error) {
+	created, removed, err := s.Repo.DetectHomophones()
+	if err != nil {
+		return nil, err
+	}
+	return &dto.HomophoneDetectionReport{Created: created, Removed: removed}, nil
+}
+
+// prepareWordRelation validates a relation set by hand and orders its words the way relations are stored
+func (s *WordRelationServiceImpl) prepareWordRelation(relation *models.WordRelation) error {
+	if !relation.Type.IsValid() || relation.WordID == relation.RelatedWordID {
+		return ErrInvalidWordRelation
+	}
+	for _, wordID := range []uuid.UUID{relation.WordID, relation.RelatedWordID} {
+		if _, err := s.WordRepo.ReadWord(wordID); err != nil {
+			return err
+		}
+	}
+
+	// Relations go both ways, they are stored once with the lowest ID first
+	if bytes.Compare(relation.WordID[:], relation.RelatedWordID[:]) > 0 {
+		relation.WordID, relation.RelatedWordID = relation.RelatedWordID, relation.WordID
+	}
+	relation.Automatic = false
+	return nil
+}
@@ -1,20 +1,49 @@
 package services
 
 import (
+	"errors"
 	"github.com/google/uuid"
+	"github.com/xanagit/kotoquiz-api/dto"
 	"github.com/xanagit/kotoquiz-api/models"
 	"github.com/xanagit/kotoquiz-api/repositories"
+	"gorm.io/gorm"
 )
 
+// ErrInvalidPartOfSpeech is returned when the part of speech or the transitivity of a word is unknown,
+// or when a transitivity is given to a word which is not a verb
+var ErrInvalidPartOfSpeech = errors.New("invalid part of speech or transitivity")
+
+// ErrInvalidWordMetadata is returned when the JLPT level of a word is not one of N5 to N1, or its frequency rank is negative
+var ErrInvalidWordMetadata = errors.New("invalid JLPT level or frequency rank")
+
+// ErrInvalidWordReference is returned when a word has no translation, or refers to a translation, a tag or a level
+// which does not exist, to a label of another type or to a custom level, or when a patch changes the texts of its translation
+var ErrInvalidWordReference = errors.New("missing translation, or invalid translation, tag or level reference")
+
+// ErrInvalidBulkOperation is returned when a bulk operation is unknown, lacks its parameters,
+// selects no word in a valid way or selects too many words
+var ErrInvalidBulkOperation = errors.New("invalid bulk operation")
+
+// maxBulkWords bounds the number of words changed by one bulk operation, so that its transaction stays short
+const maxBulkWords = 1000
+
 type WordService interface {
 	ReadWord(id uuid.UUID) (*models.Word, error)
-	CreateWord(word *models.Word) error
-	UpdateWord(word *models.Word) error
+	CreateWord(request *dto.WordWriteRequest) (*models.Word, error)
+	UpdateWord(id uuid.UUID, request *dto.WordWriteRequest) (*models.Word, error)
+	PatchWord(id uuid.UUID, patch []byte, version int) (*models.Word, error)
 	DeleteWord(id uuid.UUID) error
+	OverrideFurigana(id uuid.UUID, furigana []models.FuriganaSegment) (*models.Word, error)
+	ResetFurigana(id uuid.UUID) (*models.Word, error)
+	ApplyBulkOperation(request *dto.WordBulkRequest) (*dto.WordBulkReport, error)
 }
 
 type WordServiceImpl struct {
-	Repo repositories.WordRepository
+	Repo         repositories.WordRepository
+	KanjiRepo    repositories.KanjiRepository
+	RelationRepo repositories.WordRelationRepository
+	LabelRepo    repositories.LabelRepository
+	LevelRepo    repositories.LevelRepository
 }
 
 // Make sure that WordServiceImpl implements WordService
@@ -24,25 +53,511 @@ func (s *WordServiceImpl) ReadWord(id uuid.UUID) (*models.Word, error) {
 	return s.Repo.ReadWord(id)
 }
 
-func (s *WordServiceImpl) CreateWord(word *models.Word) error {
-	word.ID = uuid.Nil
-	word.Translation.Type = models.Translation
-	for _, t := range word.Tags {
-		t.Type = models.Tag
+// CreateWord creates a word from the request, with the translation and the tags given without ID
+func (s *WordServiceImpl) CreateWord(request *dto.WordWriteRequest) (*models.Word, error) {
+	word, err := s.buildWord(request, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.prepareWord(word); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.CreateWord(word); err != nil {
+		return nil, err
+	}
+	return s.linkWord(word)
+}
+
+// UpdateWord updates a word from the request
+// Its tags, built-in levels, senses, readings and pitch accents are replaced by the given ones
+func (s *WordServiceImpl) UpdateWord(id uuid.UUID, request *dto.WordWriteRequest) (*models.Word, error) {
+	existing, err := s.Repo.ReadWord(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.saveWord(existing, request, 0)
+}
+
+// PatchWord applies a JSON merge patch to the write form of a word, the members missing from the patch being kept
+// When version is not 0 the word must still be at this version
+func (s *WordServiceImpl) PatchWord(id uuid.UUID, patch []byte, version int) (*models.Word, error) {
+	existing, err := s.Repo.ReadWord(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(existing.Version, version); err != nil {
+		return nil, err
+	}
+	request := newWordWriteRequest(existing)
+	if err := applyMergePatch(request, patch); err != nil {
+		return nil, err
+	}
+	// The texts patched onto the referenced translation would be ignored, a new translation is given without ID
+	if request.Translation.ID != uuid.Nil && (request.Translation.En != "" || request.Translation.Fr != "") {
+		return nil, ErrInvalidWordReference
+	}
+	// The patch is based on the version read above
+	return s.saveWord(existing, request, existing.Version)
+}
+
+// saveWord replaces an existing word by the one described by the request
+func (s *WordServiceImpl) saveWord(existing *models.Word, request *dto.WordWriteRequest, version int) (*models.Word, error) {
+	word, err := s.buildWord(request, existing)
+	if err != nil {
+		return nil, err
 	}
-	for _, t := range word.Levels {
-		t.Category.Type = models.Category
-		for _, l := range t.LevelNames {
-			l.Type = models.LevelName
+	word.ID = existing.ID
+	word.Version = version
+	if err := s.prepareWord(word); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.UpdateWord(word); err != nil {
+		return nil, err
+	}
+	return s.linkWord(word)
+}
+
+// buildWord turns a write request into a word, checking that the referenced translation, tags and levels exist
+// The translation is required, either referenced by ID or given with at least one text
+// Referenced labels are left empty on the word so that saving it never changes them
+// The existing word is nil on creation
+func (s *WordServiceImpl) buildWord(request *dto.WordWriteRequest, existing *models.Word) (*models.Word, error) {
+	word := &models.Word{
+		Kanji:            request.Kanji,
+		Yomi:             request.Yomi,
+		YomiType:         request.YomiType,
+		ImageURL:         request.ImageURL,
+		PartOfSpeech:     request.PartOfSpeech,
+		Transitivity:     request.Transitivity,
+		JlptLevel:        request.JlptLevel,
+		FrequencyRank:    request.FrequencyRank,
+		Senses:           request.Senses,
+		Readings:         request.Readings,
+		PitchAccents:     request.PitchAccents,
+		Furigana:         request.Furigana,
+		FuriganaOverride: request.FuriganaOverride,
+	}
+	// Owned children are created again on each write, they only keep their IDs when they belong to the existing word.
+	// Translations of senses are always created again so that their texts are saved
+	children := ownedChildIDs(existing)
+	for _, sense := range word.Senses {
+		if !children[sense.ID] {
+			sense.ID = uuid.Nil
+		}
+		for _, t := range sense.Translations {
+			t.ID = uuid.Nil
+		}
+	}
+	for _, reading := range word.Readings {
+		if !children[reading.ID] {
+			reading.ID = uuid.Nil
+		}
+	}
+	for _, accent := range word.PitchAccents {
+		if !children[accent.ID] {
+			accent.ID = uuid.Nil
+		}
+	}
+
+	if request.Translation.ID != uuid.Nil {
+		if err := s.checkLabel(request.Translation.ID, models.Translation); err != nil {
+			return nil, err
+		}
+		word.TranslationID = request.Translation.ID
+	} else if request.Translation.En != "" || request.Translation.Fr != "" {
+		word.Translation = models.Label{En: request.Translation.En, Fr: request.Translation.Fr, Type: models.Translation}
+	} else {
+		return nil, ErrInvalidWordReference
+	}
+
+	seenTags := make(map[uuid.UUID]bool, len(request.Tags))
+	for _, tag := range request.Tags {
+		if tag.ID == uuid.Nil {
+			word.Tags = append(word.Tags, &models.Label{En: tag.En, Fr: tag.Fr, Type: models.Tag})
+			continue
+		}
+		if seenTags[tag.ID] {
+			continue
+		}
+		seenTags[tag.ID] = true
+		if err := s.checkLabel(tag.ID, models.Tag); err != nil {
+			return nil, err
 		}
+		word.Tags = append(word.Tags, &models.Label{ID: tag.ID})
 	}
-	return s.Repo.CreateWord(word)
+
+	seenLevels := make(map[uuid.UUID]bool, len(request.Levels))
+	for _, ref := range request.Levels {
+		if seenLevels[ref.ID] {
+			continue
+		}
+		seenLevels[ref.ID] = true
+		level, err := s.LevelRepo.ReadLevel(ref.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && level.IsCustom()) {
+			return nil, ErrInvalidWordReference
+		}
+		if err != nil {
+			return nil, err
+		}
+		word.Levels = append(word.Levels, &models.Level{ID: ref.ID})
+	}
+	return word, nil
+}
+
+// ownedChildIDs lists the IDs of the senses, readings and pitch accents of a word, nil when there is no word
+func ownedChildIDs(word *models.Word) map[uuid.UUID]bool {
+	if word == nil {
+		return nil
+	}
+	ids := make(map[uuid.UUID]bool, len(word.Senses)+len(word.Readings)+len(word.PitchAccents))
+	for _, sense := range word.Senses {
+		ids[sense.ID] = true
+	}
+	for _, reading := range word.Readings {
+		ids[reading.ID] = true
+	}
+	for _, accent := range word.PitchAccents {
+		ids[accent.ID] = true
+	}
+	return ids
+}
+
+// newWordWriteRequest gives the write form of a word, its translation, tags and levels being referenced by ID
+func newWordWriteRequest(word *models.Word) *dto.WordWriteRequest {
+	request := &dto.WordWriteRequest{
+		Kanji:            word.Kanji,
+		Yomi:             word.Yomi,
+		YomiType:         word.YomiType,
+		ImageURL:         word.ImageURL,
+		PartOfSpeech:     word.PartOfSpeech,
+		Transitivity:     word.Transitivity,
+		JlptLevel:        word.JlptLevel,
+		FrequencyRank:    word.FrequencyRank,
+		Translation:      dto.LabelInput{ID: word.TranslationID},
+		Senses:           word.Senses,
+		Readings:         word.Readings,
+		PitchAccents:     word.PitchAccents,
+		Furigana:         word.Furigana,
+		FuriganaOverride: word.FuriganaOverride,
+	}
+	for _, tag := range word.Tags {
+		request.Tags = append(request.Tags, &dto.LabelInput{ID: tag.ID})
+	}
+	for _, level := range word.Levels {
+		request.Levels = append(request.Levels, &dto.LevelRef{ID: level.ID})
+	}
+	return request
+}
+
+// checkLabel checks that a label referenced by a word exists and has the expected type
+func (s *WordServiceImpl) checkLabel(id uuid.UUID, labelType models.LabelType) error {
+	label, err := s.LabelRepo.ReadLabel(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && label.Type != labelType) {
+		return ErrInvalidWordReference
+	}
+	return err
+}
+
+// prepareWord validates the metadata of the word and prepares its owned children and its furigana
+func (s *WordServiceImpl) prepareWord(word *models.Word) error {
+	if err := validatePartOfSpeech(word); err != nil {
+		return err
+	}
+	if err := validateWordMetadata(word); err != nil {
+		return err
+	}
+	prepareSenses(word)
+	prepareReadings(word)
+	preparePitchAccents(word)
+	return s.prepareFurigana(word)
+}
+
+// linkWord links a saved word to its kanji and its homophones, then reads it back with its associations
+func (s *WordServiceImpl) linkWord(word *models.Word) (*models.Word, error) {
+	if err := s.KanjiRepo.LinkWordKanji(word); err != nil {
+		return nil, err
+	}
+	if err := s.RelationRepo.RefreshWordHomophones(word.ID); err != nil {
+		return nil, err
+	}
+	return s.Repo.ReadWord(word.ID)
 }
 
-func (s *WordServiceImpl) UpdateWord(word *models.Word) error {
-	return s.Repo.UpdateWord(word)
+// validatePartOfSpeech checks the grammatical class of the word, which is optional
+func validatePartOfSpeech(word *models.Word) error {
+	if word.PartOfSpeech != "" && !word.PartOfSpeech.IsValid() {
+		return ErrInvalidPartOfSpeech
+	}
+	if word.Transitivity != "" && (!word.Transitivity.IsValid() || !word.PartOfSpeech.IsVerb()) {
+		return ErrInvalidPartOfSpeech
+	}
+	return nil
+}
+
+// validateWordMetadata checks the JLPT level and the frequency rank of the word, which are optional
+func validateWordMetadata(word *models.Word) error {
+	if (word.JlptLevel != 0 && !word.JlptLevel.IsValid()) || word.FrequencyRank < 0 {
+		return ErrInvalidWordMetadata
+	}
+	return nil
+}
+
+// prepareSenses numbers the senses according to their order in the list
+// and types their translations
+func prepareSenses(word *models.Word) {
+	for i, sense := range word.Senses {
+		sense.Position = i
+		for _, t := range sense.Translations {
+			t.Type = models.Translation
+		}
+	}
 }
 
+// DeleteWord moves a word to the trash, its media are kept until it is purged
 func (s *WordServiceImpl) DeleteWord(id uuid.UUID) error {
 	return s.Repo.DeleteWord(id)
 }
+
+// OverrideFurigana replaces the computed furigana of a word by the given segments
+// The segments must spell the kanji of the word
+func (s *WordServiceImpl) OverrideFurigana(id uuid.UUID, furigana []models.FuriganaSegment) (*models.Word, error) {
+	word, err := s.Repo.ReadWord(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateFurigana(word.Kanji, furigana); err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.UpdateWordFurigana(id, furigana, true); err != nil {
+		return nil, err
+	}
+	word.Furigana = furigana
+	word.FuriganaOverride = true
+	return word, nil
+}
+
+// ResetFurigana drops the furigana set by hand and computes them again
+func (s *WordServiceImpl) ResetFurigana(id uuid.UUID) (*models.Word, error) {
+	word, err := s.Repo.ReadWord(id)
+	if err != nil {
+		return nil, err
+	}
+
+	word.FuriganaOverride = false
+	if err := s.prepareFurigana(word); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.UpdateWordFurigana(id, word.Furigana, false); err != nil {
+		return nil, err
+	}
+	return word, nil
+}
+
+// prepareFurigana computes the furigana of the word, unless valid ones were set by hand
+func (s *WordServiceImpl) prepareFurigana(word *models.Word) error {
+	if word.FuriganaOverride && validateFurigana(word.Kanji, word.Furigana) == nil {
+		return nil
+	}
+
+	furigana, err := computeFurigana(s.KanjiRepo, word.Kanji, word.Yomi)
+	if err != nil {
+		return err
+	}
+	word.Furigana = furigana
+	word.FuriganaOverride = false
+	return nil
+}
+
+// prepareReadings makes sure exactly one reading is primary and mirrors it in Yomi and YomiType
+func prepareReadings(word *models.Word) {
+	if len(word.Readings) == 0 {
+		return
+	}
+
+	var primary *models.WordReading
+	for _, r := range word.Readings {
+		if r.IsPrimary && primary == nil {
+			primary = r
+		} else {
+			r.IsPrimary = false
+		}
+	}
+	if primary == nil {
+		primary = word.Readings[0]
+		primary.IsPrimary = true
+	}
+
+	word.Yomi = primary.Reading
+	word.YomiType = primary.Type
+}
+
+// ApplyBulkOperation applies an operation to the words given by their IDs or selected by a filter
+// The changes are made within a single transaction, a report telling what happened to each word.
+// Given IDs of words which do not exist are reported and skipped
+func (s *WordServiceImpl) ApplyBulkOperation(request *dto.WordBulkRequest) (*dto.WordBulkReport, error) {
+	if err := s.validateBulkRequest(request); err != nil {
+		return nil, err
+	}
+	selected, err := s.selectBulkWords(request)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.Repo.ListExistingWordIds(selected)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &dto.WordBulkReport{Operation: request.Operation, Matched: len(existing), Results: []*dto.WordBulkResult{}}
+	results := make(map[uuid.UUID]*dto.WordBulkResult, len(selected))
+	for _, id := range selected {
+		result := &dto.WordBulkResult{WordID: id, Status: dto.BulkNotFound}
+		results[id] = result
+		report.Results = append(report.Results, result)
+	}
+	for _, id := range existing {
+		results[id].Status = dto.BulkUnchanged
+	}
+	if len(existing) == 0 {
+		return report, nil
+	}
+
+	if request.Operation == dto.DeleteWords {
+		if err := s.Repo.DeleteWords(existing); err != nil {
+			return nil, err
+		}
+		for _, id := range existing {
+			results[id].Status = dto.BulkDeleted
+		}
+		report.Changed = len(existing)
+		return report, nil
+	}
+	changed, err := s.Repo.BulkUpdateWords(existing, request)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range changed {
+		results[id].Status = dto.BulkUpdated
+	}
+	report.Changed = len(changed)
+	return report, nil
+}
+
+// validateBulkRequest checks the operation, its parameters and the way the words are selected
+// Tags must be existing tags and levels existing built-in levels
+func (s *WordServiceImpl) validateBulkRequest(request *dto.WordBulkRequest) error {
+	if !request.Operation.IsValid() {
+		return ErrInvalidBulkOperation
+	}
+	if (len(request.WordIDs) > 0) == (request.Filter != nil) {
+		return ErrInvalidBulkOperation
+	}
+	if request.Filter != nil {
+		if err := validateBulkFilter(request.Filter); err != nil {
+			return err
+		}
+	}
+
+	switch request.Operation {
+	case dto.AddTags, dto.RemoveTags:
+		if len(request.TagIDs) == 0 {
+			return ErrInvalidBulkOperation
+		}
+		for _, id := range request.TagIDs {
+			if err := s.checkLabel(id, models.Tag); err != nil {
+				return err
+			}
+		}
+	case dto.AddLevels, dto.RemoveLevels:
+		if len(request.LevelIDs) == 0 {
+			return ErrInvalidBulkOperation
+		}
+		for _, id := range request.LevelIDs {
+			level, err := s.LevelRepo.ReadLevel(id)
+			if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && level.IsCustom()) {
+				return ErrInvalidWordReference
+			}
+			if err != nil {
+				return err
+			}
+		}
+	case dto.SetYomiType:
+		if request.YomiType != models.Onyomi && request.YomiType != models.Kunyomi {
+			return ErrInvalidBulkOperation
+		}
+	}
+	return nil
+}
+
+// validateBulkFilter checks the criteria of a filter selecting the words of a bulk operation
+// At least one criterion is required, so that an empty filter never selects the whole dictionary
+func validateBulkFilter(filter *dto.WordFilter) error {
+	if len(filter.TagIds) == 0 && len(filter.LevelIds) == 0 && len(filter.LevelNameIds) == 0 &&
+		len(filter.ReadingTypes) == 0 && filter.Reading == "" && len(filter.PartsOfSpeech) == 0 &&
+		filter.Transitivity == "" && len(filter.JlptLevels) == 0 && filter.MaxFrequencyRank == 0 {
+		return ErrInvalidBulkOperation
+	}
+	for _, ids := range [][]string{filter.TagIds, filter.LevelIds, filter.LevelNameIds} {
+		for _, id := range ids {
+			if _, err := uuid.Parse(id); err != nil {
+				return ErrInvalidBulkOperation
+			}
+		}
+	}
+	for _, readingType := range filter.ReadingTypes {
+		if readingType != models.Onyomi && readingType != models.Kunyomi {
+			return ErrInvalidBulkOperation
+		}
+	}
+	for _, partOfSpeech := range filter.PartsOfSpeech {
+		if !partOfSpeech.IsValid() {
+			return ErrInvalidBulkOperation
+		}
+	}
+	if filter.Transitivity != "" && !filter.Transitivity.IsValid() {
+		return ErrInvalidBulkOperation
+	}
+	for _, level := range filter.JlptLevels {
+		if !level.IsValid() {
+			return ErrInvalidBulkOperation
+		}
+	}
+	if filter.MaxFrequencyRank < 0 {
+		return ErrInvalidBulkOperation
+	}
+	return nil
+}
+
+// selectBulkWords lists the distinct IDs of the words given by the request, in their order, or those matching its filter
+func (s *WordServiceImpl) selectBulkWords(request *dto.WordBulkRequest) ([]uuid.UUID, error) {
+	var selected []uuid.UUID
+	if request.Filter != nil {
+		// The filter only selects, the order of the words does not matter
+		filter := *request.Filter
+		filter.Sort = ""
+		// One more word than the limit is read to detect a filter selecting too many words
+		rawIDs, err := s.Repo.ListWordsIds(&filter, maxBulkWords+1)
+		if err != nil {
+			return nil, err
+		}
+		for _, rawID := range rawIDs {
+			id, err := uuid.Parse(rawID)
+			if err != nil {
+				return nil, err
+			}
+			selected = append(selected, id)
+		}
+	} else {
+		seen := make(map[uuid.UUID]bool, len(request.WordIDs))
+		for _, id := range request.WordIDs {
+			if !seen[id] {
+				seen[id] = true
+				selected = append(selected, id)
+			}
+		}
+	}
+	if len(selected) > maxBulkWords {
+		return nil, ErrInvalidBulkOperation
+	}
+	return selected, nil
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"github.com/google/uuid"
 	"github.com/xanagit/kotoquiz-api/dto"
 	"github.com/xanagit/kotoquiz-api/models"
 )
@@ -16,8 +17,62 @@ func mapWordToDTO(word *models.Word, lang string) *dto.WordDTO {
 		mappedTags = append(mappedTags, extractLabel(tag, lang))
 	}
 
-	// Filtrer la Translation en string
+	// Filtrer les sens du mot, dans leur ordre
+	var mappedSenses []*dto.SenseDTO
+	for _, sense := range word.Senses {
+		var mappedSenseTranslations []string
+		for _, translation := range sense.Translations {
+			mappedSenseTranslations = append(mappedSenseTranslations, extractLabel(translation, lang))
+		}
+		mappedSenses = append(mappedSenses, &dto.SenseDTO{
+			Translations: mappedSenseTranslations,
+			PartOfSpeech: sense.PartOfSpeech,
+			Notes:        sense.Notes,
+		})
+	}
+
+	// Filtrer la Translation en string, à défaut la première traduction du premier sens
 	mappedTranslation := extractLabel(&word.Translation, lang)
+	if mappedTranslation == "" && len(mappedSenses) > 0 && len(mappedSenses[0].Translations) > 0 {
+		mappedTranslation = mappedSenses[0].Translations[0]
+	}
+
+	// Lister les lectures, à défaut la lecture principale du mot
+	var mappedReadings []*dto.ReadingDTO
+	for _, reading := range word.Readings {
+		mappedReadings = append(mappedReadings, &dto.ReadingDTO{
+			Reading: reading.Reading,
+			Type:    reading.Type,
+			Primary: reading.IsPrimary,
+		})
+	}
+	if len(mappedReadings) == 0 && word.Yomi != "" {
+		mappedReadings = append(mappedReadings, &dto.ReadingDTO{
+			Reading: word.Yomi,
+			Type:    word.YomiType,
+			Primary: true,
+		})
+	}
+
+	// Furigana enregistrés, à défaut alignés à la volée sans les lectures des kanji
+	furigana := word.Furigana
+	if furigana == nil {
+		furigana = alignFurigana(word.Kanji, word.Yomi, nil)
+	}
+
+	// Découper la lecture en mores pour chaque accent
+	var mappedPitch []*dto.PitchDTO
+	morae := splitMorae(word.Yomi)
+	for _, accent := range word.PitchAccents {
+		high, particleHigh := pitchHeights(accent.Downstep, len(morae))
+		mappedPitch = append(mappedPitch, &dto.PitchDTO{
+			Pattern:      accent.Pattern,
+			Downstep:     accent.Downstep,
+			Morae:        morae,
+			High:         high,
+			ParticleHigh: particleHigh,
+		})
+	}
 
 	// Filtrer les Levels
 	var mappedLevels []*dto.LevelDTO
@@ -40,14 +95,38 @@ func mapWordToDTO(word *models.Word, lang string) *dto.WordDTO {
 
 	// Construire et retourner un WordDTO
 	return &dto.WordDTO{
-		ID:          word.ID,
-		Kanji:       word.Kanji,
-		Yomi:        word.Yomi,
-		YomiType:    word.YomiType,
-		ImageURL:    word.ImageURL,
-		Translation: mappedTranslation,
-		Tags:        mappedTags,
-		Levels:      mappedLevels,
+		ID:            word.ID,
+		Kanji:         word.Kanji,
+		Yomi:          word.Yomi,
+		YomiType:      word.YomiType,
+		PartOfSpeech:  word.PartOfSpeech,
+		Transitivity:  word.Transitivity,
+		JlptLevel:     word.JlptLevel,
+		FrequencyRank: word.FrequencyRank,
+		ImageURL:      word.ImageURL,
+		Translation:   mappedTranslation,
+		Tags:          mappedTags,
+		Levels:        mappedLevels,
+		Senses:        mappedSenses,
+		Readings:      mappedReadings,
+		Furigana:      furigana,
+		Pitch:         mappedPitch,
+	}
+}
+
+func mapExampleSentenceToDTO(sentence *models.ExampleSentence, lang string) *dto.ExampleSentenceDTO {
+	furigana := sentence.Furigana
+	if furigana == nil {
+		furigana = alignFurigana(sentence.Japanese, sentence.Reading, nil)
+	}
+
+	return &dto.ExampleSentenceDTO{
+		ID:          sentence.ID,
+		Japanese:    sentence.Japanese,
+		Reading:     sentence.Reading,
+		Translation: extractLabel(&sentence.Translation, lang),
+		Source:      sentence.Source,
+		Furigana:    furigana,
 	}
 }
 
@@ -64,3 +143,27 @@ func extractLabel(label *models.Label, lang string) string {
 		return ""
 	}
 }
+
+func setLabelText(label *models.Label, lang string, text string) {
+	switch lang {
+	case "en":
+		label.En = text
+	case "fr":
+		label.Fr = text
+	}
+}
+
+// mapRelatedWordToDTO construit le DTO de l'autre mot de la relation, vu depuis le mot donné
+func mapRelatedWordToDTO(wordID uuid.UUID, relation *models.WordRelation, lang string) *dto.RelatedWordDTO {
+	related := &relation.RelatedWord
+	if relation.RelatedWordID == wordID {
+		related = &relation.Word
+	}
+	return &dto.RelatedWordDTO{
+		ID:          related.ID,
+		Kanji:       related.Kanji,
+		Yomi:        related.Yomi,
+		Translation: extractLabel(&related.Translation, lang),
+		Type:        relation.Type,
+	}
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalMediaRoute is the route the media of the local storage are served from
+const LocalMediaRoute = "/media"
+
+// LocalMediaStorage stores the media as files of a local directory, served by the API itself
+type LocalMediaStorage struct {
+	// Dir is the directory holding the files
+	Dir string
+	// PublicURL is the base URL of the API
+	PublicURL string
+}
+
+// Make sure that LocalMediaStorage implements MediaStorage
+var _ MediaStorage = (*LocalMediaStorage)(nil)
+
+func (s *LocalMediaStorage) Save(key string, _ string, content io.Reader, _ int64) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that a failed upload never leaves a truncated file
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *LocalMediaStorage) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalMediaStorage) URL(key string) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(s.PublicURL, "/") + LocalMediaRoute + "/" + key, nil
+}
+
+// path returns the file of a key, which must stay inside the storage directory
+func (s *LocalMediaStorage) path(key string) (string, error) {
+	if key == "" || !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", errors.New("invalid media key: " + key)
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(key)), nil
+}
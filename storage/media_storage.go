@@ -0,0 +1,33 @@
+// Package storage implements the backends storing the media attached to words
+// Media are addressed by keys, slash-separated paths such as "words/<id>/audio-<id>.mp3"
+package storage
+
+import (
+	"github.com/xanagit/kotoquiz-api/config"
+	"io"
+)
+
+// MediaStorage defines the operations of a media storage backend
+type MediaStorage interface {
+	// Save stores the content under the given key, replacing any previous content
+	Save(key string, contentType string, content io.Reader, size int64) error
+	// Delete removes the content stored under the given key, a missing key is not an error
+	Delete(key string) error
+	// URL returns the URL clients can download the content of the key from
+	URL(key string) (string, error)
+}
+
+// NewMediaStorage creates the storage backend selected in the configuration
+// The local filesystem is used unless the "s3" backend is configured
+//
+// Parameters:
+//   - cfg: *config.MediaConfig - The media configuration
+//
+// Returns:
+//   - MediaStorage - The configured storage backend
+func NewMediaStorage(cfg *config.MediaConfig) MediaStorage {
+	if cfg.Backend == "s3" {
+		return &S3MediaStorage{Config: &cfg.S3, URLExpiry: cfg.URLExpiry}
+	}
+	return &LocalMediaStorage{Dir: cfg.LocalDir, PublicURL: cfg.PublicURL}
+}
@@ -0,0 +1,256 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/xanagit/kotoquiz-api/config"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm       = "AWS4-HMAC-SHA256"
+	s3Service         = "s3"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3DateFormat      = "20060102T150405Z"
+	// s3MaxURLExpiry is the longest validity S3 accepts for a presigned URL (7 days)
+	s3MaxURLExpiry = 7 * 24 * 3600
+)
+
+// S3MediaStorage stores the media in a bucket of an S3-compatible object storage (AWS S3, MinIO...)
+// Requests are signed with AWS Signature Version 4, media are downloaded through presigned URLs
+type S3MediaStorage struct {
+	Config *config.S3Config
+	// URLExpiry is the validity in seconds of the presigned URLs
+	URLExpiry int
+	// Client sends the requests to the storage, http.DefaultClient when nil
+	Client *http.Client
+}
+
+// Make sure that S3MediaStorage implements MediaStorage
+var _ MediaStorage = (*S3MediaStorage)(nil)
+
+func (s *S3MediaStorage) Save(key string, contentType string, content io.Reader, size int64) error {
+	objectURL, err := s.objectURL(key)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPut, objectURL.String(), content)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	return s.send(req)
+}
+
+func (s *S3MediaStorage) Delete(key string) error {
+	objectURL, err := s.objectURL(key)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodDelete, objectURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	// S3 answers 204 No Content to the deletion of a missing object
+	return s.send(req)
+}
+
+// URL returns a presigned GET URL of the object, valid for URLExpiry seconds
+func (s *S3MediaStorage) URL(key string) (string, error) {
+	return s.presignedURL(key, time.Now().UTC())
+}
+
+// presignedURL signs a GET URL of the object at the given time
+func (s *S3MediaStorage) presignedURL(key string, now time.Time) (string, error) {
+	objectURL, err := s.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+
+	expiry := s.URLExpiry
+	if expiry <= 0 || expiry > s3MaxURLExpiry {
+		expiry = s3MaxURLExpiry
+	}
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", s.Config.AccessKey+"/"+s.credentialScope(now))
+	query.Set("X-Amz-Date", now.Format(s3DateFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(expiry))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalQuery := canonicalQueryString(query)
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		objectURL.EscapedPath(),
+		canonicalQuery,
+		"host:" + objectURL.Host + "\n",
+		"host",
+		s3UnsignedPayload,
+	}, "\n")
+
+	objectURL.RawQuery = canonicalQuery + "&X-Amz-Signature=" + s.signature(now, canonicalRequest)
+	return objectURL.String(), nil
+}
+
+// send signs the request in its Authorization header and checks the response status
+func (s *S3MediaStorage) send(req *http.Request) error {
+	now := time.Now().UTC()
+	req.Header.Set("X-Amz-Date", now.Format(s3DateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedPayload)
+
+	signedHeaders, canonicalHeaders := canonicalHeaderList(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQueryString(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		s3UnsignedPayload,
+	}, "\n")
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.Config.AccessKey, s.credentialScope(now), signedHeaders, s.signature(now, canonicalRequest)))
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 %s %s failed with status %d: %s", req.Method, req.URL.Path, resp.StatusCode, body)
+	}
+	return nil
+}
+
+// objectURL builds the URL of an object, with the bucket in the path or in the host name
+func (s *S3MediaStorage) objectURL(key string) (*url.URL, error) {
+	if key == "" {
+		return nil, fmt.Errorf("invalid media key: %s", key)
+	}
+	endpoint, err := url.Parse(s.Config.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint: %s", s.Config.Endpoint)
+	}
+
+	path := "/" + key
+	if s.Config.PathStyle {
+		path = "/" + s.Config.Bucket + path
+	} else {
+		endpoint.Host = s.Config.Bucket + "." + endpoint.Host
+	}
+	endpoint.Path = strings.TrimSuffix(endpoint.Path, "/") + path
+	endpoint.RawPath = s3EscapePath(endpoint.Path)
+	return endpoint, nil
+}
+
+// credentialScope returns the scope of the signing key for the given date
+func (s *S3MediaStorage) credentialScope(t time.Time) string {
+	return t.Format("20060102") + "/" + s.Config.Region + "/" + s3Service + "/aws4_request"
+}
+
+// signature signs the canonical request with a key derived from the secret key, the date and the region
+func (s *S3MediaStorage) signature(t time.Time, canonicalRequest string) string {
+	hashedRequest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		t.Format(s3DateFormat),
+		s.credentialScope(t),
+		hex.EncodeToString(hashedRequest[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.Config.SecretKey), t.Format("20060102"))
+	key = hmacSHA256(key, s.Config.Region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalHeaderList returns the signed header names and the canonical headers of a request
+// The host and the content type are signed along with the x-amz-* headers
+func canonicalHeaderList(req *http.Request) (string, string) {
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + headers[name] + "\n")
+	}
+	return strings.Join(names, ";"), canonical.String()
+}
+
+// canonicalQueryString sorts and encodes the query parameters as required by the signature
+func canonicalQueryString(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		values := append([]string{}, query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, s3Escape(key, true)+"="+s3Escape(value, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// s3EscapePath encodes each segment of a path, keeping the slashes
+func s3EscapePath(path string) string {
+	return s3Escape(path, false)
+}
+
+// s3Escape percent-encodes every byte but the unreserved characters of RFC 3986
+// Slashes are kept unless encodeSlash is set
+func s3Escape(value string, encodeSlash bool) string {
+	var escaped strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			escaped.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			escaped.WriteByte(c)
+		default:
+			fmt.Fprintf(&escaped, "%%%02X", c)
+		}
+	}
+	return escaped.String()
+}